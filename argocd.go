@@ -0,0 +1,126 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var argoApplicationKind = schema.GroupKind{Group: "argoproj.io", Kind: "Application"}
+
+func isArgoApplication(obj *unstructured.Unstructured) bool {
+	return obj.GroupVersionKind().GroupKind() == argoApplicationKind
+}
+
+// Argo CD refers to the cluster it runs in with this server URL and name.
+const (
+	argoInClusterServer = "https://kubernetes.default.svc"
+	argoInClusterName   = "in-cluster"
+)
+
+// argoDestination returns the cluster the Application deploys to, and
+// whether it's the cluster Argo CD (and the Application) runs in.
+func argoDestination(app *unstructured.Unstructured) (string, bool) {
+	server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+	name, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+	switch {
+	case server == "" && name == "":
+		// the destination defaults to the cluster Argo CD runs in
+		return argoInClusterName, true
+	case server != "":
+		return server, server == argoInClusterServer
+	default:
+		return name, name == argoInClusterName
+	}
+}
+
+// expandApplication returns the reports of the resources managed by the
+// Argo CD Application, as listed in its status.resources[]. Resources that
+// can't be found or queried are listed in the header of the Application.
+// Applications deploying to other clusters aren't expanded, as their
+// resources can't be queried in this one.
+func expandApplication(ctx context.Context, kc *kubeClient, app *objectReport) []*objectReport {
+	resources, _, _ := unstructured.NestedSlice(app.Object.Object, "status", "resources")
+	if len(resources) == 0 {
+		return nil
+	}
+	if dest, inCluster := argoDestination(app.Object); !inCluster {
+		app.Header = append(app.Header, gray.Sprintf("Manages %d resources in the remote cluster %s (not shown)", len(resources), dest))
+		return nil
+	}
+	mapper, err := kc.configFlags.ToRESTMapper()
+	if err != nil {
+		app.Header = append(app.Header, gray.Sprintf("Failed to expand managed resources: %v", err))
+		return nil
+	}
+	dyn, err := kc.Dynamic()
+	if err != nil {
+		app.Header = append(app.Header, gray.Sprintf("Failed to expand managed resources: %v", err))
+		return nil
+	}
+
+	var out []*objectReport
+	var noConditions int
+	for _, v := range resources {
+		res, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(res, "group")
+		version, _, _ := unstructured.NestedString(res, "version")
+		kind, _, _ := unstructured.NestedString(res, "kind")
+		namespace, _, _ := unstructured.NestedString(res, "namespace")
+		name, _, _ := unstructured.NestedString(res, "name")
+		ref := kind + " " + name
+		if namespace != "" {
+			ref = kind + " " + namespace + "/" + name
+		}
+
+		obj, err := func() (*unstructured.Unstructured, error) {
+			m, err := mapper.RESTMapping(schema.GroupKind{Group: group, Kind: kind}, version)
+			if err != nil {
+				return nil, err
+			}
+			return dyn.Resource(m.Resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		}()
+		if apierrors.IsNotFound(err) {
+			app.Header = append(app.Header, color.YellowString("Managed resource %s does not exist", ref))
+			continue
+		} else if err != nil {
+			app.Header = append(app.Header, gray.Sprintf("Failed to get managed resource %s: %v", ref, err))
+			continue
+		}
+		r, err := newObjectReport(ctx, kc, obj)
+		if errors.Is(err, errNoConditions) {
+			noConditions++
+			continue
+		} else if err != nil {
+			app.Header = append(app.Header, gray.Sprintf("Failed to process managed resource %s: %v", ref, err))
+			continue
+		}
+		out = append(out, r)
+	}
+	app.Header = append(app.Header, fmt.Sprintf("Manages %d resources (%d without conditions not shown)", len(resources), noConditions))
+	return out
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestArgoDestination(t *testing.T) {
+	tests := []struct {
+		destination   map[string]any
+		want          string
+		wantInCluster bool
+	}{
+		{destination: nil, want: "in-cluster", wantInCluster: true},
+		{destination: map[string]any{"server": "https://kubernetes.default.svc", "namespace": "prod"}, want: "https://kubernetes.default.svc", wantInCluster: true},
+		{destination: map[string]any{"name": "in-cluster"}, want: "in-cluster", wantInCluster: true},
+		{destination: map[string]any{"server": "https://prod.example.com:6443"}, want: "https://prod.example.com:6443"},
+		{destination: map[string]any{"name": "prod"}, want: "prod"},
+	}
+	for _, tt := range tests {
+		app := &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{}}}
+		if tt.destination != nil {
+			app.Object["spec"] = map[string]any{"destination": tt.destination}
+		}
+		if got, inCluster := argoDestination(app); got != tt.want || inCluster != tt.wantInCluster {
+			t.Errorf("argoDestination(%v) = %s, %v, want %s, %v", tt.destination, got, inCluster, tt.want, tt.wantInCluster)
+		}
+	}
+}
+
+func TestExpandApplicationRemoteCluster(t *testing.T) {
+	defer func(v bool) { color.NoColor = v }(color.NoColor)
+	color.NoColor = true
+	app := &objectReport{Object: &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"destination": map[string]any{"name": "prod"}},
+		"status": map[string]any{"resources": []any{
+			map[string]any{"version": "v1", "kind": "Service", "namespace": "web", "name": "web"},
+		}},
+	}}}
+	// remote resources are not queried, so no client is needed
+	if got := expandApplication(context.Background(), nil, app); got != nil {
+		t.Errorf("expandApplication() = %v, want no reports", got)
+	}
+	if want := "Manages 1 resources in the remote cluster prod (not shown)"; strings.Join(app.Header, "\n") != want {
+		t.Errorf("header = %q, want %q", app.Header, want)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/restmapper"
+)
+
+// canIVerbs are the verbs kubectl-cond uses to query objects.
+var canIVerbs = []string{"get", "list", "watch"}
+
+func newCanICmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "can-i <resource-type>[,<resource-type>...]",
+		Short: "Check whether you can get, list and watch the given resource types",
+		Long: "Check whether you can get, list and watch the given resource types before running a large scan, " +
+			"so that it's known in advance which objects the output will and won't cover.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+			return runCanI(ctx, &kubeClient{configFlags: configFlags}, configFlags, args)
+		},
+	}
+}
+
+func runCanI(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, args []string) error {
+	mappings, err := resolveResourceTypes(configFlags, args)
+	if err != nil {
+		return err
+	}
+	namespace := ""
+	if !allNamespacesFlag {
+		if namespace, err = resolveNamespace(configFlags); err != nil {
+			return err
+		}
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+	}
+	cs, err := kc.Clientset()
+	if err != nil {
+		return err
+	}
+
+	table := newPlainTable(stdout, append([]string{"Resource", "Scope"}, canIVerbs...)...)
+	for _, m := range mappings {
+		ns := namespace
+		scope := "namespace " + namespace
+		if m.Scope.Name() == meta.RESTScopeNameRoot {
+			ns = ""
+			scope = "cluster"
+		} else if ns == "" {
+			scope = "all namespaces"
+		}
+		row := []string{m.Resource.GroupResource().String(), scope}
+		for _, verb := range canIVerbs {
+			review, err := cs.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: ns,
+						Verb:      verb,
+						Group:     m.Resource.Group,
+						Resource:  m.Resource.Resource,
+					},
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to check access to %s %s: %w", verb, m.Resource.GroupResource(), err)
+			}
+			if review.Status.Allowed {
+				row = append(row, activeTheme.healthy.Sprint("yes"))
+			} else {
+				row = append(row, activeTheme.unhealthy.Sprint("no"))
+			}
+		}
+		table.Append(row)
+	}
+	table.Render()
+	return nil
+}
+
+// resolveResourceTypes maps the comma-separated resource types (including
+// short names and categories like "all") in args to REST mappings.
+func resolveResourceTypes(configFlags *genericclioptions.ConfigFlags, args []string) ([]*meta.RESTMapping, error) {
+	mapper, err := configFlags.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := configFlags.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	categories := restmapper.NewDiscoveryCategoryExpander(discoveryClient)
+
+	var out []*meta.RESTMapping
+	seen := make(map[schema.GroupVersionResource]bool)
+	for _, arg := range args {
+		for _, typ := range strings.Split(arg, ",") {
+			if typ == "" {
+				continue
+			}
+			resources, ok := categories.Expand(typ)
+			if !ok {
+				resources = []schema.GroupResource{schema.ParseGroupResource(typ)}
+			}
+			for _, gr := range resources {
+				gvr, err := mapper.ResourceFor(gr.WithVersion(""))
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve resource type %q: %w", typ, err)
+				}
+				if seen[gvr] {
+					continue
+				}
+				seen[gvr] = true
+				gvk, err := mapper.KindFor(gvr)
+				if err != nil {
+					return nil, err
+				}
+				m, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, m)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// enrichCertificate adds the expiry and renewal times of a cert-manager
+// Certificate to the header, and a synthetic Expired condition once the
+// certificate in its Secret expired, since Ready only says the certificate
+// isn't up to date.
+func enrichCertificate(r *objectReport) {
+	obj := r.Object.Object
+	notAfter := nestedTime(obj, "status", "notAfter")
+	renewal := nestedTime(obj, "status", "renewalTime")
+	if notAfter != nil {
+		r.Header = append(r.Header, fmt.Sprintf("%s %s (%s)", gray.Sprint("Expires:"), relativeTime(notAfter.Time), absoluteTime(notAfter.Time)))
+	}
+	if renewal != nil {
+		r.Header = append(r.Header, fmt.Sprintf("%s %s (%s)", gray.Sprint("Renewal:"), relativeTime(renewal.Time), absoluteTime(renewal.Time)))
+	}
+
+	if notAfter == nil || conditionIndex(r, "Expired") >= 0 {
+		return
+	}
+	secret, _, _ := unstructured.NestedString(obj, "spec", "secretName")
+	cond := GenericCondition{
+		Type:      "Expired",
+		Status:    metav1.ConditionFalse,
+		Reason:    "NotExpired",
+		Message:   fmt.Sprintf("the certificate in Secret %q is valid until %s", secret, absoluteTime(notAfter.Time)),
+		Synthetic: true,
+	}
+	if !notAfter.After(time.Now()) {
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "CertificateExpired"
+		cond.Message = fmt.Sprintf("the certificate in Secret %q expired %s", secret, relativeTime(notAfter.Time))
+		cond.LastTransitionTime = notAfter
+	}
+	r.Conditions = append(r.Conditions, cond)
+}
+
+// explainCertificate explains the Issuing and Expired conditions of a
+// cert-manager Certificate with the failed issuance attempts and the
+// retry backoff of cert-manager.
+func explainCertificate(r *objectReport, cond GenericCondition) string {
+	if cond.Status != metav1.ConditionTrue {
+		return ""
+	}
+	obj := r.Object.Object
+	switch cond.Type {
+	case "Issuing":
+		attempts, _, _ := unstructured.NestedInt64(obj, "status", "failedIssuanceAttempts")
+		lastFailure := nestedTime(obj, "status", "lastFailureTime")
+		if attempts == 0 || lastFailure == nil {
+			return "cert-manager is issuing a new certificate through a CertificateRequest. The current certificate (if any) stays in the Secret until it succeeds."
+		}
+		return fmt.Sprintf("Issuing the certificate failed %d time(s), last %s. cert-manager retries with an exponential backoff (1 hour after the first failure, up to 32 hours).",
+			attempts, relativeTime(lastFailure.Time))
+	case "Expired":
+		return "Clients validating the certificate reject it until it's renewed. Renewal is likely failing, see the Issuing and Ready conditions."
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// kubeClient lazily initializes the clients used for fetching additional
+// information from the API server beyond the requested objects.
+type kubeClient struct {
+	configFlags *genericclioptions.ConfigFlags
+
+	once      sync.Once
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface
+	err       error
+
+	schemaPolarityOnce sync.Once
+	schemaPolarity     *schemaPolarity
+
+	owners ownerCache
+}
+
+func (c *kubeClient) init() {
+	restConfig, err := c.configFlags.ToRESTConfig()
+	if err != nil {
+		c.err = err
+		return
+	}
+	if c.clientset, c.err = kubernetes.NewForConfig(restConfig); c.err != nil {
+		return
+	}
+	c.dynamic, c.err = dynamic.NewForConfig(restConfig)
+}
+
+// Clientset returns a typed Kubernetes client.
+func (c *kubeClient) Clientset() (kubernetes.Interface, error) {
+	c.once.Do(c.init)
+	return c.clientset, c.err
+}
+
+// Dynamic returns a client for arbitrary resource types.
+func (c *kubeClient) Dynamic() (dynamic.Interface, error) {
+	c.once.Do(c.init)
+	return c.dynamic, c.err
+}
+
+// SchemaPolarity returns the polarity of condition types inferred from the
+// OpenAPI schemas of custom resources.
+func (c *kubeClient) SchemaPolarity() *schemaPolarity {
+	c.schemaPolarityOnce.Do(func() {
+		c.schemaPolarity = newSchemaPolarity(c.configFlags)
+	})
+	return c.schemaPolarity
+}
+
+// withContext returns a transport wrapper that binds requests that are not
+// already associated with a cancellable context to ctx. This lets the
+// requests made by libraries that don't accept a context (such as the
+// resource builder) to be cancelled.
+func withContext(ctx context.Context) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &contextRoundTripper{ctx: ctx, rt: rt}
+	}
+}
+
+type contextRoundTripper struct {
+	ctx context.Context
+	rt  http.RoundTripper
+}
+
+func (c *contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Done() == nil {
+		req = req.WithContext(c.ctx)
+	}
+	return c.rt.RoundTrip(req)
+}
+
+// Read requests that fail with transient errors are retried with exponential
+// backoff, starting with retryBaseDelay. Throttling responses with a
+// Retry-After header are already retried by client-go.
+const (
+	retryAttempts  = 3
+	retryBaseDelay = 250 * time.Millisecond
+)
+
+// withRetries returns a transport that retries read requests (except
+// watches) that fail with transient errors, such as connection failures or
+// the API server being temporarily unavailable.
+func withRetries(rt http.RoundTripper) http.RoundTripper {
+	return &retryRoundTripper{rt: rt}
+}
+
+type retryRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (r *retryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.URL.Query().Get("watch") == "true" {
+		return r.rt.RoundTrip(req)
+	}
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := r.rt.RoundTrip(req)
+		if attempt == retryAttempts || !isTransient(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		delay *= 2
+	}
+}
+
+// isTransient reports whether the request failed in a way that may succeed
+// when retried.
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return resp.Header.Get("Retry-After") == ""
+	}
+	return false
+}
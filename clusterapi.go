@@ -0,0 +1,76 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// clusterAPIPausedAnnotation pauses the reconciliation of a Cluster API
+// object.
+const clusterAPIPausedAnnotation = "cluster.x-k8s.io/paused"
+
+// enrichMachine adds the phase and the node of a Cluster API Machine to the
+// header, a Failed condition for the terminal failure reported by older API
+// versions, and a Paused condition for paused Machines that don't report
+// one.
+func enrichMachine(r *objectReport) {
+	obj := r.Object.Object
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	line := gray.Sprint("Phase: ") + orDash(phase)
+	if cluster, _, _ := unstructured.NestedString(obj, "spec", "clusterName"); cluster != "" {
+		line += fmt.Sprintf(", cluster %s", cluster)
+	}
+	if node, _, _ := unstructured.NestedString(obj, "status", "nodeRef", "name"); node != "" {
+		line += fmt.Sprintf(", node %s", node)
+	} else if providerID, _, _ := unstructured.NestedString(obj, "spec", "providerID"); providerID != "" {
+		line += fmt.Sprintf(", provider ID %s (no node yet)", providerID)
+	}
+	r.Header = append(r.Header, line)
+
+	// v1beta2 moved the fields under status.deprecated.v1beta1
+	failureReason, _, _ := unstructured.NestedString(obj, "status", "failureReason")
+	failureMessage, _, _ := unstructured.NestedString(obj, "status", "failureMessage")
+	if failureReason == "" && failureMessage == "" {
+		failureReason, _, _ = unstructured.NestedString(obj, "status", "deprecated", "v1beta1", "failureReason")
+		failureMessage, _, _ = unstructured.NestedString(obj, "status", "deprecated", "v1beta1", "failureMessage")
+	}
+	if (failureReason != "" || failureMessage != "") && conditionIndex(r, "Failed") < 0 {
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:      "Failed",
+			Status:    metav1.ConditionTrue,
+			Reason:    failureReason,
+			Message:   failureMessage,
+			Notes:     []string{"The failure is terminal, the Machine has to be deleted to be replaced"},
+			Synthetic: true,
+		})
+	}
+
+	if i := conditionIndex(r, "Paused"); i >= 0 {
+		r.Conditions[i].Warning = true
+	} else if _, paused := r.Object.GetAnnotations()[clusterAPIPausedAnnotation]; paused {
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:      "Paused",
+			Status:    metav1.ConditionTrue,
+			Reason:    "Paused",
+			Message:   fmt.Sprintf("the %s annotation is set, so the Machine is not reconciled", clusterAPIPausedAnnotation),
+			Warning:   true,
+			Synthetic: true,
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// binaryName is the name of the plugin executable that kubectl runs for
+// "kubectl cond", and that shell completion scripts are generated for.
+const binaryName = "kubectl-cond"
+
+func newCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion (bash|zsh|fish|powershell)",
+		Short: "Print the shell completion script for kubectl-cond",
+		Long: "Print the shell completion script for the kubectl-cond executable. To complete \"kubectl cond\" " +
+			"(with kubectl 1.26 or later), put an executable named kubectl_complete-cond on your PATH that runs " +
+			"\"kubectl cond __complete \"$@\"\".",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// the scripts are generated for the name of the root command, which
+			// is "kubectl" to match how the plugin is invoked
+			root := cmd.Root()
+			use := root.Use
+			root.Use = binaryName
+			defer func() { root.Use = use }()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(stdout, true)
+			case "zsh":
+				return root.GenZshCompletion(stdout)
+			case "fish":
+				return root.GenFishCompletion(stdout, true)
+			case "powershell":
+				return root.GenPowerShellCompletionWithDesc(stdout)
+			default:
+				return fmt.Errorf("unsupported shell %q, expected one of: (bash, zsh, fish, powershell)", args[0])
+			}
+		},
+	}
+}
+
+// registerCompletions registers the completion of resource types, object
+// names and flag values.
+func registerCompletions(cmd *cobra.Command, configFlags *genericclioptions.ConfigFlags) {
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if typ, name, ok := strings.Cut(toComplete, "/"); ok {
+			var out []string
+			for _, n := range completeObjectNames(configFlags, typ, name) {
+				out = append(out, typ+"/"+n)
+			}
+			return out, cobra.ShellCompDirectiveNoFileComp
+		}
+		if len(args) == 0 {
+			return completeResourceTypes(configFlags, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		if len(args) >= 1 && !strings.Contains(args[0], "/") {
+			return completeObjectNames(configFlags, args[0], toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	fixed := func(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return values, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	completeContexts := func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeContextNames(configFlags, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	for flag, fn := range map[string]func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective){
+		"output":           fixed("table", "wide", "matrix", "timeline", "json", "yaml", "markdown", "html", "go-template=", "jsonpath=", "custom-columns=", "ndjson", "jsonl"),
+		"sort-by":          fixed(conditionOrderNames()...),
+		"treat-unknown-as": fixed("neutral", "healthy", "unhealthy"),
+		"time-format":      fixed("relative", "absolute", "both"),
+		"theme":            fixed("default", "dark", "light", "mono"),
+		"show":             fixed(detailFields...),
+		"hide":             fixed(detailFields...),
+		"group-by":         fixed("condition", "kind"),
+		"contexts":         completeContexts,
+		"context":          completeContexts,
+		"namespace": func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeObjectNames(configFlags, "namespaces", toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	} {
+		// the flags are registered on the root command, so errors
+		// only indicate typos in the names above
+		_ = cmd.RegisterFlagCompletionFunc(flag, fn)
+	}
+}
+
+// completeResourceTypes returns the names of the resource types supported by
+// the server in the form printed by kubectl api-resources -o name.
+func completeResourceTypes(configFlags *genericclioptions.ConfigFlags, toComplete string) []string {
+	discoveryClient, err := configFlags.ToDiscoveryClient()
+	if err != nil {
+		return nil
+	}
+	// partial discovery results are still usable
+	lists, _ := discoveryClient.ServerPreferredResources()
+	var out []string
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "get") {
+				continue
+			}
+			name := r.Name
+			if gv.Group != "" {
+				name += "." + gv.Group
+			}
+			if strings.HasPrefix(name, toComplete) {
+				out = append(out, name)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// completeObjectNames returns the names of the objects of the resource type
+// in the namespace selected with the flags.
+func completeObjectNames(configFlags *genericclioptions.ConfigFlags, typ, toComplete string) []string {
+	rb, err := newResourceBuilder(configFlags)
+	if err != nil {
+		return nil
+	}
+	infos, err := rb.ResourceTypeOrNameArgs(true, typ).
+		RequestChunksOf(chunkSizeFlag).
+		Flatten().
+		ContinueOnError().
+		Do().
+		Infos()
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name, toComplete) {
+			out = append(out, info.Name)
+		}
+	}
+	return out
+}
+
+// completeContextNames returns the names of the contexts in the kubeconfig.
+func completeContextNames(configFlags *genericclioptions.ConfigFlags, toComplete string) []string {
+	rawConfig, err := configFlags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for name := range rawConfig.Contexts {
+		if strings.HasPrefix(name, toComplete) {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
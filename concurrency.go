@@ -0,0 +1,127 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// reportConcurrency is the number of objects whose reports are built in
+// parallel, since enrichers may send requests to the API server for each
+// object (e.g. to look up the pods of a workload).
+const reportConcurrency = 16
+
+// errVisitStopped stops the visitor once the reports are not consumed
+// anymore.
+var errVisitStopped = errors.New("visit stopped")
+
+// pendingReport is the report of a visited object that's being built.
+type pendingReport struct {
+	info    *resource.Info
+	report  *objectReport
+	managed []*objectReport
+	err     error
+	panic   any
+	done    chan struct{}
+}
+
+// visitReports builds the reports of the visited objects concurrently, and
+// calls fn with each of them (along with the reports of the resources
+// managed by Argo CD Applications with --expand-app, and of the pods of
+// workloads with --pods) as soon as it's ready, in the order the objects
+// were visited. Errors of visiting objects (e.g. of querying them) are passed
+// to fn with a nil info. fn is called on the calling goroutine, so that it
+// doesn't need to be synchronized and panics are recovered by the crash
+// handler.
+func visitReports(ctx context.Context, kc *kubeClient, v resource.Visitor,
+	fn func(info *resource.Info, report *objectReport, managed []*objectReport, err error) error) error {
+	queue := make(chan *pendingReport, reportConcurrency)
+	stop := make(chan struct{})
+	var visitErr error
+	var visitPanic any
+	go func() {
+		defer close(queue)
+		defer func() { visitPanic = recover() }()
+		sem := make(chan struct{}, reportConcurrency)
+		visitErr = v.Visit(func(info *resource.Info, err error) error {
+			if err != nil {
+				p := &pendingReport{err: err, done: make(chan struct{})}
+				close(p.done)
+				select {
+				case queue <- p:
+					return nil
+				case <-stop:
+					return errVisitStopped
+				}
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-stop:
+				return errVisitStopped
+			}
+			p := &pendingReport{info: info, done: make(chan struct{})}
+			go func() {
+				defer close(p.done)
+				defer func() { <-sem }()
+				defer func() { p.panic = recover() }()
+				p.report, p.err = newObjectReport(ctx, kc, info.Object)
+				if p.report != nil && expandAppFlag && isArgoApplication(p.report.Object) {
+					p.managed = expandApplication(ctx, kc, p.report)
+				}
+				if p.report != nil && podsFlag && kc != nil && isPodWorkload(p.report.Object) {
+					p.managed = append(p.managed, expandWorkloadPods(ctx, kc, p.report)...)
+				}
+			}()
+			select {
+			case queue <- p:
+				return nil
+			case <-stop:
+				return errVisitStopped
+			}
+		})
+	}()
+
+	var err error
+	for p := range queue {
+		if err != nil {
+			// keep draining the queue until the visitor stops
+			continue
+		}
+		<-p.done
+		if p.panic != nil {
+			panic(p.panic)
+		}
+		if err = fn(p.info, p.report, p.managed, p.err); err != nil {
+			close(stop)
+		}
+	}
+	if visitPanic != nil {
+		panic(visitPanic)
+	}
+	if err != nil {
+		return err
+	}
+	// the errors of visiting that weren't passed to fn, e.g. of listing a
+	// resource type
+	for _, e := range flattenErrors(visitErr) {
+		if err = fn(nil, nil, nil, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
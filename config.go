@@ -0,0 +1,76 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// config is the contents of the config file, holding the defaults for
+// settings that are usually the same across invocations.
+type config struct {
+	// NegativePolarity lists condition types whose True status is unhealthy,
+	// in the same form as --negative-polarity.
+	NegativePolarity []string `json:"negativePolarity,omitempty"`
+
+	// Theme is the default for --theme.
+	Theme string `json:"theme,omitempty"`
+	// Colors override the colors of the theme, keyed by health (healthy,
+	// unhealthy, warning, unknown and muted), e.g. "bold hiblue".
+	Colors map[string]string `json:"colors,omitempty"`
+	// Icons is the default for --icons.
+	Icons *bool `json:"icons,omitempty"`
+	// Glyphs override the icons shown with --icons, keyed by health, e.g.
+	// to use the icons of a Nerd Font.
+	Glyphs map[string]string `json:"glyphs,omitempty"`
+}
+
+// configPath returns the location of the config file, which follows the XDG
+// base directory convention on all platforms.
+func configPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "kubectl-cond", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "kubectl-cond", "config.yaml"), nil
+}
+
+// loadConfig reads the config file. A missing config file is not an error.
+func loadConfig() (*config, error) {
+	path, err := configPath()
+	if err != nil {
+		return &config{}, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &config{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var c config
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &c, nil
+}
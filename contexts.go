@@ -0,0 +1,124 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var contextsFlag []string
+var allContextsFlag bool
+
+// contextResult is the output of querying the objects in a context.
+type contextResult struct {
+	name string
+	out  bytes.Buffer
+	err  error
+}
+
+// runContexts queries the requested objects in each of the contexts
+// concurrently and prints the results grouped by context, in the order the
+// contexts are specified.
+func runContexts(ctx context.Context, configFlags *genericclioptions.ConfigFlags, args []string) error {
+	names := contextsFlag
+	if allContextsFlag {
+		rawConfig, err := configFlags.ToRawKubeConfigLoader().RawConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+		names = nil
+		for name := range rawConfig.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return errors.New("no contexts to query")
+	}
+
+	results := make([]contextResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		results[i].name = name
+		wg.Add(1)
+		go func(res *contextResult) {
+			defer wg.Done()
+			res.err = queryContext(ctx, configFlagsForContext(configFlags, res.name), args, &res.out)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	var unhealthy bool
+	var failed []string
+	for i, res := range results {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		fmt.Fprintln(stdout, bold.Sprintf("Context %s", res.name))
+		fmt.Fprintln(stdout)
+		stdout.Write(res.out.Bytes())
+		switch {
+		case errors.Is(res.err, errUnhealthy):
+			unhealthy = true
+		case res.err != nil:
+			fmt.Fprintf(stdout, "query failed: %v\n", res.err)
+			failed = append(failed, res.name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to query %d of %d contexts: %v", len(failed), len(names), failed)
+	}
+	if unhealthy {
+		return errUnhealthy
+	}
+	return nil
+}
+
+// queryContext prints the conditions of the requested objects in the
+// context of configFlags to out.
+func queryContext(ctx context.Context, configFlags *genericclioptions.ConfigFlags, args []string, out *bytes.Buffer) error {
+	rb, err := newResourceBuilder(configFlags)
+	if err != nil {
+		return err
+	}
+	rb = rb.ResourceTypeOrNameArgs(true, args...).FilenameParam(false, filenameOpts)
+	kc := &kubeClient{configFlags: configFlags}
+	return printObjectsTo(ctx, kc, queryLatest(rb), out, out)
+}
+
+// configFlagsForContext returns a copy of the flags that selects the given
+// context. Flags that select a cluster or user directly are not copied, as
+// they would override the ones of the context.
+func configFlagsForContext(configFlags *genericclioptions.ConfigFlags, name string) *genericclioptions.ConfigFlags {
+	c := genericclioptions.NewConfigFlags(true)
+	c.CacheDir = configFlags.CacheDir
+	c.KubeConfig = configFlags.KubeConfig
+	c.Namespace = configFlags.Namespace
+	c.Impersonate = configFlags.Impersonate
+	c.ImpersonateUID = configFlags.ImpersonateUID
+	c.ImpersonateGroup = configFlags.ImpersonateGroup
+	c.Timeout = configFlags.Timeout
+	c.DisableCompression = configFlags.DisableCompression
+	c.WrapConfigFn = configFlags.WrapConfigFn
+	c.Context = &name
+	return c
+}
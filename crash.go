@@ -0,0 +1,133 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const issuesURL = "https://github.com/ahmetb/kubectl-cond/issues"
+
+// lastObject is the object that was last processed, to include in the bug
+// report if kubectl-cond crashes.
+var lastObject atomic.Pointer[unstructured.Unstructured]
+
+// sensitiveFlags are the flags whose values are removed from bug reports.
+var sensitiveFlags = []string{"token", "password", "username", "client-key", "client-certificate",
+	"certificate-authority", "server", "as", "as-group", "as-uid", "push-metrics",
+	// webhook URLs and exec commands can carry tokens
+	"notify"}
+
+// sensitiveShorthands are the shorthands of sensitive flags (-s for
+// --server).
+var sensitiveShorthands = []string{"s"}
+
+// handlePanic recovers from a panic, writes a bug report with the details
+// to a temporary file and exits, instead of printing a raw stack trace.
+// It must be deferred by main.
+func handlePanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	path, err := writeBugReport(r, stack)
+	fmt.Fprintf(stderr, "kubectl cond crashed unexpectedly: %v\n", r)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to write the bug report (%v), stack trace:\n%s\n", err, stack)
+	} else {
+		fmt.Fprintf(stderr, "A bug report with the details (credentials removed) was written to %s.\n", path)
+	}
+	fmt.Fprintf(stderr, "Please file an issue at %s and attach the report.\n", issuesURL)
+	os.Exit(2)
+}
+
+// writeBugReport writes the version, the redacted command line arguments,
+// the stack trace and the conditions of the object being processed to a
+// temporary file.
+func writeBugReport(p any, stack []byte) (string, error) {
+	f, err := os.CreateTemp("", "kubectl-cond-crash-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "kubectl-cond %s (%s %s/%s)\n", versionString(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(f, "Time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "Arguments: %s\n\n", strings.Join(redactArgs(os.Args[1:]), " "))
+	fmt.Fprintf(f, "Panic: %v\n\n%s\n", p, stack)
+	if obj := lastObject.Load(); obj != nil {
+		name := obj.GetName()
+		if obj.GetNamespace() != "" {
+			name = obj.GetNamespace() + "/" + name
+		}
+		fmt.Fprintf(f, "Object: %s %s (%s)\n", obj.GetKind(), name, obj.GetAPIVersion())
+		conditions, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "status", "conditions")
+		b, err := json.MarshalIndent(conditions, "", "  ")
+		if err != nil {
+			b = []byte(err.Error())
+		}
+		fmt.Fprintf(f, "Conditions: %s\n", b)
+	}
+	return f.Name(), f.Close()
+}
+
+// redactArgs replaces the values of sensitive flags in args.
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+	for i := 0; i < len(out); i++ {
+		arg := out[i]
+		var prefix, name string
+		var hasValue bool
+		switch {
+		case arg == "--":
+			// the rest are positional arguments
+			return out
+		case strings.HasPrefix(arg, "--"):
+			prefix = "--"
+			name, _, hasValue = strings.Cut(arg[2:], "=")
+			if !slices.Contains(sensitiveFlags, name) {
+				continue
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// the value of a shorthand can follow it directly (-sURL)
+			prefix, name = "-", arg[1:2]
+			hasValue = len(arg) > 2
+			if !slices.Contains(sensitiveShorthands, name) {
+				continue
+			}
+		default:
+			continue
+		}
+		if hasValue {
+			out[i] = prefix + name + "=REDACTED"
+		} else if i+1 < len(out) {
+			out[i+1] = "REDACTED"
+			i++
+		}
+	}
+	return out
+}
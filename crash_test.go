@@ -0,0 +1,77 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "flag with value",
+			args: []string{"pods", "--token=secret", "-A"},
+			want: []string{"pods", "--token=REDACTED", "-A"},
+		},
+		{
+			name: "flag followed by value",
+			args: []string{"pods", "--server", "https://10.0.0.1", "-A"},
+			want: []string{"pods", "--server", "REDACTED", "-A"},
+		},
+		{
+			name: "notify target",
+			args: []string{"pods", "-w", "--notify=webhook=https://hooks.slack.com/services/T0/B0/xyz"},
+			want: []string{"pods", "-w", "--notify=REDACTED"},
+		},
+		{
+			name: "shorthand followed by value",
+			args: []string{"-s", "https://10.0.0.1", "nodes"},
+			want: []string{"-s", "REDACTED", "nodes"},
+		},
+		{
+			name: "shorthand with value",
+			args: []string{"-s=https://10.0.0.1", "-shttps://10.0.0.2", "nodes"},
+			want: []string{"-s=REDACTED", "-s=REDACTED", "nodes"},
+		},
+		{
+			name: "sensitive flag as the last argument",
+			args: []string{"nodes", "--token"},
+			want: []string{"nodes", "--token"},
+		},
+		{
+			name: "other flags",
+			args: []string{"pods", "-n", "default", "--type=Ready", "--as-not-a-flag"},
+			want: []string{"pods", "-n", "default", "--type=Ready", "--as-not-a-flag"},
+		},
+		{
+			name: "positional arguments after --",
+			args: []string{"nodes", "--", "--token"},
+			want: []string{"nodes", "--", "--token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactArgs(tt.args)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("redactArgs(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
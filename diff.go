@@ -0,0 +1,269 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// diffSide is one of the objects compared by the diff command.
+type diffSide struct {
+	report *objectReport
+	source string
+}
+
+func newDiffCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff (TYPE/NAME TYPE/NAME | TYPE/NAME -f FILE | -f FILE)",
+		Short: "Compare the conditions of two objects, or of saved objects and their live state",
+		Long: "Compare the conditions of two live objects (e.g. two nodes), of a live object and an object in a file, " +
+			"or of the objects in the files (e.g. exported with kubectl get -o yaml) and their live state. " +
+			"Files given with -f are read as they are instead of identifying the objects to query.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+
+			pairs, err := diffPairs(ctx, configFlags, args)
+			if err != nil {
+				return err
+			}
+			for i, pair := range pairs {
+				if i > 0 {
+					fmt.Fprintln(stdout)
+				}
+				printConditionDiff(stdout, pair[0], pair[1])
+			}
+			return nil
+		},
+	}
+}
+
+// diffPairs returns the pairs of objects to compare for the arguments.
+func diffPairs(ctx context.Context, configFlags *genericclioptions.ConfigFlags, args []string) ([][2]diffSide, error) {
+	hasFiles := len(filenameOpts.Filenames) > 0 || filenameOpts.Kustomize != ""
+	if !hasFiles {
+		live, err := queryDiffObjects(ctx, configFlags, args, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(live) != 2 {
+			return nil, fmt.Errorf("expected two objects to compare, got %d", len(live))
+		}
+		return [][2]diffSide{{live[0], live[1]}}, nil
+	}
+
+	saved, err := readDiffFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 {
+		live, err := queryDiffObjects(ctx, configFlags, args, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(saved) != 1 || len(live) != 1 {
+			return nil, fmt.Errorf("expected one object in the files and one live object to compare, got %d and %d", len(saved), len(live))
+		}
+		return [][2]diffSide{{saved[0], live[0]}}, nil
+	}
+	live, err := queryDiffObjects(ctx, configFlags, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	var pairs [][2]diffSide
+	for _, s := range saved {
+		pair := [2]diffSide{s, {source: "not found"}}
+		for _, l := range live {
+			if sameDiffObject(s.report, l.report) {
+				pair[1] = l
+				break
+			}
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// sameDiffObject reports whether the reports are of the same object. Saved
+// objects may not specify a namespace.
+func sameDiffObject(saved, live *objectReport) bool {
+	return saved.Object.GroupVersionKind().GroupKind() == live.Object.GroupVersionKind().GroupKind() &&
+		saved.Name == live.Name && (saved.Namespace == "" || saved.Namespace == live.Namespace)
+}
+
+// queryDiffObjects queries the live objects specified with the arguments, or
+// the live state of the objects in the files. Objects in the files that
+// don't exist are skipped.
+func queryDiffObjects(ctx context.Context, configFlags *genericclioptions.ConfigFlags, args []string, files bool) ([]diffSide, error) {
+	rb, err := newResourceBuilder(configFlags)
+	if err != nil {
+		return nil, err
+	}
+	if files {
+		rb = rb.FilenameParam(false, filenameOpts)
+	} else {
+		rb = rb.ResourceTypeOrNameArgs(true, args...)
+	}
+	var sides []diffSide
+	err = queryLatest(rb).Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			if files {
+				return nil
+			}
+			return err
+		}
+		side, err := newDiffSide(ctx, info, "live")
+		if err != nil {
+			return err
+		}
+		sides = append(sides, side)
+		return nil
+	})
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", timeoutFlag)
+	}
+	return sides, err
+}
+
+// readDiffFiles reads the objects in the files as they are.
+func readDiffFiles() ([]diffSide, error) {
+	var sides []diffSide
+	err := resource.NewLocalBuilder().
+		Unstructured().
+		FilenameParam(false, filenameOpts).
+		Flatten().
+		Do().
+		Visit(func(info *resource.Info, err error) error {
+			if err != nil {
+				return err
+			}
+			side, err := newDiffSide(context.Background(), info, info.Source)
+			if err != nil {
+				return err
+			}
+			sides = append(sides, side)
+			return nil
+		})
+	if err == nil && len(sides) == 0 {
+		err = errors.New("no objects found in the files")
+	}
+	return sides, err
+}
+
+// newDiffSide creates the report of the object. Enrichers that query the
+// API server are skipped for both sides, so that only the conditions stored
+// in the objects are compared.
+func newDiffSide(ctx context.Context, info *resource.Info, source string) (diffSide, error) {
+	r, err := newObjectReport(ctx, nil, info.Object)
+	if errors.Is(err, errNoConditions) {
+		// objects without conditions can still be compared with ones that have them
+		u, _ := info.Object.(*unstructured.Unstructured)
+		r, err = &objectReport{Object: u, Kind: u.GetKind(), Namespace: info.Namespace, Name: info.Name}, nil
+	}
+	if err != nil {
+		return diffSide{}, fmt.Errorf("failed to process object %s %s: %w", info.Object.GetObjectKind().GroupVersionKind().Kind, info.Name, err)
+	}
+	return diffSide{report: r, source: source}, nil
+}
+
+// printConditionDiff prints the conditions that were added, removed or
+// changed from the old object to the new one, and the unchanged ones.
+func printConditionDiff(w io.Writer, old, new diffSide) {
+	red, green, yellow := color.New(color.FgRed), color.New(color.FgGreen), color.New(color.FgYellow)
+	fmt.Fprintln(w, red.Sprintf("--- %s %s (%s)", old.report.Kind, old.report.displayName(), old.source))
+	if new.report == nil {
+		fmt.Fprintln(w, green.Sprintf("+++ %s %s (%s)", old.report.Kind, old.report.displayName(), new.source))
+		return
+	}
+	fmt.Fprintln(w, green.Sprintf("+++ %s %s (%s)", new.report.Kind, new.report.displayName(), new.source))
+
+	oldConds := make(map[string]GenericCondition)
+	for _, c := range old.report.Conditions {
+		oldConds[c.Type] = c
+	}
+	newTypes := make(map[string]bool)
+	var changed int
+	for _, c := range new.report.Conditions {
+		newTypes[c.Type] = true
+		prev, ok := oldConds[c.Type]
+		if !ok {
+			changed++
+			fmt.Fprintln(w, green.Sprintf("+ %s: %s", c.Type, c.Status))
+			printDiffDetail(w, "reason", "", c.Reason)
+			printDiffDetail(w, "message", "", c.Message)
+			continue
+		}
+		if prev.Status == c.Status && prev.Reason == c.Reason && prev.Message == c.Message {
+			line := fmt.Sprintf("  %s: %s", c.Type, c.Status)
+			if retransitioned(prev, c) {
+				changed++
+				line += fmt.Sprintf(" (transitioned again %s ago)", duration.HumanDuration(time.Since(c.LastTransitionTime.Time)))
+				fmt.Fprintln(w, yellow.Sprint("~"+line[1:]))
+			} else {
+				fmt.Fprintln(w, gray.Sprint(line))
+			}
+			continue
+		}
+		changed++
+		status := string(c.Status)
+		if prev.Status != c.Status {
+			status = string(prev.Status) + " → " + statusColor(c)(string(c.Status))
+		}
+		fmt.Fprintln(w, yellow.Sprintf("~ %s: ", c.Type)+status)
+		printDiffDetail(w, "reason", prev.Reason, c.Reason)
+		printDiffDetail(w, "message", prev.Message, c.Message)
+	}
+	for _, c := range old.report.Conditions {
+		if newTypes[c.Type] {
+			continue
+		}
+		changed++
+		fmt.Fprintln(w, red.Sprintf("- %s: %s", c.Type, c.Status))
+	}
+	if changed == 0 {
+		fmt.Fprintln(w, "No differences in conditions.")
+	}
+}
+
+// printDiffDetail prints the change of a field of a condition, if any.
+func printDiffDetail(w io.Writer, field, old, new string) {
+	switch {
+	case old == new:
+	case old == "":
+		fmt.Fprintf(w, "    %s: %s\n", field, new)
+	case new == "":
+		fmt.Fprintf(w, "    %s: %s → (none)\n", field, old)
+	default:
+		fmt.Fprintf(w, "    %s: %s → %s\n", field, old, new)
+	}
+}
+
+// retransitioned reports whether the condition transitioned again (e.g.
+// flapped back to the same status) between the two observations.
+func retransitioned(old, new GenericCondition) bool {
+	return old.LastTransitionTime != nil && new.LastTransitionTime != nil &&
+		new.LastTransitionTime.After(old.LastTransitionTime.Time)
+}
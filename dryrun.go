@@ -0,0 +1,250 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// plannedRequest is a request that would be sent to the API server to query
+// the requested objects.
+type plannedRequest struct {
+	Verb      string
+	Mapping   *meta.RESTMapping
+	Namespace string
+	Name      string
+}
+
+// Path returns the API path (including the query) of the request.
+func (r plannedRequest) Path() string {
+	gv := r.Mapping.Resource.GroupVersion()
+	p := "/apis/" + gv.String()
+	if gv.Group == "" {
+		p = "/api/" + gv.Version
+	}
+	if r.Namespace != "" {
+		p = path.Join(p, "namespaces", r.Namespace)
+	}
+	p = path.Join(p, r.Mapping.Resource.Resource)
+	query := url.Values{}
+	switch r.Verb {
+	case "list":
+		if chunkSizeFlag > 0 {
+			query.Set("limit", strconv.FormatInt(chunkSizeFlag, 10))
+		}
+		if fieldSelectorFlag != "" {
+			query.Set("fieldSelector", fieldSelectorFlag)
+		}
+	case "watch":
+		query.Set("watch", "true")
+		if r.Name != "" {
+			query.Set("fieldSelector", "metadata.name="+r.Name)
+		} else if fieldSelectorFlag != "" {
+			query.Set("fieldSelector", fieldSelectorFlag)
+		}
+	default:
+		return path.Join(p, r.Name)
+	}
+	if selectorFlag != "" {
+		query.Set("labelSelector", selectorFlag)
+	}
+	if len(query) > 0 {
+		p += "?" + query.Encode()
+	}
+	return p
+}
+
+// runDryRun prints the requests that would be sent to query the objects
+// specified by args and the filename flags, without querying any objects.
+// API discovery is still used to resolve the resource types.
+func runDryRun(w io.Writer, configFlags *genericclioptions.ConfigFlags, args []string) error {
+	namespace := ""
+	if !allNamespacesFlag {
+		var err error
+		if namespace, err = resolveNamespace(configFlags); err != nil {
+			return err
+		}
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+	}
+
+	reqs, err := plannedArgRequests(configFlags, namespace, args)
+	if err != nil {
+		return err
+	}
+	fileReqs, err := plannedFileRequests(configFlags, namespace)
+	if err != nil {
+		return err
+	}
+	reqs = append(reqs, fileReqs...)
+	if len(reqs) == 0 {
+		return fmt.Errorf("no resource types, names or files specified")
+	}
+
+	table := newPlainTable(w, "Verb", "Resource", "Namespace", "Path")
+	var lists int
+	var notes []string
+	for _, r := range reqs {
+		ns := r.Namespace
+		switch {
+		case r.Mapping.Scope.Name() == meta.RESTScopeNameRoot:
+			ns = "-"
+		case ns == "":
+			ns = "(all)"
+		}
+		if r.Verb == "list" {
+			lists++
+		}
+		for _, note := range extraRequestsNotes(r.Mapping.GroupVersionKind.GroupKind()) {
+			if !slices.Contains(notes, note) {
+				notes = append(notes, note)
+			}
+		}
+		table.Append([]string{strings.ToUpper(r.Verb), r.Mapping.Resource.GroupResource().String(), ns, r.Path()})
+	}
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d requests", len(reqs))
+	if lists > 0 && chunkSizeFlag > 0 {
+		fmt.Fprintf(w, ", plus one request for every %d objects beyond the first %d in each list", chunkSizeFlag, chunkSizeFlag)
+	}
+	fmt.Fprintln(w, ".")
+	for _, note := range notes {
+		fmt.Fprintln(w, note)
+	}
+	return nil
+}
+
+// plannedArgRequests returns the requests for the resource types and names in
+// args, in the same forms as kubectl get accepts them ("type1,type2 [name...]"
+// or "type/name...").
+func plannedArgRequests(configFlags *genericclioptions.ConfigFlags, namespace string, args []string) ([]plannedRequest, error) {
+	var out []plannedRequest
+	for _, set := range splitWatchArgs(args) {
+		typ, names := set[0], set[1:]
+		if t, name, ok := strings.Cut(typ, "/"); ok {
+			typ, names = t, []string{name}
+		}
+		mappings, err := resolveResourceTypes(configFlags, []string{typ})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mappings {
+			ns := namespace
+			if m.Scope.Name() == meta.RESTScopeNameRoot {
+				ns = ""
+			}
+			if len(names) == 0 {
+				// watches replay the current state, so no list is needed
+				verb := "list"
+				if watchFlag {
+					verb = "watch"
+				}
+				out = append(out, plannedRequest{Verb: verb, Mapping: m, Namespace: ns})
+				continue
+			}
+			if ns == "" && m.Scope.Name() != meta.RESTScopeNameRoot {
+				return nil, fmt.Errorf("a resource cannot be retrieved by name across all namespaces")
+			}
+			for _, name := range names {
+				out = append(out, plannedRequest{Verb: "get", Mapping: m, Namespace: ns, Name: name})
+				if watchFlag {
+					out = append(out, plannedRequest{Verb: "watch", Mapping: m, Namespace: ns, Name: name})
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// plannedFileRequests returns the requests for the objects in the files
+// specified with the filename flags. The files are read, but the objects in
+// them are not queried.
+func plannedFileRequests(configFlags *genericclioptions.ConfigFlags, namespace string) ([]plannedRequest, error) {
+	if len(filenameOpts.Filenames) == 0 && filenameOpts.Kustomize == "" {
+		return nil, nil
+	}
+	infos, err := resource.NewBuilder(configFlags).
+		Unstructured().
+		NamespaceParam(namespace).DefaultNamespace().
+		FilenameParam(false, filenameOpts).
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, err
+	}
+	var out []plannedRequest
+	seen := make(map[string]bool)
+	for _, info := range infos {
+		if seen[infoKey(info)] {
+			continue
+		}
+		seen[infoKey(info)] = true
+		out = append(out, plannedRequest{Verb: "get", Mapping: info.Mapping, Namespace: info.Namespace, Name: info.Name})
+		if watchFlag {
+			out = append(out, plannedRequest{Verb: "watch", Mapping: info.Mapping, Namespace: info.Namespace, Name: info.Name})
+		}
+	}
+	return out, nil
+}
+
+// extraRequestsNotes describes the additional requests sent for each object
+// of the given kind to enrich its report, if any.
+func extraRequestsNotes(gk schema.GroupKind) []string {
+	var notes []string
+	if !noOwnersFlag {
+		notes = append(notes, fmt.Sprintf("Each object with owners also requires getting them, up to %d levels of controllers, unless already fetched for another object (disable with --no-owners).", maxOwnerDepth))
+	}
+	if eventsFlag {
+		notes = append(notes, "Each object also requires listing its Warning events, and workloads listing their pods and the Warning events of pods (--events).")
+	}
+	switch gk {
+	case schema.GroupKind{Kind: "Service"}:
+		notes = append(notes, "Each Service also requires listing its EndpointSlices.")
+	case schema.GroupKind{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"},
+		schema.GroupKind{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:
+		notes = append(notes, "Each webhook also requires getting its backing Service and listing its EndpointSlices.")
+	case schema.GroupKind{Kind: "Node"}:
+		if nodeMetricsFlag {
+			notes = append(notes, "Each Node also requires querying the metrics API and the kubelet stats summary (--node-metrics).")
+		}
+	case schema.GroupKind{Group: "apps", Kind: "DaemonSet"}:
+		if missingNodesFlag {
+			notes = append(notes, "Each unavailable DaemonSet also requires listing its pods and all nodes (--missing-nodes).")
+		}
+	case argoApplicationKind:
+		if expandAppFlag {
+			notes = append(notes, "Each Argo CD Application also requires getting every resource it manages (--expand-app).")
+		}
+	}
+	if podsFlag && (gk.Group == "apps" || gk.Group == "batch") && podWorkloadKinds.Has(gk.Kind) {
+		notes = append(notes, fmt.Sprintf("Each %s also requires listing its pods (--pods).", gk.Kind))
+	}
+	return notes
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPlannedRequestPath(t *testing.T) {
+	defer func(chunkSize int64, fieldSelector, selector string) {
+		chunkSizeFlag, fieldSelectorFlag, selectorFlag = chunkSize, fieldSelector, selector
+	}(chunkSizeFlag, fieldSelectorFlag, selectorFlag)
+	pods := &meta.RESTMapping{Resource: schema.GroupVersionResource{Version: "v1", Resource: "pods"}}
+	deployments := &meta.RESTMapping{Resource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}}
+
+	tests := []struct {
+		name          string
+		req           plannedRequest
+		chunkSize     int64
+		fieldSelector string
+		selector      string
+		want          string
+	}{
+		{name: "list", req: plannedRequest{Verb: "list", Mapping: pods},
+			want: "/api/v1/pods"},
+		{name: "list with limit", req: plannedRequest{Verb: "list", Mapping: pods}, chunkSize: 500,
+			want: "/api/v1/pods?limit=500"},
+		{name: "list with field selector", req: plannedRequest{Verb: "list", Mapping: pods}, fieldSelector: "status.phase=Running",
+			want: "/api/v1/pods?fieldSelector=status.phase%3DRunning"},
+		{name: "list with label selector", req: plannedRequest{Verb: "list", Mapping: pods}, selector: "app=x",
+			want: "/api/v1/pods?labelSelector=app%3Dx"},
+		{name: "list with limit and field selector", req: plannedRequest{Verb: "list", Mapping: pods}, chunkSize: 500, fieldSelector: "status.phase=Running",
+			want: "/api/v1/pods?fieldSelector=status.phase%3DRunning&limit=500"},
+		{name: "list with limit and label selector", req: plannedRequest{Verb: "list", Mapping: pods}, chunkSize: 500, selector: "app=x",
+			want: "/api/v1/pods?labelSelector=app%3Dx&limit=500"},
+		{name: "list with both selectors", req: plannedRequest{Verb: "list", Mapping: pods}, fieldSelector: "status.phase=Running", selector: "app=x",
+			want: "/api/v1/pods?fieldSelector=status.phase%3DRunning&labelSelector=app%3Dx"},
+		{name: "list with limit and both selectors", req: plannedRequest{Verb: "list", Mapping: pods}, chunkSize: 500, fieldSelector: "status.phase=Running", selector: "app=x",
+			want: "/api/v1/pods?fieldSelector=status.phase%3DRunning&labelSelector=app%3Dx&limit=500"},
+		{name: "namespaced list of group", req: plannedRequest{Verb: "list", Mapping: deployments, Namespace: "prod"},
+			want: "/apis/apps/v1/namespaces/prod/deployments"},
+		{name: "get", req: plannedRequest{Verb: "get", Mapping: pods, Namespace: "default", Name: "web"}, chunkSize: 500, selector: "app=x",
+			want: "/api/v1/namespaces/default/pods/web"},
+		{name: "watch", req: plannedRequest{Verb: "watch", Mapping: pods}, chunkSize: 500,
+			want: "/api/v1/pods?watch=true"},
+		{name: "watch with selectors", req: plannedRequest{Verb: "watch", Mapping: pods}, fieldSelector: "status.phase=Running", selector: "app=x",
+			want: "/api/v1/pods?fieldSelector=status.phase%3DRunning&labelSelector=app%3Dx&watch=true"},
+		{name: "watch by name", req: plannedRequest{Verb: "watch", Mapping: pods, Namespace: "default", Name: "web"}, fieldSelector: "status.phase=Running",
+			want: "/api/v1/namespaces/default/pods?fieldSelector=metadata.name%3Dweb&watch=true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunkSizeFlag, fieldSelectorFlag, selectorFlag = tt.chunkSize, tt.fieldSelector, tt.selector
+			if got := tt.req.Path(); got != tt.want {
+				t.Errorf("Path() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtraRequestsNotes(t *testing.T) {
+	defer func(noOwners, events, pods, expandApp bool) {
+		noOwnersFlag, eventsFlag, podsFlag, expandAppFlag = noOwners, events, pods, expandApp
+	}(noOwnersFlag, eventsFlag, podsFlag, expandAppFlag)
+	deployment := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	tests := []struct {
+		name                              string
+		gk                                schema.GroupKind
+		noOwners, events, pods, expandApp bool
+		want                              int
+	}{
+		{name: "no owners", gk: deployment, noOwners: true},
+		{name: "owners", gk: deployment, want: 1},
+		{name: "events", gk: deployment, noOwners: true, events: true, want: 1},
+		{name: "pods", gk: deployment, noOwners: true, pods: true, want: 1},
+		{name: "pods of a kind without pods", gk: schema.GroupKind{Kind: "ConfigMap"}, noOwners: true, pods: true},
+		{name: "expand-app", gk: argoApplicationKind, noOwners: true, expandApp: true, want: 1},
+		{name: "all", gk: deployment, events: true, pods: true, expandApp: true, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noOwnersFlag, eventsFlag, podsFlag, expandAppFlag = tt.noOwners, tt.events, tt.pods, tt.expandApp
+			if got := extraRequestsNotes(tt.gk); len(got) != tt.want {
+				t.Errorf("extraRequestsNotes(%s) = %q, want %d notes", tt.gk, got, tt.want)
+			}
+		})
+	}
+}
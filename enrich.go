@@ -0,0 +1,105 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// enricher adds kind-specific smarts to the reports of the objects of a
+// GroupKind, for information that is not reflected in their
+// status.conditions.
+type enricher struct {
+	// Enrich adds derived conditions, notes and header lines to the report.
+	// It's called with a nil kc when no cluster access is available, so
+	// enrichers that query the API server must check for it.
+	Enrich func(ctx context.Context, kc *kubeClient, r *objectReport)
+
+	// NegativeTypes are the condition types of the kind whose True status
+	// means unhealthy. The polarity rules of the config file and
+	// --negative-polarity take precedence.
+	NegativeTypes []string
+
+	// Explain returns an explanation of the condition (or "") for
+	// --explain, made up from the other fields of the object. It's only
+	// used when explanations.yaml has no explanation for the condition.
+	Explain func(r *objectReport, cond GenericCondition) string
+}
+
+// enrichers holds the built-in enrichers, selected by the GroupKind of the
+// object being printed.
+var enrichers = map[schema.GroupKind]enricher{
+	{Kind: "Pod"}:                   {Enrich: offline(enrichPod)},
+	{Kind: "Service"}:               {Enrich: enrichService},
+	{Kind: "Node"}:                  {Enrich: enrichNode},
+	{Kind: "PersistentVolumeClaim"}: {Enrich: offline(enrichPersistentVolumeClaim)},
+
+	{Group: "apps", Kind: "Deployment"}:  {Enrich: offline(enrichDeployment), NegativeTypes: []string{"ReplicaFailure"}},
+	{Group: "apps", Kind: "ReplicaSet"}:  {NegativeTypes: []string{"ReplicaFailure"}},
+	{Group: "apps", Kind: "StatefulSet"}: {Enrich: offline(enrichStatefulSet)},
+	{Group: "apps", Kind: "DaemonSet"}:   {Enrich: enrichDaemonSet},
+	{Group: "batch", Kind: "Job"}:        {Enrich: offline(enrichJob), NegativeTypes: []string{"Failed", "FailureTarget", "Suspended"}},
+	{Group: "batch", Kind: "CronJob"}:    {Enrich: offline(enrichCronJob), NegativeTypes: []string{"Suspended"}},
+
+	{Group: "autoscaling", Kind: "HorizontalPodAutoscaler"}: {Enrich: offline(enrichHorizontalPodAutoscaler), NegativeTypes: []string{"ScalingLimited"}},
+
+	{Group: "networking.k8s.io", Kind: "Ingress"}:                     {Enrich: offline(enrichIngress)},
+	{Group: "snapshot.storage.k8s.io", Kind: "VolumeSnapshot"}:        {Enrich: offline(enrichVolumeSnapshot)},
+	{Group: "snapshot.storage.k8s.io", Kind: "VolumeSnapshotContent"}: {Enrich: offline(enrichVolumeSnapshot)},
+	{Group: "storage.k8s.io", Kind: "VolumeAttachment"}:               {Enrich: offline(enrichVolumeAttachment)},
+	{Group: "coordination.k8s.io", Kind: "Lease"}:                     {Enrich: offline(enrichLease)},
+
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: {Enrich: enrichWebhookConfiguration},
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   {Enrich: enrichWebhookConfiguration},
+
+	{Group: "cert-manager.io", Kind: "Certificate"}:                {Enrich: offline(enrichCertificate), NegativeTypes: []string{"Expired"}, Explain: explainCertificate},
+	{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"}:  {Enrich: offline(enrichFluxKustomization), NegativeTypes: []string{"Stalled", "Reconciling", "Suspended"}},
+	{Group: "cluster.x-k8s.io", Kind: "Machine"}:                   {Enrich: offline(enrichMachine), NegativeTypes: []string{"Failed", "Paused", "Deleting"}},
+	{Group: "operators.coreos.com", Kind: "ClusterServiceVersion"}: {Enrich: offline(enrichClusterServiceVersion)},
+	{Group: "config.openshift.io", Kind: "ClusterVersion"}:         {Enrich: offline(enrichClusterVersion), NegativeTypes: []string{"Failing"}},
+}
+
+// offline adapts an enricher that only looks at the object itself.
+func offline(fn func(r *objectReport)) func(context.Context, *kubeClient, *objectReport) {
+	return func(_ context.Context, _ *kubeClient, r *objectReport) { fn(r) }
+}
+
+// enrichReport adds the context of the enricher of the object's kind (if
+// any) to the report, along with the context that applies to all kinds.
+// Enrichers that need to query the API server use kc, which may be nil
+// when no cluster access is available.
+func enrichReport(ctx context.Context, kc *kubeClient, r *objectReport) {
+	if !noOwnersFlag {
+		enrichOwners(ctx, kc, r)
+	}
+	enrichTerminating(r)
+
+	if e := enrichers[r.Object.GroupVersionKind().GroupKind()]; e.Enrich != nil {
+		e.Enrich(ctx, kc, r)
+	}
+
+	if eventsFlag && kc != nil {
+		enrichEvents(ctx, kc, r)
+	}
+}
+
+// isEnricherNegativeType reports whether the enricher of the kind declares
+// True status of the condition type as unhealthy.
+func isEnricherNegativeType(gk schema.GroupKind, condType string) bool {
+	return slices.Contains(enrichers[gk].NegativeTypes, condType)
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// maxEvents is the number of most recent Warning events shown per object.
+const maxEvents = 5
+
+var eventsFlag bool
+
+// workloadKinds are the kinds whose pods' events are shown along with the
+// events of the object itself, as problems mostly surface on the pods.
+var workloadKinds = sets.New(
+	schema.GroupKind{Group: "apps", Kind: "Deployment"},
+	schema.GroupKind{Group: "apps", Kind: "ReplicaSet"},
+	schema.GroupKind{Group: "apps", Kind: "StatefulSet"},
+	schema.GroupKind{Group: "apps", Kind: "DaemonSet"},
+	schema.GroupKind{Group: "batch", Kind: "Job"},
+)
+
+// enrichEvents adds the recent Warning events involving the object (and its
+// pods, for workloads) to the report, which often explain why a condition
+// is unhealthy.
+func enrichEvents(ctx context.Context, kc *kubeClient, r *objectReport) {
+	events, err := warningEvents(ctx, kc, r.Object)
+	if err != nil {
+		r.Header = append(r.Header, gray.Sprintf("Events are unavailable: %v", err))
+		return
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).After(eventTime(events[j]))
+	})
+	if len(events) > maxEvents {
+		r.Header = append(r.Header, gray.Sprintf("%d most recent of %d warning events:", maxEvents, len(events)))
+		events = events[:maxEvents]
+	}
+	now := time.Now()
+	for _, ev := range events {
+		line := color.YellowString("Warning %s", ev.Reason)
+		if ev.InvolvedObject.UID != r.Object.GetUID() {
+			line = strings.ToLower(ev.InvolvedObject.Kind) + "/" + ev.InvolvedObject.Name + ": " + line
+		}
+		line += ": " + strings.TrimSpace(ev.Message)
+		var age string
+		if t := eventTime(ev); !t.IsZero() {
+			age = duration.HumanDuration(now.Sub(t)) + " ago"
+		}
+		if ev.Count > 1 {
+			age = fmt.Sprintf("x%d, last %s", ev.Count, age)
+		}
+		if age != "" {
+			line += gray.Sprintf(" (%s)", age)
+		}
+		r.Header = append(r.Header, line)
+	}
+}
+
+// warningEvents returns the Warning events involving the object, and its pods
+// if it's a workload.
+func warningEvents(ctx context.Context, kc *kubeClient, obj *unstructured.Unstructured) ([]corev1.Event, error) {
+	cs, err := kc.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	list, err := cs.CoreV1().Events(obj.GetNamespace()).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.uid": string(obj.GetUID()), "type": corev1.EventTypeWarning}.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	events := list.Items
+	if !workloadKinds.Has(obj.GroupVersionKind().GroupKind()) {
+		return events, nil
+	}
+
+	selector, err := workloadSelector(obj)
+	if err != nil {
+		return events, err
+	}
+	pods, err := cs.CoreV1().Pods(obj.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return events, fmt.Errorf("failed to list pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return events, nil
+	}
+	uids := sets.New[string]()
+	for _, pod := range pods.Items {
+		uids.Insert(string(pod.UID))
+	}
+	podEvents, err := cs.CoreV1().Events(obj.GetNamespace()).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": "Pod", "type": corev1.EventTypeWarning}.String(),
+	})
+	if err != nil {
+		return events, fmt.Errorf("failed to list pod events: %w", err)
+	}
+	for _, ev := range podEvents.Items {
+		if uids.Has(string(ev.InvolvedObject.UID)) {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+// eventTime returns the last time the event was observed.
+func eventTime(ev corev1.Event) time.Time {
+	switch {
+	case ev.Series != nil && !ev.Series.LastObservedTime.IsZero():
+		return ev.Series.LastObservedTime.Time
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	default:
+		return ev.CreationTimestamp.Time
+	}
+}
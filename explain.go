@@ -0,0 +1,173 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+var explainFlag bool
+
+//go:embed explanations.yaml
+var builtinExplanations []byte
+
+// explanation describes a well-known condition in words, along with a
+// command that can help investigating it.
+type explanation struct {
+	Group       string                 `json:"group,omitempty"`
+	Kind        string                 `json:"kind"`
+	Type        string                 `json:"type"`
+	Status      metav1.ConditionStatus `json:"status,omitempty"`
+	Reason      string                 `json:"reason,omitempty"`
+	Explanation string                 `json:"explanation"`
+	Suggestion  string                 `json:"suggestion,omitempty"`
+
+	suggestion *template.Template
+}
+
+type explanationsFile struct {
+	Explanations []explanation `json:"explanations"`
+}
+
+// explanationSources holds the explanations of the override file (if any)
+// followed by the built-in ones, in the order they're looked up.
+var explanationSources [][]explanation
+
+// explanationsPath returns the location of the file with explanations that
+// override or extend the built-in ones, next to the config file.
+func explanationsPath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "explanations.yaml"), nil
+}
+
+// loadExplanations parses the built-in explanations and the override file.
+func loadExplanations() error {
+	builtin, err := parseExplanations(builtinExplanations)
+	if err != nil {
+		return fmt.Errorf("invalid built-in explanations: %w", err)
+	}
+	explanationSources = [][]explanation{builtin}
+
+	path, err := explanationsPath()
+	if err != nil {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read explanations file: %w", err)
+	}
+	overrides, err := parseExplanations(b)
+	if err != nil {
+		return fmt.Errorf("invalid explanations file %s: %w", path, err)
+	}
+	explanationSources = [][]explanation{overrides, builtin}
+	return nil
+}
+
+func parseExplanations(b []byte) ([]explanation, error) {
+	var f explanationsFile
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, err
+	}
+	for i, e := range f.Explanations {
+		if e.Kind == "" || e.Type == "" || e.Explanation == "" {
+			return nil, fmt.Errorf("explanation #%d: kind, type and explanation are required", i+1)
+		}
+		if e.Suggestion == "" {
+			continue
+		}
+		t, err := template.New("suggestion").Option("missingkey=error").Parse(e.Suggestion)
+		if err != nil {
+			return nil, fmt.Errorf("explanation #%d: invalid suggestion: %w", i+1, err)
+		}
+		f.Explanations[i].suggestion = t
+	}
+	return f.Explanations, nil
+}
+
+// lookupExplanation returns the explanation of the condition of an object
+// of the given kind. Explanations matching the reason of the condition take
+// precedence over the ones for any reason.
+func lookupExplanation(gk schema.GroupKind, cond GenericCondition) *explanation {
+	for _, source := range explanationSources {
+		var anyReason *explanation
+		for i, e := range source {
+			if e.Group != gk.Group || !strings.EqualFold(e.Kind, gk.Kind) || e.Type != cond.Type ||
+				(e.Status != "" && e.Status != cond.Status) {
+				continue
+			}
+			if e.Reason == cond.Reason {
+				return &source[i]
+			}
+			if e.Reason == "" && anyReason == nil {
+				anyReason = &source[i]
+			}
+		}
+		if anyReason != nil {
+			return anyReason
+		}
+	}
+	return nil
+}
+
+// addExplanations adds the explanations of the well-known conditions of the
+// object (and the suggested commands) to their notes. Conditions without one
+// are explained by the enricher of the kind, if it can.
+func addExplanations(r *objectReport) {
+	gk := r.Object.GroupVersionKind().GroupKind()
+	data := struct {
+		Kind, Name, Namespace, NamespaceFlag string
+	}{Kind: r.Kind, Name: r.Name, Namespace: r.Namespace}
+	if r.Namespace != "" {
+		data.NamespaceFlag = "-n " + r.Namespace
+	}
+	for i, cond := range r.Conditions {
+		e := lookupExplanation(gk, cond)
+		if e == nil {
+			if explain := enrichers[gk].Explain; explain != nil {
+				if s := explain(r, cond); s != "" {
+					r.Conditions[i].Notes = append(r.Conditions[i].Notes, "Explanation: "+s)
+				}
+			}
+			continue
+		}
+		r.Conditions[i].Notes = append(r.Conditions[i].Notes, "Explanation: "+e.Explanation)
+		if e.suggestion == nil {
+			continue
+		}
+		var sb strings.Builder
+		if err := e.suggestion.Execute(&sb, data); err != nil {
+			r.Conditions[i].Notes = append(r.Conditions[i].Notes, fmt.Sprintf("Invalid suggestion: %v", err))
+			continue
+		}
+		r.Conditions[i].Notes = append(r.Conditions[i].Notes, "Try: "+strings.Join(strings.Fields(sb.String()), " "))
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+func newExportCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var listen string
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "export <object-type> [<object-name>...] --listen <address>",
+		Short: "Serve the conditions of the requested objects as Prometheus metrics",
+		Long: "Periodically list the requested objects and serve their conditions on /metrics as Prometheus metrics:\n\n" +
+			"  kube_cond{kind,namespace,name,type,status,reason}        1 for the current status and reason of each condition\n" +
+			"  kubectl_cond_condition_transitions_total{kind,namespace,name,type}  status changes since the exporter started\n\n" +
+			"along with the kubectl_cond_condition, kubectl_cond_condition_healthy and kubectl_cond_condition_reason " +
+			"gauges of --push-metrics.\n\n" +
+			"The metrics are served without authentication on localhost by default; pass --listen (e.g. :9123) " +
+			"to let Prometheus scrape them from other hosts.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return errors.New("--interval must be positive")
+			}
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+			e := &exporter{configFlags: configFlags, args: args, tracker: newConditionTracker(),
+				transitions: make(map[string]int)}
+			return e.run(ctx, listen, interval)
+		},
+	}
+	cmd.Flags().StringVar(&listen, "listen", "localhost:9123", "Address to serve the metrics on.")
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "Interval between listing the requested objects.")
+	return cmd
+}
+
+// exporter collects the conditions of the requested objects periodically,
+// and serves the metrics of the last collection.
+type exporter struct {
+	configFlags *genericclioptions.ConfigFlags
+	args        []string
+
+	// tracker and transitions are only used by the collection loop
+	tracker     *conditionTracker
+	transitions map[string]int
+
+	mu      sync.Mutex
+	metrics []byte
+}
+
+func (e *exporter) run(ctx context.Context, listen string, interval time.Duration) error {
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", e.serveMetrics)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+	fmt.Fprintf(stderr, "Serving metrics on http://%s/metrics\n", ln.Addr())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := e.collect(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			// keep serving the last collected metrics
+			fmt.Fprintln(stderr, gray.Sprintf("Failed to collect conditions: %v", err))
+		}
+		select {
+		case <-ctx.Done():
+			// like --watch, the exporter stops after --timeout
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return srv.Shutdown(shutdownCtx)
+		case err := <-errCh:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect lists the requested objects and updates the served metrics.
+func (e *exporter) collect(ctx context.Context, now time.Time) error {
+	rb, err := newResourceBuilder(e.configFlags)
+	if err != nil {
+		return err
+	}
+	kc := &kubeClient{configFlags: e.configFlags}
+	var reports []*objectReport
+	seen := make(map[string]bool)
+	err = queryLatest(rb.ResourceTypeOrNameArgs(true, e.args...)).Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		r, err := newObjectReport(ctx, kc, info.Object)
+		if errors.Is(err, errNoConditions) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		reports = append(reports, r)
+		seen[objectKey(r.Object)] = true
+		for _, t := range e.tracker.observe(r, now) {
+			if t.Old != nil && t.New != nil && t.Old.Status != t.New.Status {
+				e.transitions[metricLabels(r, *t.New)]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// stop exporting the objects that were deleted
+	for key := range e.tracker.objects {
+		if !seen[key] {
+			delete(e.tracker.objects, key)
+			delete(e.tracker.outdated, key)
+		}
+	}
+	current := make(map[string]bool)
+	for _, r := range reports {
+		for _, cond := range r.Conditions {
+			current[metricLabels(r, cond)] = true
+		}
+	}
+	for labels := range e.transitions {
+		if !current[labels] {
+			delete(e.transitions, labels)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := writeConditionMetric(&b, reports); err != nil {
+		return err
+	}
+	if err := writeMetrics(&b, reports, now); err != nil {
+		return err
+	}
+	b.WriteString("# HELP kubectl_cond_condition_transitions_total The number of status changes of the condition of the object observed by the exporter.\n")
+	b.WriteString("# TYPE kubectl_cond_condition_transitions_total counter\n")
+	labels := make([]string, 0, len(current))
+	for l := range current {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		fmt.Fprintf(&b, "kubectl_cond_condition_transitions_total{%s} %d\n", l, e.transitions[l])
+	}
+	e.mu.Lock()
+	e.metrics = b.Bytes()
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *exporter) serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	e.mu.Lock()
+	metrics := e.metrics
+	e.mu.Unlock()
+	if metrics == nil {
+		http.Error(w, "conditions have not been collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(metrics)
+}
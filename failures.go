@@ -0,0 +1,68 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// printFailures prints the errors of the objects (or resource types) that
+// failed to be queried, grouped by their reason, along with how to fix the
+// permission errors.
+func printFailures(w io.Writer, failures []error) {
+	var reasons []string
+	byReason := make(map[string][]error)
+	for _, err := range failures {
+		for _, e := range flattenErrors(err) {
+			reason := failureReason(e)
+			if _, ok := byReason[reason]; !ok {
+				reasons = append(reasons, reason)
+			}
+			byReason[reason] = append(byReason[reason], e)
+		}
+	}
+	sort.Strings(reasons)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, activeTheme.warning.Sprintf("Failed to query %d objects or resource types:", len(failures)))
+	for _, reason := range reasons {
+		errs := byReason[reason]
+		fmt.Fprintf(w, "  %s (%d):\n", reason, len(errs))
+		for _, e := range errs {
+			fmt.Fprintln(w, gray.Sprintf("    %v", e))
+		}
+	}
+	for _, hint := range rbacHints(errors.Join(failures...)) {
+		fmt.Fprintln(w, hint)
+	}
+}
+
+// failureReason returns the reason of the API error, e.g. Forbidden.
+func failureReason(err error) string {
+	if reason := apierrors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return string(metav1.StatusReasonTimeout)
+	}
+	return "Error"
+}
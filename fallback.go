@@ -0,0 +1,45 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var ignoreMissingFlag bool
+
+// addFallbackStatus describes the state of an object that doesn't report
+// any conditions using the other status fields commonly found on objects,
+// such as its phase or the number of ready replicas.
+func addFallbackStatus(r *objectReport) {
+	var lines []string
+	if phase, _, _ := unstructured.NestedString(r.Object.Object, "status", "phase"); phase != "" {
+		lines = append(lines, "Phase: "+bold.Sprint(phase))
+	}
+	if state, _, _ := unstructured.NestedString(r.Object.Object, "status", "state"); state != "" {
+		lines = append(lines, "State: "+bold.Sprint(state))
+	}
+	if desired, ok, _ := unstructured.NestedInt64(r.Object.Object, "spec", "replicas"); ok {
+		ready, _, _ := unstructured.NestedInt64(r.Object.Object, "status", "readyReplicas")
+		line := fmt.Sprintf("Replicas: %d/%d ready", ready, desired)
+		if ready < desired {
+			line = activeTheme.warning.Sprint(line)
+		}
+		lines = append(lines, line)
+	}
+	r.Header = append(r.Header, lines...)
+}
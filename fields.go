@@ -0,0 +1,99 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var showFlag []string
+var hideFlag []string
+
+// detailFields are the parts of the details of a condition that can be
+// selected with --show and --hide.
+var detailFields = []string{"reason", "message", "notes", "lastTransition", "lastUpdate", "heartbeat"}
+
+// hiddenFields holds the detail fields that are not printed.
+var hiddenFields = make(map[string]bool)
+
+// setupDetailFields validates --show and --hide and determines the hidden
+// detail fields.
+func setupDetailFields() error {
+	parse := func(flag string, values []string) ([]string, error) {
+		var fields []string
+		for _, v := range values {
+			i := slices.IndexFunc(detailFields, func(f string) bool { return strings.EqualFold(f, v) })
+			if i < 0 {
+				return nil, fmt.Errorf("unsupported --%s field %q, expected one of: (%s)", flag, v, strings.Join(detailFields, ", "))
+			}
+			fields = append(fields, detailFields[i])
+		}
+		return fields, nil
+	}
+	show, err := parse("show", showFlag)
+	if err != nil {
+		return err
+	}
+	hide, err := parse("hide", hideFlag)
+	if err != nil {
+		return err
+	}
+	for _, f := range detailFields {
+		hiddenFields[f] = (len(show) > 0 && !slices.Contains(show, f)) || slices.Contains(hide, f)
+	}
+	return nil
+}
+
+// withDetailFields wraps the printer to drop the detail fields hidden with
+// --show and --hide from the conditions.
+func withDetailFields(p printer) printer {
+	if len(showFlag) == 0 && len(hideFlag) == 0 {
+		return p
+	}
+	return &detailFieldsPrinter{p}
+}
+
+type detailFieldsPrinter struct {
+	printer
+}
+
+func (p *detailFieldsPrinter) Print(r *objectReport) error {
+	trimmed := *r
+	trimmed.Conditions = make([]GenericCondition, len(r.Conditions))
+	for i, cond := range r.Conditions {
+		if hiddenFields["reason"] {
+			cond.Reason = ""
+		}
+		if hiddenFields["message"] {
+			cond.Message = ""
+		}
+		if hiddenFields["notes"] {
+			cond.Notes = nil
+		}
+		if hiddenFields["lastTransition"] {
+			cond.LastTransitionTime = nil
+		}
+		if hiddenFields["lastUpdate"] {
+			cond.LastUpdateTime = nil
+		}
+		if hiddenFields["heartbeat"] {
+			cond.LastHeartbeatTime = nil
+		}
+		trimmed.Conditions[i] = cond
+	}
+	return p.printer.Print(&trimmed)
+}
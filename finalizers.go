@@ -0,0 +1,93 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// knownFinalizer describes the controller responsible for removing a
+// well-known finalizer.
+type knownFinalizer struct {
+	// name is the finalizer name, or a prefix when it ends with "/".
+	name       string
+	controller string
+	builtin    bool
+}
+
+var knownFinalizers = []knownFinalizer{
+	{"foregroundDeletion", "garbage collector (waits for dependents with blockOwnerDeletion to be deleted)", true},
+	{"orphan", "garbage collector (orphans the dependents)", true},
+	{"kubernetes", "namespace controller (waits for all objects in the namespace to be deleted)", true},
+	{"kubernetes.io/pvc-protection", "pvc-protection controller (waits until no pod uses the claim)", true},
+	{"kubernetes.io/pv-protection", "pv-protection controller (waits until the volume is released)", true},
+	{"batch.kubernetes.io/job-tracking", "job controller", true},
+	{"customresourcecleanup.apiextensions.k8s.io", "apiextensions-apiserver (waits for custom resources to be deleted)", true},
+	{"service.kubernetes.io/load-balancer-cleanup", "cloud-controller-manager (waits for the load balancer to be deleted)", true},
+	{"external-attacher/", "CSI external-attacher sidecar (waits for the volume to be detached)", false},
+	{"external-provisioner.volume.kubernetes.io/", "CSI external-provisioner sidecar", false},
+	{"snapshot.storage.kubernetes.io/", "CSI snapshot controller", false},
+	{"resources-finalizer.argocd.argoproj.io", "Argo CD application controller (deletes the managed resources)", false},
+	{"finalizers.fluxcd.io", "Flux controllers", false},
+	{"cluster.x-k8s.io/", "Cluster API controllers", false},
+}
+
+// lookupFinalizer returns the known controller responsible for the
+// finalizer, if any.
+func lookupFinalizer(finalizer string) (knownFinalizer, bool) {
+	for _, f := range knownFinalizers {
+		if f.name == finalizer || (strings.HasSuffix(f.name, "/") && strings.HasPrefix(finalizer, f.name)) {
+			return f, true
+		}
+	}
+	return knownFinalizer{}, false
+}
+
+// likelyBlockingFinalizer guesses which of the remaining finalizers is
+// holding up the deletion. Finalizers of third-party controllers are
+// suspected first since those controllers are commonly uninstalled or
+// unhealthy before their objects are cleaned up.
+func likelyBlockingFinalizer(finalizers []string) string {
+	if len(finalizers) == 0 {
+		return ""
+	}
+	for _, f := range finalizers {
+		if kf, ok := lookupFinalizer(f); !ok || !kf.builtin {
+			return f
+		}
+	}
+	return finalizers[0]
+}
+
+// finalizerNotes returns the lines describing the remaining finalizers of a
+// terminating object. When stuck is set, the likely blocking finalizer is
+// pointed out.
+func finalizerNotes(finalizers []string, stuck bool) []string {
+	if len(finalizers) == 0 {
+		return nil
+	}
+	notes := []string{fmt.Sprintf("Remaining finalizers: %s", strings.Join(finalizers, ", "))}
+	if !stuck {
+		return notes
+	}
+	blocking := likelyBlockingFinalizer(finalizers)
+	if kf, ok := lookupFinalizer(blocking); ok {
+		notes = append(notes, fmt.Sprintf("Likely blocked by %q, handled by %s", blocking, kf.controller))
+	} else {
+		notes = append(notes, fmt.Sprintf("Likely blocked by %q, check that the controller handling it is running", blocking))
+	}
+	return notes
+}
@@ -0,0 +1,331 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func newFindCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var kinds, where []string
+	cmd := &cobra.Command{
+		Use:   "find [TYPE[=STATUS]] [--where <predicate>] [--kinds <type>,...] [-n <namespace> | -A]",
+		Short: "Find the objects that have a condition matching a predicate",
+		Long: "List the objects of the given resource types (all types with conditions if --kinds is not given) " +
+			"that have a condition matching all the predicates, along with the matching conditions.\n\n" +
+			"A predicate is a comma-separated list of FIELD OP VALUE terms, where FIELD is one of " +
+			"type, status, reason, message, health (healthy, unhealthy or unknown, considering the polarity " +
+			"of the condition) or age (time since the last transition). " +
+			"The = and != operators compare the value (which can be a glob pattern for type), " +
+			"=~ and !~ match a regular expression, and < and > compare the age with a duration.\n\n" +
+			"For example, \"find Ready=False --kinds pods,nodes -A\" is the same as " +
+			"\"find --where type=Ready,status=False --kinds pods,nodes -A\", and " +
+			"\"find --where 'health=unhealthy,reason=~Evicted|OOM,age>1h'\" finds the objects that have been " +
+			"unhealthy for more than an hour with a matching reason.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var preds []conditionPredicate
+			if len(args) > 0 {
+				typ, status, err := parseTransitionFilter(args[0])
+				if err != nil {
+					return err
+				}
+				preds = append(preds, conditionPredicate{field: "type", op: "=", value: typ})
+				if status != "" {
+					preds = append(preds, conditionPredicate{field: "status", op: "=", value: string(status)})
+				}
+			}
+			for _, w := range where {
+				p, err := parseConditionPredicates(w)
+				if err != nil {
+					return err
+				}
+				preds = append(preds, p...)
+			}
+			if len(preds) == 0 {
+				return errors.New("a condition to find is required, e.g. \"find Ready=False\" or \"find --where health=unhealthy\"")
+			}
+			switch outputFlag {
+			case "", "name":
+			default:
+				return fmt.Errorf("output format %q is not supported by find, expected one of: (name)", outputFlag)
+			}
+
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+			namespace := ""
+			if !allNamespacesFlag {
+				ns, err := resolveNamespace(configFlags)
+				if err != nil {
+					return err
+				}
+				namespace = ns
+			}
+			var targets []scanTarget
+			var targetsErr error
+			if len(kinds) > 0 {
+				var err error
+				if targets, err = resolveScanTargets(configFlags, kinds); err != nil {
+					return err
+				}
+			} else {
+				// cluster-scoped objects are only searched across all namespaces
+				if targets, targetsErr = discoverScanTargets(configFlags, allNamespacesFlag); len(targets) == 0 {
+					return targetsErr
+				}
+			}
+
+			matches, err := findObjects(ctx, &kubeClient{configFlags: configFlags}, targets, namespace, preds)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s", timeoutFlag)
+			}
+			err = errors.Join(err, targetsErr)
+			if len(matches) == 0 && err != nil {
+				return err
+			} else if err != nil {
+				fmt.Fprintln(stderr, gray.Sprintf("Some resource types couldn't be listed: %v", err))
+			}
+			if outputFlag == "name" {
+				for _, m := range matches {
+					fmt.Fprintln(stdout, strings.ToLower(m.report.Object.GroupVersionKind().GroupKind().String())+"/"+m.report.Name)
+				}
+			} else {
+				printFoundObjects(stdout, matches, allNamespacesFlag)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&kinds, "kinds", nil, "Comma-separated resource types to search (e.g. pods,nodes). Defaults to all listable types.")
+	cmd.Flags().StringArrayVar(&where, "where", nil, "Comma-separated predicates that a condition must match (e.g. type=Ready,status=False,reason=~Evicted). Can be repeated.")
+	return cmd
+}
+
+// conditionPredicate is a term of a --where expression matching a field of
+// a condition.
+type conditionPredicate struct {
+	field, op, value string
+	re               *regexp.Regexp
+	age              time.Duration
+}
+
+// predicateOperators are the supported operators, with the ones that are
+// prefixes of others last.
+var predicateOperators = []string{"!=", "=~", "!~", "=", "<", ">"}
+
+// parseConditionPredicates parses a comma-separated list of FIELD OP VALUE
+// terms.
+func parseConditionPredicates(s string) ([]conditionPredicate, error) {
+	var out []conditionPredicate
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		i := strings.IndexAny(term, "=!~<>")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid predicate %q, expected FIELD OP VALUE (e.g. status=False)", term)
+		}
+		p := conditionPredicate{field: strings.ToLower(strings.TrimSpace(term[:i]))}
+		for _, op := range predicateOperators {
+			if strings.HasPrefix(term[i:], op) {
+				p.op, p.value = op, strings.TrimSpace(term[i+len(op):])
+				break
+			}
+		}
+		if p.op == "" {
+			return nil, fmt.Errorf("invalid operator in predicate %q, expected one of: (%s)", term, strings.Join(predicateOperators, ", "))
+		}
+
+		switch p.field {
+		case "type", "status", "reason", "message":
+			if p.op == "<" || p.op == ">" {
+				return nil, fmt.Errorf("invalid predicate %q, %s can only be compared with =, !=, =~ and !~", term, p.field)
+			}
+		case "health":
+			if p.op != "=" && p.op != "!=" {
+				return nil, fmt.Errorf("invalid predicate %q, health can only be compared with = and !=", term)
+			}
+			switch strings.ToLower(p.value) {
+			case "healthy", "unhealthy", "unknown":
+			default:
+				return nil, fmt.Errorf("invalid health %q, expected one of: (healthy, unhealthy, unknown)", p.value)
+			}
+		case "age":
+			if p.op != "<" && p.op != ">" {
+				return nil, fmt.Errorf("invalid predicate %q, age can only be compared with < and >", term)
+			}
+			d, err := time.ParseDuration(p.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid age in predicate %q: %w", term, err)
+			}
+			p.age = d
+		default:
+			return nil, fmt.Errorf("unknown field %q in predicate %q, expected one of: (type, status, reason, message, health, age)", p.field, term)
+		}
+		if p.op == "=~" || p.op == "!~" {
+			re, err := regexp.Compile(p.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression in predicate %q: %w", term, err)
+			}
+			p.re = re
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// matches reports whether the condition matches the predicate. Conditions
+// without a last transition time never match age predicates.
+func (p conditionPredicate) matches(cond GenericCondition, now time.Time) bool {
+	var v string
+	switch p.field {
+	case "type":
+		v = cond.Type
+	case "status":
+		v = string(cond.Status)
+	case "reason":
+		v = cond.Reason
+	case "message":
+		v = cond.Message
+	case "health":
+		v = map[metav1.ConditionStatus]string{
+			metav1.ConditionTrue:    "healthy",
+			metav1.ConditionFalse:   "unhealthy",
+			metav1.ConditionUnknown: "unknown",
+		}[semanticStatus(cond)]
+	case "age":
+		if cond.LastTransitionTime == nil {
+			return false
+		}
+		age := now.Sub(cond.LastTransitionTime.Time)
+		if p.op == "<" {
+			return age < p.age
+		}
+		return age > p.age
+	}
+
+	switch p.op {
+	case "=~":
+		return p.re.MatchString(v)
+	case "!~":
+		return !p.re.MatchString(v)
+	}
+	var eq bool
+	switch p.field {
+	case "type":
+		eq, _ = path.Match(p.value, v)
+	case "status", "health":
+		eq = strings.EqualFold(p.value, v)
+	default:
+		eq = p.value == v
+	}
+	return eq == (p.op == "=")
+}
+
+// foundObject is an object with the conditions matching the predicates.
+type foundObject struct {
+	report     *objectReport
+	conditions []GenericCondition
+}
+
+// findObjects scans the objects of the resource types and returns the ones
+// that have conditions matching all the predicates, sorted by kind and
+// name. Enrichers that query the API server are skipped to keep it fast.
+func findObjects(ctx context.Context, kc *kubeClient, targets []scanTarget, namespace string, preds []conditionPredicate) ([]foundObject, error) {
+	dyn, err := kc.Dynamic()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var mu sync.Mutex
+	var out []foundObject
+	err = scanObjects(ctx, dyn, targets, namespace, func(obj *unstructured.Unstructured) {
+		r, err := newObjectReport(ctx, nil, obj)
+		if err != nil {
+			return
+		}
+		var conds []GenericCondition
+		for _, cond := range r.Conditions {
+			if matchesAll(preds, cond, now) {
+				conds = append(conds, cond)
+			}
+		}
+		if len(conds) == 0 {
+			return
+		}
+		mu.Lock()
+		out = append(out, foundObject{report: r, conditions: conds})
+		mu.Unlock()
+	})
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].report, out[j].report
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return out, err
+}
+
+func matchesAll(preds []conditionPredicate, cond GenericCondition, now time.Time) bool {
+	for _, p := range preds {
+		if !p.matches(cond, now) {
+			return false
+		}
+	}
+	return true
+}
+
+// printFoundObjects prints a row for each matching condition of the objects.
+func printFoundObjects(w io.Writer, found []foundObject, namespaced bool) {
+	if len(found) == 0 {
+		fmt.Fprintln(stderr, "No objects with matching conditions found.")
+		return
+	}
+	now := time.Now()
+	table := newPlainTable(w, "Kind", "Name", "Condition", "Reason", "Age", "Message")
+	for _, f := range found {
+		name := f.report.Name
+		if namespaced {
+			name = f.report.displayName()
+		}
+		for _, cond := range f.conditions {
+			age := "-"
+			if cond.LastTransitionTime != nil {
+				age = duration.HumanDuration(now.Sub(cond.LastTransitionTime.Time))
+			}
+			table.Append([]string{f.report.Kind, name, statusColor(cond)(cond.Type + "=" + string(cond.Status)),
+				orDash(cond.Reason), age, strings.Join(strings.Fields(cond.Message), " ")})
+		}
+	}
+	table.Render()
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d objects found\n", len(found))
+}
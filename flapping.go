@@ -0,0 +1,109 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var flapThresholdFlag int
+var flapWindowFlag time.Duration
+
+// flapDetector keeps the recent status changes of each watched condition
+// to detect conditions that oscillate between statuses.
+type flapDetector struct {
+	threshold int
+	window    time.Duration
+
+	// changes holds the times of the status changes within the window,
+	// keyed by object and condition type.
+	changes map[string][]time.Time
+	// flapping holds the most status changes seen within the window for
+	// the conditions that have flapped.
+	flapping map[string]flapRecord
+}
+
+type flapRecord struct {
+	name    string
+	changes int
+	warned  time.Time
+}
+
+func newFlapDetector(threshold int, window time.Duration) *flapDetector {
+	return &flapDetector{
+		threshold: threshold,
+		window:    window,
+		changes:   make(map[string][]time.Time),
+		flapping:  make(map[string]flapRecord),
+	}
+}
+
+// record counts the status change of the transition, and prints a warning
+// when the condition changed status at least threshold times within the
+// window. Conditions are warned about at most once per window.
+func (d *flapDetector) record(w io.Writer, t conditionTransition) {
+	if d.threshold <= 0 || t.Old == nil || t.New == nil || t.Old.Status == t.New.Status {
+		return
+	}
+	name := t.Kind + " " + t.displayName() + " " + t.New.Type
+	key := t.Kind + "/" + t.Namespace + "/" + t.Name + "/" + t.New.Type
+	cutoff := t.Time.Add(-d.window)
+	changes := append(d.changes[key], t.Time)
+	i := 0
+	for i < len(changes) && changes[i].Before(cutoff) {
+		i++
+	}
+	changes = changes[i:]
+	d.changes[key] = changes
+	if len(changes) < d.threshold {
+		return
+	}
+
+	rec := d.flapping[key]
+	rec.name = name
+	rec.changes = max(rec.changes, len(changes))
+	if rec.warned.IsZero() || t.Time.Sub(rec.warned) >= d.window {
+		rec.warned = t.Time
+		fmt.Fprintln(w, activeTheme.warning.Sprintf("⚠ %s %s %s flapped %d times in %s",
+			bold.Sprint(t.Kind), t.displayName(), t.New.Type, len(changes), duration.HumanDuration(d.window)))
+	}
+	d.flapping[key] = rec
+}
+
+// printSummary lists the conditions that have flapped during the watch.
+func (d *flapDetector) printSummary(w io.Writer) {
+	if len(d.flapping) == 0 {
+		return
+	}
+	recs := make([]flapRecord, 0, len(d.flapping))
+	for _, rec := range d.flapping {
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].changes != recs[j].changes {
+			return recs[i].changes > recs[j].changes
+		}
+		return recs[i].name < recs[j].name
+	})
+	fmt.Fprintln(w, "Flapping conditions:")
+	for _, rec := range recs {
+		fmt.Fprintf(w, "  %s (up to %d status changes in %s)\n", rec.name, rec.changes, duration.HumanDuration(d.window))
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+var fleetFlag bool
+
+// outlierColor highlights the cells and the objects that differ from the
+// rest of the fleet.
+var outlierColor = color.New(color.Bold, color.Underline)
+
+// fleetPrinter buffers all reports and renders them as a grid where rows
+// are condition types and columns are objects, to compare similar objects
+// (e.g. the pods of a Deployment). Objects whose conditions differ from the
+// state of most other objects are highlighted as outliers.
+type fleetPrinter struct {
+	w       io.Writer
+	reports []*objectReport
+}
+
+func (p *fleetPrinter) Print(r *objectReport) error {
+	p.reports = append(p.reports, r)
+	return nil
+}
+
+func (p *fleetPrinter) Flush() error {
+	if len(p.reports) == 0 {
+		return nil
+	}
+	groups := groupByCondition(p.reports)
+	names := fleetNames(p.reports)
+
+	// cells[t][i] is the glyph of condition type t on object i
+	cells := make(map[string][]string)
+	for _, g := range groups {
+		row := make([]string, len(p.reports))
+		for i, r := range p.reports {
+			row[i] = gray.Sprint("-")
+			for _, cond := range r.Conditions {
+				if cond.Type == g.Type {
+					row[i] = matrixCell(cond)
+					break
+				}
+			}
+		}
+		cells[g.Type] = row
+	}
+	outliers := make([][]string, len(p.reports)) // outlying condition types of each object
+	for _, g := range groups {
+		for _, i := range fleetOutliers(cells[g.Type]) {
+			outliers[i] = append(outliers[i], g.Type)
+		}
+	}
+
+	header := []string{"Condition"}
+	for i, name := range names {
+		if len(outliers[i]) > 0 {
+			name = outlierColor.Sprint(name)
+		}
+		header = append(header, name)
+	}
+	table := tablewriter.NewWriter(p.w)
+	table.SetHeader(header)
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetCenterSeparator("")
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	alignment := []int{tablewriter.ALIGN_LEFT}
+	for range names {
+		alignment = append(alignment, tablewriter.ALIGN_CENTER)
+	}
+	table.SetColumnAlignment(alignment)
+	for _, g := range groups {
+		row := []string{g.Type}
+		outlying := fleetOutliers(cells[g.Type])
+		for i, cell := range cells[g.Type] {
+			if slices.Contains(outlying, i) {
+				cell = outlierColor.Sprint("[") + cell + outlierColor.Sprint("]")
+			}
+			row = append(row, cell)
+		}
+		table.Append(row)
+	}
+	table.Render()
+
+	fmt.Fprintln(p.w)
+	var lines []string
+	for i, types := range outliers {
+		if len(types) > 0 {
+			lines = append(lines, fmt.Sprintf("  %s (%s)", outlierColor.Sprint(names[i]), strings.Join(types, ", ")))
+		}
+	}
+	if len(lines) == 0 {
+		_, err := fmt.Fprintf(p.w, "No outliers among %d objects.\n", len(p.reports))
+		return err
+	}
+	fmt.Fprintf(p.w, "Outliers among %d objects:\n", len(p.reports))
+	_, err := fmt.Fprintln(p.w, strings.Join(lines, "\n"))
+	return err
+}
+
+// fleetOutliers returns the positions of the cells that differ from the
+// state of the majority of the cells. There are no outliers without a
+// majority.
+func fleetOutliers(cells []string) []int {
+	counts := make(map[string]int)
+	for _, c := range cells {
+		counts[c]++
+	}
+	var majority string
+	for c, n := range counts {
+		if n*2 > len(cells) {
+			majority = c
+		}
+	}
+	if majority == "" {
+		return nil
+	}
+	var out []int
+	for i, c := range cells {
+		if c != majority {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// fleetNames returns the column names of the objects, without the prefix
+// common to all of them (such as the name of the ReplicaSet of pods) to keep
+// the columns narrow.
+func fleetNames(reports []*objectReport) []string {
+	kinds := make(map[string]bool)
+	for _, r := range reports {
+		kinds[r.Kind] = true
+	}
+	names := make([]string, len(reports))
+	for i, r := range reports {
+		names[i] = r.displayName()
+		if len(kinds) > 1 {
+			names[i] = strings.ToLower(r.Kind) + "/" + names[i]
+		}
+	}
+	if len(names) < 2 {
+		return names
+	}
+	prefix := names[0]
+	for _, n := range names[1:] {
+		for !strings.HasPrefix(n, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	// only cut at separators, so that the names remain recognizable, and
+	// only if it makes a difference
+	cut := strings.LastIndexAny(prefix, "-./")
+	if cut < 7 {
+		return names
+	}
+	for i := range names {
+		if len(names[i]) > cut+1 {
+			names[i] = "…" + names[i][cut+1:]
+		}
+	}
+	return names
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// enrichFluxKustomization adds the source and the applied revision of a Flux
+// Kustomization to the header, marks an ongoing reconciliation as a warning
+// and adds a Suspended condition if reconciliation is suspended.
+func enrichFluxKustomization(r *objectReport) {
+	obj := r.Object.Object
+	if kind, _, _ := unstructured.NestedString(obj, "spec", "sourceRef", "kind"); kind != "" {
+		name, _, _ := unstructured.NestedString(obj, "spec", "sourceRef", "name")
+		source := kind + "/" + name
+		if ns, _, _ := unstructured.NestedString(obj, "spec", "sourceRef", "namespace"); ns != "" && ns != r.Namespace {
+			source = kind + "/" + ns + "/" + name
+		}
+		if path, _, _ := unstructured.NestedString(obj, "spec", "path"); path != "" {
+			source += ", path " + path
+		}
+		r.Header = append(r.Header, gray.Sprint("Source: ")+source)
+	}
+
+	applied, _, _ := unstructured.NestedString(obj, "status", "lastAppliedRevision")
+	attempted, _, _ := unstructured.NestedString(obj, "status", "lastAttemptedRevision")
+	switch {
+	case attempted != "" && attempted != applied:
+		r.Header = append(r.Header, fmt.Sprintf("%s %s, %s", gray.Sprint("Revision:"), orDash(applied),
+			activeTheme.warning.Sprintf("attempted %s (not applied)", attempted)))
+	case applied != "":
+		r.Header = append(r.Header, gray.Sprint("Revision: ")+applied)
+	}
+
+	for i, cond := range r.Conditions {
+		if cond.Type == "Reconciling" && cond.Status == metav1.ConditionTrue {
+			r.Conditions[i].Warning = true
+		}
+	}
+	if suspend, _, _ := unstructured.NestedBool(obj, "spec", "suspend"); suspend && conditionIndex(r, "Suspended") < 0 {
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:      "Suspended",
+			Status:    metav1.ConditionTrue,
+			Reason:    "ReconciliationSuspended",
+			Message:   "spec.suspend is set, so changes of the source are not applied",
+			Warning:   true,
+			Synthetic: true,
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var staleAfterFlag time.Duration
+
+// staleHeartbeatColor is used for the conditions whose heartbeat is older
+// than --stale-after, which may no longer reflect the current state.
+var staleHeartbeatColor = color.New(color.FgMagenta)
+
+// isHeartbeatStale reports whether the condition reports a heartbeat that is
+// older than --stale-after.
+func isHeartbeatStale(cond GenericCondition) bool {
+	if staleAfterFlag <= 0 || cond.LastHeartbeatTime == nil {
+		return false
+	}
+	return time.Since(cond.LastHeartbeatTime.Time) > staleAfterFlag
+}
+
+// markStaleHeartbeats adds a note to the conditions of a node whose
+// heartbeat is older than --stale-after, which usually means that the
+// kubelet stopped posting the node status.
+func markStaleHeartbeats(r *objectReport) {
+	for i, cond := range r.Conditions {
+		if !isHeartbeatStale(cond) {
+			continue
+		}
+		r.Conditions[i].Notes = append(r.Conditions[i].Notes, fmt.Sprintf("No heartbeat for %s, the kubelet may be down and the status may be outdated",
+			duration.HumanDuration(time.Since(cond.LastHeartbeatTime.Time))))
+	}
+}
+
+// staleHeartbeatMarker returns the marker appended to the heartbeat time of
+// the condition if it is stale.
+func staleHeartbeatMarker(cond GenericCondition) string {
+	if !isHeartbeatStale(cond) {
+		return ""
+	}
+	return staleHeartbeatColor.Sprint(" (stale)")
+}
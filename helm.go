@@ -0,0 +1,142 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// helmRelease is the part of a Helm 3 release record that's needed to find
+// the objects of the release.
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Manifest  string `json:"manifest"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+func newHelmCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "helm <release>",
+		Short: "Show the conditions of the objects of a Helm release",
+		Long: "Show the conditions of the objects rendered by the latest revision of a Helm release, " +
+			"read from the release record stored in a Secret in the release namespace.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+
+			kc := &kubeClient{configFlags: configFlags}
+			namespace, err := resolveNamespace(configFlags)
+			if err != nil {
+				return err
+			}
+			if namespace == "" {
+				namespace = metav1.NamespaceDefault
+			}
+			rel, err := latestHelmRelease(ctx, kc, namespace, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "%s %s (revision %d, %s, chart %s-%s)\n\n", bold.Sprint("Release"), rel.Name,
+				rel.Version, rel.Info.Status, rel.Chart.Metadata.Name, rel.Chart.Metadata.Version)
+			if strings.TrimSpace(rel.Manifest) == "" {
+				fmt.Fprintln(stdout, "Release has no objects.")
+				return nil
+			}
+
+			rb, err := newResourceBuilder(configFlags)
+			if err != nil {
+				return err
+			}
+			return printObjects(ctx, kc, queryLatest(rb.Stream(strings.NewReader(rel.Manifest), "release "+rel.Name)))
+		},
+	}
+}
+
+// latestHelmRelease returns the latest revision of the release stored with
+// the default (Secret) storage driver of Helm 3.
+func latestHelmRelease(ctx context.Context, kc *kubeClient, namespace, name string) (*helmRelease, error) {
+	cs, err := kc.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := cs.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "owner=helm,name=" + name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release records: %w", err)
+	}
+	var latest []byte
+	var latestVersion int
+	for _, s := range secrets.Items {
+		if v, _ := strconv.Atoi(s.Labels["version"]); v > latestVersion && s.Type == "helm.sh/release.v1" {
+			latest, latestVersion = s.Data["release"], v
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("release %q not found in namespace %q (only Helm releases stored in Secrets are supported)", name, namespace)
+	}
+	rel, err := decodeHelmRelease(latest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode revision %d of release %q: %w", latestVersion, name, err)
+	}
+	return rel, nil
+}
+
+// decodeHelmRelease decodes a release record, which Helm stores as base64
+// encoded, gzipped JSON.
+func decodeHelmRelease(data []byte) (*helmRelease, error) {
+	b, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		if b, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+	var rel helmRelease
+	if err := json.Unmarshal(b, &rel); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
@@ -0,0 +1,321 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var recordFlag bool
+var historyFlag bool
+
+// historyRecord is a recorded state of a condition, stored in the history
+// file of its object when it differs from the previously recorded one.
+type historyRecord struct {
+	Time    time.Time              `json:"time"`
+	Type    string                 `json:"type"`
+	Status  metav1.ConditionStatus `json:"status"`
+	Reason  string                 `json:"reason,omitempty"`
+	Message string                 `json:"message,omitempty"`
+}
+
+// maxHistoryRecordsPerType is the number of records of each condition type
+// kept in the history file of an object. Older records are dropped when the
+// file is compacted.
+const maxHistoryRecordsPerType = 100
+
+// historyStore keeps the condition history of objects in a cluster as a
+// JSON lines file per object under the user's cache directory, since the
+// API server only keeps the latest state of conditions.
+type historyStore struct {
+	dir string
+
+	mu sync.Mutex
+	// cache holds the history of the objects read or recorded so far, so
+	// that watching objects doesn't re-read their file on every change.
+	cache map[string][]historyRecord
+}
+
+func newHistoryStore(cluster string) (*historyStore, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the history directory: %w", err)
+	}
+	if cluster == "" {
+		cluster = "default"
+	}
+	return &historyStore{dir: filepath.Join(cacheDir, "kubectl-cond", "history", url.PathEscape(cluster)),
+		cache: make(map[string][]historyRecord)}, nil
+}
+
+func (s *historyStore) path(r *objectReport) string {
+	namespace := r.Namespace
+	if namespace == "" {
+		namespace = "_cluster"
+	}
+	gk := r.Object.GroupVersionKind().GroupKind().String()
+	return filepath.Join(s.dir, url.PathEscape(gk), namespace, r.Name+".jsonl")
+}
+
+// load returns the recorded history of the object, oldest first.
+func (s *historyStore) load(r *objectReport) ([]historyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked(s.path(r))
+}
+
+func (s *historyStore) loadLocked(path string) ([]historyRecord, error) {
+	if records, ok := s.cache[path]; ok {
+		return records, nil
+	}
+	records, err := readHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	s.cache[path] = records
+	return records, nil
+}
+
+func readHistory(path string) ([]historyRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []historyRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec historyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// skip lines corrupted by e.g. interrupted writes
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// record appends the conditions of the object that changed since they were
+// last recorded to its history. The file is compacted to the last
+// maxHistoryRecordsPerType records of each type once it has more.
+func (s *historyStore) record(r *objectReport, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(r)
+	history, err := s.loadLocked(path)
+	if err != nil {
+		return err
+	}
+	last := make(map[string]historyRecord)
+	for _, rec := range history {
+		last[rec.Type] = rec
+	}
+	var added []historyRecord
+	for _, cond := range r.Conditions {
+		prev, ok := last[cond.Type]
+		if ok && prev.Status == cond.Status && prev.Reason == cond.Reason && prev.Message == cond.Message {
+			continue
+		}
+		t := now
+		if cond.LastTransitionTime != nil && !ok {
+			// the first observation can tell when the condition got into its
+			// current state
+			t = cond.LastTransitionTime.Time
+		}
+		added = append(added, historyRecord{Time: t, Type: cond.Type, Status: cond.Status, Reason: cond.Reason, Message: cond.Message})
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// copy so that the slice returned by load earlier isn't modified
+	records := append(slices.Clip(history), added...)
+	if compacted := compactHistory(records); len(compacted) < len(records) {
+		if err := writeHistory(path, compacted); err != nil {
+			return err
+		}
+		s.cache[path] = compacted
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := encodeHistory(f, added); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	s.cache[path] = records
+	return nil
+}
+
+// compactHistory returns the last maxHistoryRecordsPerType records of each
+// condition type, in their original order.
+func compactHistory(records []historyRecord) []historyRecord {
+	counts := make(map[string]int)
+	for _, rec := range records {
+		counts[rec.Type]++
+	}
+	out := make([]historyRecord, 0, len(records))
+	for _, rec := range records {
+		if counts[rec.Type] > maxHistoryRecordsPerType {
+			counts[rec.Type]--
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// writeHistory replaces the history file with the records, through a
+// temporary file so that readers never see a partially written one.
+func writeHistory(path string, records []historyRecord) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := encodeHistory(f, records); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func encodeHistory(w io.Writer, records []historyRecord) error {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// historyLines summarizes the recorded status changes of each condition
+// type, e.g. "History of Ready: 3 status changes since 1h ago
+// (False → True → False), reasons: A, B", counted from the oldest record
+// kept.
+func historyLines(records []historyRecord, now time.Time) []string {
+	var types []string
+	byType := make(map[string][]historyRecord)
+	for _, rec := range records {
+		if _, ok := byType[rec.Type]; !ok {
+			types = append(types, rec.Type)
+		}
+		byType[rec.Type] = append(byType[rec.Type], rec)
+	}
+	var lines []string
+	for _, typ := range types {
+		recs := byType[typ]
+		statuses := []string{string(recs[0].Status)}
+		var reasons []string
+		seenReasons := make(map[string]bool)
+		for i, rec := range recs {
+			if i > 0 && rec.Status != recs[i-1].Status {
+				statuses = append(statuses, string(rec.Status))
+			}
+			if rec.Reason != "" && !seenReasons[rec.Reason] {
+				seenReasons[rec.Reason] = true
+				reasons = append(reasons, rec.Reason)
+			}
+		}
+		if len(statuses) == 1 {
+			lines = append(lines, gray.Sprintf("History of %s: %s since %s ago", typ, statuses[0],
+				duration.HumanDuration(now.Sub(recs[0].Time))))
+			continue
+		}
+		// keep the lines short for flapping conditions
+		shown := statuses
+		if len(shown) > 6 {
+			shown = append([]string{"…"}, shown[len(shown)-5:]...)
+		}
+		line := fmt.Sprintf("History of %s: %d status changes since %s ago (%s)", typ, len(statuses)-1,
+			duration.HumanDuration(now.Sub(recs[0].Time)), strings.Join(shown, " → "))
+		if len(reasons) > 0 {
+			line += ", reasons: " + strings.Join(reasons, ", ")
+		}
+		lines = append(lines, gray.Sprint(line))
+	}
+	return lines
+}
+
+// historyPrinter records the history of the printed objects with --record
+// and adds it to their reports with --history.
+type historyPrinter struct {
+	printer
+	store *historyStore
+}
+
+func (p *historyPrinter) Print(r *objectReport) error {
+	now := time.Now()
+	if historyFlag {
+		history, err := loadHistory(p.store, r)
+		if len(history) == 0 && err == nil {
+			r.Header = append(r.Header, gray.Sprint("No condition history recorded yet (record it with --record)."))
+		}
+		r.Header = append(r.Header, historyLines(history, now)...)
+	}
+	if recordFlag {
+		recordHistory(p.store, r, now)
+	}
+	return p.printer.Print(r)
+}
+
+// loadHistory loads the history of the object, warning about failures since
+// a broken history shouldn't fail the command.
+func loadHistory(s *historyStore, r *objectReport) ([]historyRecord, error) {
+	history, err := s.load(r)
+	if err != nil {
+		fmt.Fprintln(stderr, gray.Sprintf("Failed to read the condition history of %s %s: %v", r.Kind, r.displayName(), err))
+	}
+	return history, err
+}
+
+// recordHistory records the current conditions of the object, warning about
+// failures.
+func recordHistory(s *historyStore, r *objectReport, now time.Time) {
+	if err := s.record(r, now); err != nil {
+		fmt.Fprintln(stderr, gray.Sprintf("Failed to record the condition history of %s %s: %v", r.Kind, r.displayName(), err))
+	}
+}
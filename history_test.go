@@ -0,0 +1,98 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHistoryStoreRecord(t *testing.T) {
+	s := &historyStore{dir: t.TempDir(), cache: make(map[string][]historyRecord)}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	statuses := []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse}
+	const changes = maxHistoryRecordsPerType + 20
+	for i := 0; i < changes; i++ {
+		r := testReport(
+			GenericCondition{Type: "Ready", Status: statuses[i%2]},
+			GenericCondition{Type: "Initialized", Status: metav1.ConditionTrue},
+		)
+		// recording the same state twice adds nothing
+		for j := 0; j < 2; j++ {
+			if err := s.record(r, now.Add(time.Duration(i)*time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	cached, err := s.load(testReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	onDisk, err := readHistory(s.path(testReport()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, records := range map[string][]historyRecord{"cached": cached, "on disk": onDisk} {
+		counts := make(map[string]int)
+		for _, rec := range records {
+			counts[rec.Type]++
+		}
+		if counts["Ready"] != maxHistoryRecordsPerType || counts["Initialized"] != 1 {
+			t.Errorf("%s history has %v records, want %d Ready and 1 Initialized", name, counts, maxHistoryRecordsPerType)
+		}
+		if last := records[len(records)-1]; last.Type != "Ready" || !last.Time.Equal(now.Add((changes-1)*time.Minute)) {
+			t.Errorf("%s history ends with %+v, want the last change of Ready", name, last)
+		}
+	}
+
+	f, err := os.Open(s.path(testReport()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		lines++
+	}
+	if lines != len(onDisk) {
+		t.Errorf("history file has %d lines, want %d", lines, len(onDisk))
+	}
+}
+
+func TestHistoryLines(t *testing.T) {
+	defer func(v bool) { color.NoColor = v }(color.NoColor)
+	color.NoColor = true
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []historyRecord{
+		{Time: now.Add(-5 * time.Hour), Type: "Ready", Status: metav1.ConditionTrue},
+		{Time: now.Add(-4 * time.Hour), Type: "Initialized", Status: metav1.ConditionTrue},
+		{Time: now.Add(-90 * time.Minute), Type: "Ready", Status: metav1.ConditionFalse, Reason: "ContainersNotReady"},
+		{Time: now.Add(-time.Hour), Type: "Ready", Status: metav1.ConditionTrue},
+	}
+	want := []string{
+		"History of Ready: 2 status changes since 5h ago (True → False → True), reasons: ContainersNotReady",
+		"History of Initialized: True since 4h ago",
+	}
+	if got := historyLines(records, now); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("historyLines() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
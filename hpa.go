@@ -0,0 +1,213 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// hpaMetric is a metric that a HorizontalPodAutoscaler scales on, with the
+// current value from its status and the target from its spec.
+type hpaMetric struct {
+	name            string
+	current, target string
+	// average is set for targets of the average value across pods.
+	average bool
+	// ratio is the current value relative to the target, or 0 if either is
+	// unknown. The metric with the highest ratio decides the number of
+	// replicas.
+	ratio float64
+}
+
+func (m hpaMetric) String() string {
+	if m.average {
+		return fmt.Sprintf("%s: %s/%s (avg)", m.name, m.current, m.target)
+	}
+	return fmt.Sprintf("%s: %s/%s", m.name, m.current, m.target)
+}
+
+// enrichHorizontalPodAutoscaler adds the current and desired number of
+// replicas and the metrics driving them to the header, since the conditions
+// of an HPA don't say much without them. ScalingLimited is marked as a
+// warning, since the desired number of replicas being capped by minReplicas
+// or maxReplicas needs attention but isn't a failure of the autoscaler, and
+// notes which bound was hit.
+func enrichHorizontalPodAutoscaler(r *objectReport) {
+	obj := r.Object.Object
+	current, _, _ := unstructured.NestedInt64(obj, "status", "currentReplicas")
+	desired, _, _ := unstructured.NestedInt64(obj, "status", "desiredReplicas")
+	minReplicas, found, _ := unstructured.NestedInt64(obj, "spec", "minReplicas")
+	if !found {
+		minReplicas = 1
+	}
+	maxReplicas, _, _ := unstructured.NestedInt64(obj, "spec", "maxReplicas")
+	r.Header = append(r.Header, fmt.Sprintf("%s %d current, %d desired (min %d, max %d)",
+		gray.Sprint("Replicas:"), current, desired, minReplicas, maxReplicas))
+
+	metrics := hpaMetrics(obj)
+	var highest *hpaMetric
+	if len(metrics) > 0 {
+		s := make([]string, len(metrics))
+		for i, m := range metrics {
+			s[i] = m.String()
+			if m.ratio > 0 && (highest == nil || m.ratio > highest.ratio) {
+				highest = &metrics[i]
+			}
+		}
+		r.Header = append(r.Header, gray.Sprint("Metrics: ")+strings.Join(s, ", "))
+	}
+
+	for i, cond := range r.Conditions {
+		if cond.Type != "ScalingLimited" || cond.Status != metav1.ConditionTrue {
+			continue
+		}
+		r.Conditions[i].Warning = true
+		switch cond.Reason {
+		case "TooManyReplicas":
+			r.Conditions[i].Notes = append(r.Conditions[i].Notes, fmt.Sprintf("Capped at spec.maxReplicas (%d)", maxReplicas))
+		case "TooFewReplicas":
+			r.Conditions[i].Notes = append(r.Conditions[i].Notes, fmt.Sprintf("Raised to spec.minReplicas (%d)", minReplicas))
+		}
+		if highest != nil {
+			r.Conditions[i].Notes = append(r.Conditions[i].Notes, fmt.Sprintf("Driven by %s (%.0f%% of the target)", highest, highest.ratio*100))
+		}
+	}
+}
+
+// hpaMetrics returns the metrics in the spec of a HorizontalPodAutoscaler
+// along with their current values in its status, in the format of kubectl
+// get hpa (e.g. "cpu: 85%/50%").
+func hpaMetrics(obj map[string]any) []hpaMetric {
+	specMetrics, _, _ := unstructured.NestedSlice(obj, "spec", "metrics")
+	if len(specMetrics) == 0 {
+		// autoscaling/v1 only supports a CPU utilization target
+		target, found, _ := unstructured.NestedInt64(obj, "spec", "targetCPUUtilizationPercentage")
+		if !found {
+			return nil
+		}
+		m := hpaMetric{name: "cpu", current: "<unknown>", target: fmt.Sprintf("%d%%", target)}
+		if current, found, _ := unstructured.NestedInt64(obj, "status", "currentCPUUtilizationPercentage"); found {
+			m.current = fmt.Sprintf("%d%%", current)
+			if target > 0 {
+				m.ratio = float64(current) / float64(target)
+			}
+		}
+		return []hpaMetric{m}
+	}
+
+	currentMetrics := make(map[string]map[string]any)
+	statusMetrics, _, _ := unstructured.NestedSlice(obj, "status", "currentMetrics")
+	for _, v := range statusMetrics {
+		if m, ok := v.(map[string]any); ok {
+			if name, source := hpaMetricSource(m); source != nil {
+				currentMetrics[name] = source
+			}
+		}
+	}
+
+	var out []hpaMetric
+	for _, v := range specMetrics {
+		spec, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, source := hpaMetricSource(spec)
+		if source == nil {
+			continue
+		}
+		var field string
+		switch targetType, _, _ := unstructured.NestedString(source, "target", "type"); targetType {
+		case "Utilization":
+			field = "averageUtilization"
+		case "AverageValue":
+			field = "averageValue"
+		default:
+			field = "value"
+		}
+		m := hpaMetric{name: name, current: "<unknown>", average: field == "averageValue"}
+		target, targetValue, ok := hpaMetricValue(source, "target", field)
+		if !ok {
+			continue
+		}
+		m.target = target
+		if status, ok := currentMetrics[name]; ok {
+			if current, currentValue, ok := hpaMetricValue(status, "current", field); ok {
+				m.current = current
+				if targetValue > 0 {
+					m.ratio = currentValue / targetValue
+				}
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// hpaMetricSource returns the name of the metric (as shown by kubectl) in an
+// item of spec.metrics or status.currentMetrics of a HorizontalPodAutoscaler
+// and the field that describes it, e.g. "resource" for the Resource type.
+func hpaMetricSource(m map[string]any) (string, map[string]any) {
+	typ, _, _ := unstructured.NestedString(m, "type")
+	if typ == "" {
+		return "", nil
+	}
+	field := string(unicode.ToLower(rune(typ[0]))) + typ[1:]
+	source, ok := m[field].(map[string]any)
+	if !ok {
+		return "", nil
+	}
+	switch typ {
+	case "Resource":
+		name, _, _ := unstructured.NestedString(source, "name")
+		return name, source
+	case "ContainerResource":
+		name, _, _ := unstructured.NestedString(source, "name")
+		container, _, _ := unstructured.NestedString(source, "container")
+		return fmt.Sprintf("%s (container %s)", name, container), source
+	case "Object":
+		name, _, _ := unstructured.NestedString(source, "metric", "name")
+		kind, _, _ := unstructured.NestedString(source, "describedObject", "kind")
+		objName, _, _ := unstructured.NestedString(source, "describedObject", "name")
+		return fmt.Sprintf("%s on %s/%s", name, strings.ToLower(kind), objName), source
+	default:
+		// Pods and External
+		name, _, _ := unstructured.NestedString(source, "metric", "name")
+		return name, source
+	}
+}
+
+// hpaMetricValue returns the value of a metric in the target or current
+// field of its source, formatted and as a number.
+func hpaMetricValue(source map[string]any, key, field string) (string, float64, bool) {
+	if field == "averageUtilization" {
+		v, found, _ := unstructured.NestedInt64(source, key, field)
+		return fmt.Sprintf("%d%%", v), float64(v), found
+	}
+	s, found, _ := unstructured.NestedFieldNoCopy(source, key, field)
+	if !found {
+		return "", 0, false
+	}
+	q, err := resource.ParseQuantity(fmt.Sprint(s))
+	if err != nil {
+		return fmt.Sprint(s), 0, true
+	}
+	return q.String(), q.AsApproximateFloat64(), true
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"net/url"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+)
+
+// inClusterContextName is the cluster name used in notifications, history
+// and recorded transitions when running with the in-cluster config.
+const inClusterContextName = "in-cluster"
+
+// hasKubeconfig reports whether the API server is specified with a
+// kubeconfig context or --server.
+func hasKubeconfig(configFlags *genericclioptions.ConfigFlags) bool {
+	if ptr.Deref(configFlags.APIServer, "") != "" {
+		return true
+	}
+	raw, err := configFlags.ToRawKubeConfigLoader().RawConfig()
+	return err != nil || len(raw.Contexts) > 0
+}
+
+// inCluster reports whether the API server is reached with the config of the
+// pod's service account, which client-go falls back to when there is no
+// kubeconfig. This lets kubectl cond run in a debug container or a CronJob.
+func inCluster(configFlags *genericclioptions.ConfigFlags) bool {
+	if hasKubeconfig(configFlags) {
+		return false
+	}
+	_, err := rest.InClusterConfig()
+	return err == nil
+}
+
+// configHints explains the failure to reach the API server when there is no
+// kubeconfig and kubectl cond is not running inside a cluster, in which case
+// client-go falls back to http://localhost:8080.
+func configHints(configFlags *genericclioptions.ConfigFlags, err error) []string {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || hasKubeconfig(configFlags) || inCluster(configFlags) {
+		return nil
+	}
+	return []string{
+		"No kubeconfig was found and kubectl cond is not running inside a cluster.",
+		"Specify a kubeconfig with --kubeconfig or the KUBECONFIG environment variable, or the API server with --server.",
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// defaultBackoffLimit is the number of retries of a Job with no
+// spec.backoffLimit.
+const defaultBackoffLimit = 6
+
+// enrichJob adds the progress of a Job and its failed pods compared to
+// spec.backoffLimit to its Complete and Failed conditions, which are
+// synthesized while the Job is running (the latter only if any pods failed)
+// since Jobs only report them once they finish. A suspended Job gets a
+// Suspended condition.
+func enrichJob(r *objectReport) {
+	obj := r.Object.Object
+	succeeded, _, _ := unstructured.NestedInt64(obj, "status", "succeeded")
+	failed, _, _ := unstructured.NestedInt64(obj, "status", "failed")
+	active, _, _ := unstructured.NestedInt64(obj, "status", "active")
+	completions, hasCompletions, _ := unstructured.NestedInt64(obj, "spec", "completions")
+	backoffLimit, found, _ := unstructured.NestedInt64(obj, "spec", "backoffLimit")
+	if !found {
+		backoffLimit = defaultBackoffLimit
+	}
+	suspend, _, _ := unstructured.NestedBool(obj, "spec", "suspend")
+	startTime := nestedTime(obj, "status", "startTime")
+	completionTime := nestedTime(obj, "status", "completionTime")
+
+	progress := fmt.Sprintf("%d succeeded", succeeded)
+	if hasCompletions {
+		progress = fmt.Sprintf("%d of %d completions succeeded", succeeded, completions)
+	}
+	if active > 0 {
+		progress += fmt.Sprintf(", %d active", active)
+	}
+	failures := fmt.Sprintf("%d failed pods, backoff limit is %d", failed, backoffLimit)
+	if failed == backoffLimit && failed > 0 {
+		failures += " (the next failure fails the Job)"
+	}
+
+	complete, failedCond, suspended := conditionIndex(r, "Complete"), conditionIndex(r, "Failed"), conditionIndex(r, "Suspended")
+	switch {
+	case complete >= 0:
+		note := "Progress: " + progress
+		if startTime != nil && completionTime != nil {
+			note += fmt.Sprintf(", took %s", duration.HumanDuration(completionTime.Sub(startTime.Time)))
+		}
+		r.Conditions[complete].Notes = append(r.Conditions[complete].Notes, note)
+	case failedCond >= 0 && r.Conditions[failedCond].Status == metav1.ConditionTrue:
+		// a failed Job won't complete
+	default:
+		// whether a running Job completes is not known yet
+		cond := GenericCondition{
+			Type:      "Complete",
+			Status:    metav1.ConditionUnknown,
+			Reason:    "InProgress",
+			Message:   progress,
+			Synthetic: true,
+		}
+		if suspend {
+			cond.Reason = "Suspended"
+		} else if startTime != nil {
+			cond.Message += fmt.Sprintf(", running for %s", duration.HumanDuration(time.Since(startTime.Time)))
+		}
+		r.Conditions = append(r.Conditions, cond)
+	}
+
+	switch {
+	case failedCond >= 0:
+		r.Conditions[failedCond].Notes = append(r.Conditions[failedCond].Notes, "Pods: "+failures)
+	case failed == 0:
+		// nothing failed so far
+	default:
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:      "Failed",
+			Status:    metav1.ConditionFalse,
+			Reason:    "BackoffLimitNotExceeded",
+			Message:   failures,
+			Synthetic: true,
+		})
+	}
+
+	switch {
+	case suspended >= 0:
+		// suspending a Job is intentional, so it's not shown as a failure
+		r.Conditions[suspended].Warning = true
+	case suspend:
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:      "Suspended",
+			Status:    metav1.ConditionTrue,
+			Reason:    "JobSuspended",
+			Message:   "spec.suspend is set, so the pods of the Job are not created",
+			Warning:   true,
+			Synthetic: true,
+		})
+	}
+}
+
+// enrichCronJob adds a synthetic LastRunSucceeded condition based on the
+// last schedule and successful completion times of a CronJob, since
+// CronJobs don't report conditions, and a Suspended condition if it's
+// suspended.
+func enrichCronJob(r *objectReport) {
+	obj := r.Object.Object
+	schedule, _, _ := unstructured.NestedString(obj, "spec", "schedule")
+	if tz, _, _ := unstructured.NestedString(obj, "spec", "timeZone"); tz != "" {
+		schedule += " (" + tz + ")"
+	}
+	if schedule != "" {
+		r.Header = append(r.Header, gray.Sprint("Schedule: ")+schedule)
+	}
+	lastSchedule := nestedTime(obj, "status", "lastScheduleTime")
+	lastSuccess := nestedTime(obj, "status", "lastSuccessfulTime")
+	active, _, _ := unstructured.NestedSlice(obj, "status", "active")
+	suspend, _, _ := unstructured.NestedBool(obj, "spec", "suspend")
+
+	cond := GenericCondition{
+		Type:      "LastRunSucceeded",
+		Synthetic: true,
+	}
+	switch {
+	case lastSchedule == nil:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "NeverScheduled"
+		cond.Message = "no Job has been scheduled yet"
+	case lastSuccess == nil:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "NeverSucceeded"
+		cond.Message = fmt.Sprintf("last run scheduled %s, no run has succeeded yet", relativeTime(lastSchedule.Time))
+		cond.LastTransitionTime = lastSchedule
+		if len(active) > 0 {
+			cond.Status = metav1.ConditionUnknown
+			cond.Reason = "FirstRunActive"
+		}
+	case !lastSuccess.Before(lastSchedule):
+		// the successful Job completed after it was scheduled
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Succeeded"
+		cond.Message = fmt.Sprintf("last run succeeded %s", relativeTime(lastSuccess.Time))
+		cond.LastTransitionTime = lastSuccess
+	case len(active) > 0:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Succeeded"
+		cond.Message = fmt.Sprintf("last run succeeded %s, the run scheduled %s is active", relativeTime(lastSuccess.Time), relativeTime(lastSchedule.Time))
+		cond.LastTransitionTime = lastSuccess
+	default:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "LastRunFailed"
+		cond.Message = fmt.Sprintf("the run scheduled %s didn't succeed, last success was %s", relativeTime(lastSchedule.Time), relativeTime(lastSuccess.Time))
+		cond.LastTransitionTime = lastSchedule
+	}
+	if len(active) > 0 {
+		cond.Notes = append(cond.Notes, fmt.Sprintf("Active Jobs: %d", len(active)))
+	}
+	r.Conditions = append(r.Conditions, cond)
+
+	if suspend {
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:      "Suspended",
+			Status:    metav1.ConditionTrue,
+			Reason:    "CronJobSuspended",
+			Message:   "spec.suspend is set, so no new Jobs are scheduled",
+			Warning:   true,
+			Synthetic: true,
+		})
+	}
+}
+
+// conditionIndex returns the index of the condition of the given type in
+// the report, or -1 if there is none.
+func conditionIndex(r *objectReport, condType string) int {
+	for i, cond := range r.Conditions {
+		if cond.Type == condType {
+			return i
+		}
+	}
+	return -1
+}
+
+// nestedTime returns the timestamp at the path in the object, or nil if it's
+// not set or invalid.
+func nestedTime(obj map[string]any, fields ...string) *metav1.Time {
+	s, _, _ := unstructured.NestedString(obj, fields...)
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return &metav1.Time{Time: t}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// spansMultipleKinds reports whether the resource arguments select objects
+// of several kinds, such as "all" or "deploy,rs,pods".
+func spansMultipleKinds(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	return args[0] == "all" || strings.Contains(args[0], ",")
+}
+
+// kindGroupPrinter prints the objects under a heading for each kind, and
+// skips the kinds none of whose objects have conditions. Objects are
+// printed as they are received, since they're listed one resource type at
+// a time.
+type kindGroupPrinter struct {
+	printer
+	w io.Writer
+
+	kind    string
+	pending []*objectReport
+	shown   bool
+
+	counts  map[string]*healthCounts
+	kinds   []string
+	skipped []string
+}
+
+func newKindGroupPrinter(p printer, w io.Writer) *kindGroupPrinter {
+	return &kindGroupPrinter{printer: p, w: w, counts: make(map[string]*healthCounts)}
+}
+
+func (p *kindGroupPrinter) Print(r *objectReport) error {
+	if r.Kind != p.kind {
+		p.endKind()
+		p.kind = r.Kind
+	}
+	if !p.shown && len(r.Conditions) == 0 {
+		// held back until an object of the kind that has conditions is seen
+		p.pending = append(p.pending, r)
+		return nil
+	}
+	if !p.shown {
+		p.shown = true
+		if len(p.kinds) > 0 {
+			fmt.Fprintln(p.w)
+		}
+		fmt.Fprintln(p.w, bold.Sprintf("━━ %s ━━", r.Kind))
+		if _, ok := p.counts[r.Kind]; !ok {
+			p.counts[r.Kind] = &healthCounts{}
+			p.kinds = append(p.kinds, r.Kind)
+		}
+		for _, pr := range p.pending {
+			if err := p.print(pr); err != nil {
+				return err
+			}
+		}
+		p.pending = nil
+	}
+	return p.print(r)
+}
+
+func (p *kindGroupPrinter) print(r *objectReport) error {
+	p.counts[r.Kind].add(r.Conditions)
+	return p.printer.Print(r)
+}
+
+// endKind records the current kind as skipped if none of its objects had
+// conditions.
+func (p *kindGroupPrinter) endKind() {
+	if p.kind != "" && !p.shown {
+		p.skipped = append(p.skipped, fmt.Sprintf("%s (%d)", p.kind, len(p.pending)))
+	}
+	p.kind, p.pending, p.shown = "", nil, false
+}
+
+func (p *kindGroupPrinter) Flush() error {
+	p.endKind()
+	if err := p.printer.Flush(); err != nil {
+		return err
+	}
+	if len(p.kinds) > 1 {
+		fmt.Fprintln(p.w)
+		for _, kind := range p.kinds {
+			c := p.counts[kind]
+			fmt.Fprintf(p.w, "%s: %d objects (%d unhealthy)\n", kind, c.Objects, c.UnhealthyObjects)
+		}
+	}
+	if len(p.skipped) > 0 {
+		fmt.Fprintln(p.w, gray.Sprintf("Skipped kinds without conditions: %s", strings.Join(p.skipped, ", ")))
+	}
+	return nil
+}
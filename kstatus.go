@@ -0,0 +1,48 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/fatih/color"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+// addComputedStatus adds the overall status of the object computed with
+// kstatus (from its generation, conditions and kind-specific rules, the
+// same way kpt, Flux and Config Sync determine readiness) to the report
+// header.
+func addComputedStatus(r *objectReport) {
+	res, err := status.Compute(r.Object)
+	if err != nil {
+		r.Header = append(r.Header, gray.Sprintf("Status: failed to compute: %v", err))
+		return
+	}
+	var c *color.Color
+	switch res.Status {
+	case status.CurrentStatus:
+		c = activeTheme.healthy
+	case status.InProgressStatus:
+		c = activeTheme.warning
+	case status.FailedStatus:
+		c = activeTheme.unhealthy
+	default:
+		c = gray
+	}
+	line := "Status: " + c.Sprint(string(res.Status))
+	if res.Message != "" {
+		line += gray.Sprintf(" (%s)", res.Message)
+	}
+	r.Header = append([]string{line}, r.Header...)
+}
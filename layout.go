@@ -0,0 +1,157 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// defaultMessageWidth is the width messages are wrapped at when the
+	// width of the output isn't known.
+	defaultMessageWidth = 80
+
+	// minMessageWidth keeps messages readable in very narrow terminals.
+	minMessageWidth = 30
+
+	// compactTimesWidth is the terminal width below which the absolute
+	// times are omitted from the table.
+	compactTimesWidth = 100
+)
+
+// tableLayout controls how condition details are sized in the table.
+type tableLayout struct {
+	// terminalWidth is the width of the terminal the table is printed to,
+	// or zero if it isn't printed to a terminal.
+	terminalWidth int
+}
+
+// newTableLayout detects the width of the terminal if w is the standard
+// output (or buffers output for it).
+func newTableLayout(w io.Writer) tableLayout {
+	if _, ok := w.(*screenBuffer); !ok && w != stdout {
+		return tableLayout{}
+	}
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return tableLayout{}
+	}
+	return tableLayout{terminalWidth: width}
+}
+
+// messageWidth returns the width to wrap messages at so that the table of
+// the conditions fits in the terminal.
+func (l tableLayout) messageWidth(conditions []GenericCondition) int {
+	if l.terminalWidth == 0 {
+		return defaultMessageWidth
+	}
+	typeWidth := len("CONDITION TYPE")
+	for _, cond := range conditions {
+		typeWidth = max(typeWidth, runewidth.StringWidth(statusIcon(cond)+cond.Type), len(cond.Status)+2)
+	}
+	// borders and padding of the two columns: "| " + " | " + " |"
+	return max(l.terminalWidth-typeWidth-7, minMessageWidth)
+}
+
+// compactTimes reports whether only the relative times should be printed.
+func (l tableLayout) compactTimes() bool {
+	return l.terminalWidth > 0 && l.terminalWidth < compactTimesWidth
+}
+
+// widePrinter prints each object's conditions as rows in the style of
+// kubectl get -o wide, without truncating or wrapping any field.
+type widePrinter struct {
+	w io.Writer
+}
+
+func (p *widePrinter) Print(r *objectReport) error {
+	printObjectHeader(p.w, r)
+	if len(r.Conditions) == 0 {
+		return nil
+	}
+	var hasUpdate, hasHeartbeat bool
+	for _, cond := range r.Conditions {
+		hasUpdate = hasUpdate || cond.LastUpdateTime != nil
+		hasHeartbeat = hasHeartbeat || cond.LastHeartbeatTime != nil
+	}
+	// columns of the fields hidden with --show and --hide are omitted
+	header := []string{"Type", "Status"}
+	for _, c := range []struct {
+		field, name string
+		shown       bool
+	}{
+		{"reason", "Reason", true},
+		{"message", "Message", true},
+		{"lastTransition", "Last Transition", true},
+		{"lastUpdate", "Last Update", hasUpdate},
+		{"heartbeat", "Last Heartbeat", hasHeartbeat},
+		{"notes", "Notes", true},
+	} {
+		if c.shown && !hiddenFields[c.field] {
+			header = append(header, c.name)
+		}
+	}
+	table := newPlainTable(p.w, header...)
+	for _, cond := range r.Conditions {
+		colorize := statusColor(cond)
+		row := []string{highlightRecent(cond, colorize(statusIcon(cond)+cond.Type)), colorize(string(cond.Status))}
+		if !hiddenFields["reason"] {
+			row = append(row, orDash(cond.Reason))
+		}
+		if !hiddenFields["message"] {
+			row = append(row, orDash(strings.Join(strings.Fields(cond.Message), " ")))
+		}
+		if !hiddenFields["lastTransition"] {
+			row = append(row, formatRFC3339(cond.LastTransitionTime))
+		}
+		if hasUpdate && !hiddenFields["lastUpdate"] {
+			row = append(row, formatRFC3339(cond.LastUpdateTime))
+		}
+		if hasHeartbeat && !hiddenFields["heartbeat"] {
+			row = append(row, formatRFC3339(cond.LastHeartbeatTime)+staleHeartbeatMarker(cond))
+		}
+		if !hiddenFields["notes"] {
+			row = append(row, gray.Sprint(orDash(strings.Join(cond.Notes, "; "))))
+		}
+		table.Append(row)
+	}
+	table.Render()
+	return nil
+}
+
+func (p *widePrinter) Flush() error { return nil }
+
+func formatRFC3339(t *metav1.Time) string {
+	if t == nil {
+		return "-"
+	}
+	if timeFormatFlag == "relative" {
+		return relativeTime(t.Time)
+	}
+	return absoluteTime(t.Time)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// enrichLease adds a synthetic condition reporting whether the holder of
+// the Lease is still renewing it. Controllers using leader election stop
+// renewing their Lease when they are dead or wedged, which is usually why
+// conditions stop being updated across the cluster.
+func enrichLease(r *objectReport) {
+	obj := r.Object.Object
+	holder, _, _ := unstructured.NestedString(obj, "spec", "holderIdentity")
+	renewTimeStr, _, _ := unstructured.NestedString(obj, "spec", "renewTime")
+	durationSeconds, _, _ := unstructured.NestedInt64(obj, "spec", "leaseDurationSeconds")
+
+	cond := GenericCondition{
+		Type:      "Renewing",
+		Synthetic: true,
+	}
+	renewTime, err := time.Parse(time.RFC3339Nano, renewTimeStr)
+	switch {
+	case holder == "":
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "NotHeld"
+		cond.Message = "lease has no holder"
+	case err != nil:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "NeverRenewed"
+		cond.Message = fmt.Sprintf("held by %s, but has no renew time", holder)
+	case durationSeconds <= 0:
+		// without a duration, the lease can't be told to have expired
+		cond.LastUpdateTime = &metav1.Time{Time: renewTime}
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Held"
+		cond.Message = fmt.Sprintf("held by %s, last renewed %s (no lease duration)",
+			holder, humanize.RelTime(renewTime, time.Now(), "ago", "from now"))
+	default:
+		leaseDuration := time.Duration(durationSeconds) * time.Second
+		cond.LastUpdateTime = &metav1.Time{Time: renewTime}
+		cond.Message = fmt.Sprintf("held by %s, last renewed %s (lease duration %s)",
+			holder, humanize.RelTime(renewTime, time.Now(), "ago", "from now"), leaseDuration)
+		if time.Since(renewTime) > leaseDuration {
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = "LeaseExpired"
+		} else {
+			cond.Status = metav1.ConditionTrue
+			cond.Reason = "LeaseRenewed"
+		}
+	}
+	r.Conditions = append(r.Conditions, cond)
+}
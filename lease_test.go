@@ -0,0 +1,47 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestEnrichLease(t *testing.T) {
+	renewed := func(ago time.Duration) string { return time.Now().Add(-ago).Format(time.RFC3339Nano) }
+	tests := []struct {
+		name string
+		spec map[string]any
+		want string
+	}{
+		{name: "no holder", spec: map[string]any{}, want: "NotHeld"},
+		{name: "never renewed", spec: map[string]any{"holderIdentity": "a"}, want: "NeverRenewed"},
+		{name: "renewed", spec: map[string]any{"holderIdentity": "a", "renewTime": renewed(5 * time.Second), "leaseDurationSeconds": int64(15)}, want: "LeaseRenewed"},
+		{name: "expired", spec: map[string]any{"holderIdentity": "a", "renewTime": renewed(time.Minute), "leaseDurationSeconds": int64(15)}, want: "LeaseExpired"},
+		{name: "no duration", spec: map[string]any{"holderIdentity": "a", "renewTime": renewed(time.Hour)}, want: "Held"},
+		{name: "zero duration", spec: map[string]any{"holderIdentity": "a", "renewTime": renewed(time.Hour), "leaseDurationSeconds": int64(0)}, want: "Held"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &objectReport{Object: &unstructured.Unstructured{Object: map[string]any{"spec": tt.spec}}}
+			enrichLease(r)
+			if len(r.Conditions) != 1 || r.Conditions[0].Reason != tt.want {
+				t.Errorf("enrichLease() = %+v, want reason %s", r.Conditions, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/ahmetb/kubectl-cond/pkg/conditions"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var lintFlag bool
+
+// addLintViolations validates the conditions of the object against the
+// contract of metav1.Condition with --lint, and adds the violations as notes
+// of the conditions they were found in. conds must be the conditions
+// extracted from the object, in their original order. The returned header
+// line summarizes the result.
+func addLintViolations(obj *unstructured.Unstructured, conds []GenericCondition) string {
+	violations, err := conditions.Lint(obj)
+	if err != nil {
+		return activeTheme.warning.Sprintf("Lint: %v", err)
+	}
+	if len(violations) == 0 {
+		if len(conds) == 0 {
+			return ""
+		}
+		return gray.Sprint("Lint: conditions follow the metav1.Condition contract")
+	}
+	for _, v := range violations {
+		if v.Index < len(conds) {
+			conds[v.Index].Notes = append(conds[v.Index].Notes, "Lint: "+v.String())
+		}
+	}
+	return activeTheme.warning.Sprintf("Lint: %d violations of the metav1.Condition contract", len(violations))
+}
@@ -15,24 +15,29 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"sort"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/dustin/go-humanize"
+	"github.com/ahmetb/kubectl-cond/pkg/conditions"
 	"github.com/fatih/color"
+	"github.com/mattn/go-colorable"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/json"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest"
 	"k8s.io/utils/ptr"
 )
 
@@ -40,45 +45,232 @@ var (
 	bold = color.New(color.Bold)
 	gray = color.New(color.FgHiBlack)
 
-	// double-negated well-known conditions
-	negativePolarityNodeConditions = sets.New(
-		// kubernetes builtin Node conditions
-		"MemoryPressure",
-		"DiskPressure",
-		"NetworkUnavailable",
-		"PIDPressure",
-
-		// node-problem-detector Node conditions
-		"ReadonlyFilesystem",
-		"KernelDeadlock",
-		"FrequentKubeletRestart",
-		"FrequentDockerRestart",
-		"FrequentContainerdRestart",
-		"KubeletUnhealthy",
-		"ContainerRuntimeUnhealthy",
-	)
+	// stdout and stderr translate the escape sequences used for colors on
+	// Windows consoles that don't support them natively.
+	stdout = colorable.NewColorableStdout()
+	stderr = colorable.NewColorableStderr()
 )
 
+var errNoConditions = errors.New("no status.conditions[] found in object")
+
+// errUnhealthy is returned to exit with a non-zero code without printing an
+// error, after the output has already been printed.
+var errUnhealthy = errors.New("one or more conditions are unhealthy")
+
 var allNamespacesFlag bool
+var byConditionFlag bool
+var groupByFlag string
+var outputFlag string
+var nodeMetricsFlag bool
+var terminatingThresholdFlag time.Duration
+var missingNodesFlag bool
+var watchFlag bool
+var timeoutFlag time.Duration
+var qpsFlag float32
+var burstFlag int
+var watchStatsIntervalFlag time.Duration
+var dryRunFlag bool
+var expandAppFlag bool
+var podsFlag bool
+var strictFlag bool
+var pushMetricsFlag string
+var interactiveFlag bool
+var outputFileFlag string
+var outputJSONFlag string
+var plainFlag bool
+var noColorFlag bool
+var problemsOnlyFlag bool
+var freshOnlyFlag bool
+var typeFlag []string
+var localFlag bool
+var serverDryRunFlag bool
+var failOnUnhealthyFlag bool
+var selectorFlag string
+var fieldSelectorFlag string
+var treatUnknownAsFlag string
+var sortByFlag string
+var reverseFlag bool
 var filenameOpts = &resource.FilenameOptions{}
 
+// chunkSizeFlag is the number of objects requested per page when listing,
+// to avoid decoding huge list responses at once.
+var chunkSizeFlag int64
+
 func main() {
+	defer handlePanic()
 	configFlags := genericclioptions.NewConfigFlags(true)
 
 	cmd := &cobra.Command{
-		Use:          "kubectl cond",
-		Short:        "View Kubernetes resource conditions",
-		SilenceUsage: true,
-		RunE:         runFunc(configFlags),
+		Use:           "kubectl cond",
+		Short:         "View Kubernetes resource conditions",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ArbitraryArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			// the color package already disables colors when NO_COLOR is set
+			// or stdout isn't a terminal (e.g. piped to a file or less)
+			if plainFlag || noColorFlag {
+				color.NoColor = true
+			}
+			switch treatUnknownAsFlag {
+			case "neutral", "healthy", "unhealthy":
+			default:
+				return fmt.Errorf("unsupported --treat-unknown-as value %q, expected one of: (neutral, healthy, unhealthy)", treatUnknownAsFlag)
+			}
+			// applies to the subcommands reading -f as well, e.g. diff
+			for i, f := range filenameOpts.Filenames {
+				filenameOpts.Filenames[i] = rawManifestURL(f)
+			}
+			if byConditionFlag {
+				groupByFlag = "condition"
+			}
+			if quietFlag {
+				failOnUnhealthyFlag = true
+			}
+			switch groupByFlag {
+			case "", "condition", "kind":
+			default:
+				return fmt.Errorf("unsupported --group-by value %q, expected one of: (condition, kind)", groupByFlag)
+			}
+			if _, ok := conditionOrders[sortByFlag]; !ok {
+				return fmt.Errorf("unsupported --sort-by value %q, expected one of: (%s)", sortByFlag, strings.Join(conditionOrderNames(), ", "))
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupPolarity(cfg); err != nil {
+				return err
+			}
+			if err := setupTheme(cfg, cmd.Flags().Changed("icons")); err != nil {
+				return err
+			}
+			if err := setupTimeFormat(); err != nil {
+				return err
+			}
+			if err := setupDetailFields(); err != nil {
+				return err
+			}
+			if explainFlag {
+				if err := loadExplanations(); err != nil {
+					return err
+				}
+			}
+			return startProfiling()
+		},
+		RunE: runFunc(configFlags),
 	}
+	cmd.CompletionOptions.DisableDefaultCmd = true
+	cmd.AddCommand(newCanICmd(configFlags))
+	cmd.AddCommand(newCompletionCmd())
+	cmd.AddCommand(newDiffCmd(configFlags))
+	cmd.AddCommand(newExportCmd(configFlags))
+	cmd.AddCommand(newFindCmd(configFlags))
+	cmd.AddCommand(newHelmCmd(configFlags))
+	cmd.AddCommand(newOverviewCmd(configFlags))
+	cmd.AddCommand(newQueryCmd(configFlags))
+	cmd.AddCommand(newRecordCmd(configFlags))
+	cmd.AddCommand(newServeCmd(configFlags))
+	cmd.AddCommand(newSnapshotCmd(configFlags))
+	cmd.AddCommand(newUpgradeCmd(configFlags))
+	cmd.AddCommand(newViewCmd())
 	cmd.PersistentFlags().BoolVarP(&allNamespacesFlag, "all-namespaces", "A", false, "If present, list the requested object(s) across all namespaces. Namespace in current context is ignored even if specified with --namespace.")
-	cmd.PersistentFlags().StringSliceVarP(&filenameOpts.Filenames, "filename", "f", nil, "Filename, directory, or URL to files identifying the resource to get from a server.")
-	cmd.PersistentFlags().BoolVar(&filenameOpts.Recursive, "recursive", false, "Process the directory used in -f, --filename recursively. Useful when you want to manage related manifests organized within the same directory.")
+	cmd.PersistentFlags().BoolVar(&byConditionFlag, "by-condition", false, "If present, group the output by condition type. Same as --group-by=condition.")
+	cmd.PersistentFlags().StringVar(&groupByFlag, "group-by", "", "Group the output by the given field, e.g. to see which of many objects have a condition in each status. One of: (condition, kind). Defaults to kind when querying several resource types (e.g. all or deploy,rs,pods).")
+	cmd.PersistentFlags().StringVarP(&selectorFlag, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2). Matching objects must satisfy all of the specified label constraints.")
+	cmd.PersistentFlags().StringVar(&fieldSelectorFlag, "field-selector", "", "Selector (field query) to filter on, supports '=', '==', and '!='.(e.g. --field-selector key1=value1,key2=value2). The server only supports a limited number of field queries per type.")
+	cmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "If present, only print a line for each unhealthy object and exit with status 1 if there are any, e.g. for health checks in scripts.")
+	cmd.PersistentFlags().BoolVar(&failOnUnhealthyFlag, "fail-on-unhealthy", false, "If present, exit with status 1 when any of the printed conditions is unhealthy (or Unknown, unless --treat-unknown-as=healthy), e.g. to gate CI pipelines.")
+	cmd.PersistentFlags().StringVar(&waitForFlag, "wait-for", "", "Watch the requested objects until all of them have the specified condition in the form of TYPE[=STATUS] (e.g. Ready=True), printing the condition transitions meanwhile. Conditions reported for an older generation of an object (e.g. right after kubectl apply) are not considered met. Use with --timeout to limit the wait.")
+	cmd.PersistentFlags().BoolVar(&summaryFlag, "summary", false, "If present, print one line per object with the number of unhealthy, unknown and healthy conditions and its worst condition, unhealthy objects first.")
+	cmd.PersistentFlags().BoolVar(&fleetFlag, "fleet", false, "If present, compare similar objects (e.g. the pods of a Deployment, or the nodes of a node pool selected with -l) in a grid where rows are condition types and columns are objects, highlighting the objects whose conditions differ from most others.")
+	cmd.PersistentFlags().BoolVar(&problemsOnlyFlag, "problems-only", false, "If present, hide the healthy conditions (after taking their polarity into account), and the objects without any other conditions.")
+	cmd.PersistentFlags().BoolVar(&explainFlag, "explain", false, "If present, explain the well-known conditions (e.g. the DiskPressure of Nodes) in words and suggest a command to investigate them. Explanations for other conditions can be added in explanations.yaml next to the config file.")
+	cmd.PersistentFlags().BoolVar(&lintFlag, "lint", false, "If present, validate the conditions of the objects against the contract of metav1.Condition (required and well-formed type, status, lastTransitionTime and reason, unique types, no other fields) and show the violations, e.g. to find malformed conditions emitted by a controller.")
+	cmd.PersistentFlags().BoolVar(&eventsFlag, "events", false, "If present, show the recent Warning events involving each object (and the pods of workloads) along with its conditions.")
+	cmd.PersistentFlags().BoolVar(&noOwnersFlag, "no-owners", false, "If present, don't look up the controllers owning each object, which are shown above its conditions.")
+	cmd.PersistentFlags().BoolVar(&treeFlag, "tree", false, "If present, also show the objects owned by the requested objects (recursively, through ownerReferences) with their conditions as a tree, e.g. Deployment → ReplicaSets → Pods.")
+	cmd.PersistentFlags().BoolVar(&freshOnlyFlag, "fresh-only", false, "If present, hide the stale conditions (whose observedGeneration is behind the generation of the object), and the objects without any other conditions.")
+	cmd.PersistentFlags().StringSliceVar(&typeFlag, "type", nil, "Only show the conditions of the specified types, and the objects that have them. Supports wildcards (e.g. --type Ready --type 'Frequent*'). Can be repeated.")
+	cmd.PersistentFlags().BoolVar(&recordFlag, "record", false, "If present, record the changes of the conditions of the printed (or watched) objects to a local history under the user cache directory, to be shown later with --history.")
+	cmd.PersistentFlags().BoolVar(&historyFlag, "history", false, "If present, show a summary of the recorded status changes of each condition (see --record), e.g. to spot flapping conditions.")
+	cmd.PersistentFlags().StringVar(&sortByFlag, "sort-by", "severity", "Order of the conditions of each object. One of: (severity, type, lastTransitionTime, status). severity lists the unhealthy conditions first, lastTransitionTime the oldest transitions first.")
+	cmd.PersistentFlags().BoolVar(&reverseFlag, "reverse", false, "If present, reverse the order of the conditions selected with --sort-by.")
+	cmd.PersistentFlags().StringSliceVar(&contextsFlag, "contexts", nil, "Names of kubeconfig contexts to query the requested objects in concurrently (e.g. staging,production), instead of the current context. Results are printed grouped by context.")
+	cmd.PersistentFlags().BoolVar(&allContextsFlag, "all-contexts", false, "If present, query the requested objects in all contexts of the kubeconfig, like --contexts.")
+	cmd.PersistentFlags().BoolVar(&showManagerFlag, "show-manager", false, "If present, show the field managers (e.g. kubelet or a controller) that last set each condition, based on the managedFields of the object.")
+	cmd.PersistentFlags().BoolVar(&ignoreMissingFlag, "ignore-missing", false, "If present, skip the objects that have neither conditions nor other status fields to show (e.g. ConfigMaps) instead of failing, e.g. when querying \"all\".")
+	cmd.PersistentFlags().StringSliceVar(&showFlag, "show", nil, "Only show the given fields in the details of conditions. One or more of: (reason, message, notes, lastTransition, lastUpdate, heartbeat).")
+	cmd.PersistentFlags().StringSliceVar(&hideFlag, "hide", nil, "Hide the given fields from the details of conditions (e.g. --hide message,heartbeat). One or more of: (reason, message, notes, lastTransition, lastUpdate, heartbeat).")
+	cmd.PersistentFlags().IntVar(&maxMessageLinesFlag, "max-message-lines", 10, "Maximum number of lines of the message of a condition to print in tables. Zero means no limit.")
+	cmd.PersistentFlags().BoolVar(&fullMessageFlag, "full-message", false, "If present, print the messages of conditions in full, without truncating them, cutting the lines of embedded JSON or YAML, or collapsing repeated messages.")
+	cmd.PersistentFlags().DurationVar(&highlightRecentFlag, "highlight-recent", 0, "Mark the conditions that transitioned within the given duration (e.g. 10m) to tell recent changes apart from long-standing state. Zero means no highlighting.")
+	cmd.PersistentFlags().DurationVar(&staleAfterFlag, "stale-after", 5*time.Minute, "Flag the conditions of nodes whose last heartbeat is older than the given duration as stale, since the kubelet may be down. Zero disables the check.")
+	cmd.PersistentFlags().StringVar(&timeFormatFlag, "time-format", "both", "How to print the times of conditions. One of: (relative, absolute, both), e.g. relative for quick scans or absolute for incident timelines.")
+	cmd.PersistentFlags().StringVar(&timezoneFlag, "timezone", "local", "Time zone to print absolute times in, as \"local\", \"UTC\" or an IANA time zone name (e.g. America/New_York).")
+	cmd.PersistentFlags().BoolVar(&rolloutFlag, "rollout", false, "Follow the rollout of a Deployment, StatefulSet or DaemonSet until it completes, printing the conditions of the workload, its current and old ReplicaSets and its pods that aren't ready as they change. Use with --timeout to limit the wait.")
+	cmd.PersistentFlags().Int64Var(&chunkSizeFlag, "chunk-size", 500, "Return large lists in chunks rather than all at once. Pass 0 to disable.")
+	cmd.PersistentFlags().BoolVar(&strictFlag, "strict", false, "If present, stop at the first object (or resource type) that fails to be queried, instead of printing the others and summarizing the failures at the end.")
+	cmd.PersistentFlags().BoolVarP(&watchFlag, "watch", "w", false, "After listing the requested objects, watch for changes and print condition transitions as they happen. Multiple resource types can be watched at once (e.g. deploy,rs,pods).")
+	cmd.PersistentFlags().DurationVar(&refreshFlag, "refresh", 0, "Re-query and redraw the output every interval (e.g. 10s) like watch, highlighting the reasons and messages that changed since the previous refresh. Zero means no refresh.")
+	cmd.PersistentFlags().IntVar(&flapThresholdFlag, "flap-threshold", 5, "While watching, warn when a condition changes status at least this many times within --flap-window. Set to 0 to disable.")
+	cmd.PersistentFlags().DurationVar(&flapWindowFlag, "flap-window", 10*time.Minute, "Time window for detecting flapping conditions with --flap-threshold.")
+	cmd.PersistentFlags().DurationVar(&watchStatsIntervalFlag, "watch-stats-interval", 30*time.Second, "Interval for printing the rate of condition transitions while watching. Set to 0 to disable.")
+	cmd.PersistentFlags().DurationVar(&timeoutFlag, "timeout", 0, "The maximum time the whole command (including discovery and all requests) can take, for example 30s. Zero means no timeout. In watch mode, the watch stops after the timeout.")
+	cmd.PersistentFlags().Float32Var(&qpsFlag, "qps", 0, "Maximum number of queries per second to the API server. Zero uses the client default.")
+	cmd.PersistentFlags().IntVar(&burstFlag, "burst", 0, "Maximum burst of queries to the API server above --qps. Zero uses the client default.")
+	cmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", "", "Output format. One of: (table, wide, matrix, timeline, json, yaml, markdown, html, go-template=..., jsonpath=..., custom-columns=..., ndjson, jsonl). ndjson (or jsonl) prints each condition transition as a JSON line and is only supported with --watch.")
+	cmd.PersistentFlags().BoolVar(&nodeMetricsFlag, "node-metrics", false, "If present, query the metrics API and kubelet stats to print resource usage next to Node pressure conditions.")
+	cmd.PersistentFlags().DurationVar(&terminatingThresholdFlag, "terminating-threshold", 5*time.Minute, "Duration after which an object that is still terminating is highlighted as stuck.")
+	cmd.PersistentFlags().BoolVar(&missingNodesFlag, "missing-nodes", false, "If present, list the nodes that don't have a ready pod of an unavailable DaemonSet. Requires listing nodes and pods.")
+	cmd.PersistentFlags().BoolVar(&expandAppFlag, "expand-app", false, "If present, also show the conditions of the resources managed by Argo CD Applications, as listed in their status.")
+	cmd.PersistentFlags().BoolVar(&podsFlag, "pods", false, "If present, also show the conditions of the pods of Deployments, StatefulSets, DaemonSets, ReplicaSets and Jobs, found with their selectors. Combine with --problems-only to only show the pods with problems.")
+	cmd.PersistentFlags().StringVar(&pushMetricsFlag, "push-metrics", "", "URL of a Prometheus Pushgateway to push the condition statuses to as gauges after printing them, e.g. http://pushgateway:9091. Metrics are grouped under job \"kubectl-cond\" unless the URL specifies a grouping key (.../metrics/job/<job>/...).")
+	cmd.PersistentFlags().BoolVarP(&interactiveFlag, "interactive", "i", false, "If present, choose which of the matching objects to show from an interactive list. Names with wildcards (e.g. web-*) offer the list automatically when running in a terminal.")
+	cmd.PersistentFlags().StringVar(&outputFileFlag, "output-file", "", "Write the output to the given file instead and only print a summary. Files ending with .json or .html are written in that format, other files get the text output without colors.")
+	cmd.PersistentFlags().StringVar(&outputJSONFlag, "output-json", "", "Also write the conditions as JSON to the given file, in addition to the output selected with --output. The conditions hidden with --problems-only, --fresh-only and --type are left out of it, too.")
+	cmd.PersistentFlags().StringSliceVar(&negativePolarityFlag, "negative-polarity", nil, "Condition types whose True status is unhealthy (e.g. Stalled,Degraded), in addition to the well-known ones and the ones in the config file. Types can be scoped to a kind as KIND[.GROUP]/TYPE, and prefixed with - to treat them as positive.")
+	cmd.PersistentFlags().BoolVar(&inferPolarityFlag, "infer-polarity", true, "Infer the polarity of the condition types of custom resources that aren't configured with --negative-polarity from the OpenAPI schema of their CRD, based on the condition types it declares (e.g. Degraded).")
+	cmd.PersistentFlags().StringVar(&treatUnknownAsFlag, "treat-unknown-as", "neutral", "How to score conditions with Unknown status when coloring, sorting and counting them. One of: (neutral, healthy, unhealthy). With neutral, Unknown is colored and sorted apart from False, but its object still counts as unhealthy (e.g. for --fail-on-unhealthy).")
+	cmd.PersistentFlags().StringVar(&themeFlag, "theme", "", "Color theme. One of: (default, dark, light, mono). Defaults to the theme in the config file, or default.")
+	cmd.PersistentFlags().BoolVar(&iconsFlag, "icons", false, "If present, show an icon for the health of each condition (✔, ✖, ! or ?) in addition to its color. Enabled by default with --theme=mono.")
+	cmd.PersistentFlags().BoolVar(&noColorFlag, "no-color", false, "If present, print the output without colors. Colors are also disabled when the NO_COLOR environment variable is set or the output isn't a terminal.")
+	cmd.PersistentFlags().BoolVar(&plainFlag, "plain", false, "If present, print linear \"label: value\" lines without tables, colors or symbols, for screen readers and basic terminals.")
+	cmd.PersistentFlags().StringVar(&profileFlag, "profile", "none", "Name of the profile to capture. One of: (none, cpu, mem, heap, goroutine, threadcreate, block, mutex).")
+	cmd.PersistentFlags().StringVar(&profileOutputFlag, "profile-output", "profile.pprof", "Name of the file to write the profile to.")
+	cmd.PersistentFlags().StringArrayVar(&notifyFlag, "notify", nil, "Send a notification for each condition transition observed with --watch. One of: (webhook=<url>, exec=<command>, desktop, events). events creates a Warning Event involving the object when the status of a condition changes from healthy to unhealthy. Can be repeated.")
+	cmd.PersistentFlags().StringVar(&notifyTemplateFlag, "notify-template", "", "Go template for the notification messages. Fields: .Time, .Cluster, .APIVersion, .Kind, .Namespace, .Name, .UID, .Ref, .Type, .OldStatus, .Status, .Reason, .Message, .Healthy.")
+	cmd.PersistentFlags().BoolVar(&dryRunFlag, "dry-run", false, "If present, only print the requests that would be sent to query the requested objects (and how many), without querying them. API discovery is still used to resolve resource types.")
+	cmd.PersistentFlags().StringSliceVarP(&filenameOpts.Filenames, "filename", "f", nil, "Filename, directory, or URL to files identifying the resource to get from a server. Can be repeated, and - reads from stdin. Objects specified more than once are only shown once.")
+	cmd.PersistentFlags().BoolVar(&localFlag, "local", false, "If present, print the conditions of the objects in the files specified with -f as they are (e.g. exported with kubectl get -o yaml), without contacting the API server.")
+	cmd.PersistentFlags().BoolVar(&serverDryRunFlag, "server-dry-run", false, "If present, submit the objects in the files specified with -f as server-side dry-run applies, and print the conditions of their live state along with whether the change would be rejected, create the object or change its spec.")
+	cmd.PersistentFlags().BoolVarP(&filenameOpts.Recursive, "recursive", "R", false, "Process the directory used in -f, --filename recursively. Useful when you want to manage related manifests organized within the same directory.")
 	cmd.PersistentFlags().StringVar(&filenameOpts.Kustomize, "kustomize", "", "Process a kustomization directory. This flag can't be used together with -f or -R.")
 
 	configFlags.AddFlags(cmd.PersistentFlags())
-	if err := cmd.Execute(); err != nil {
-		fmt.Printf("command failed: %v\n", err)
+	registerCompletions(cmd, configFlags)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// restore the default behavior so that a second signal terminates
+		// the program immediately
+		<-ctx.Done()
+		stop()
+	}()
+	err := cmd.ExecuteContext(ctx)
+	if perr := stopProfiling(); perr != nil && err == nil {
+		err = perr
+	}
+	if err != nil {
+		if errors.Is(err, errUnhealthy) {
+			os.Exit(1)
+		}
+		fmt.Fprintf(stdout, "command failed: %v\n", err)
+		for _, hint := range rbacHints(err) {
+			fmt.Fprintln(stdout, hint)
+		}
+		for _, hint := range configHints(configFlags, err) {
+			fmt.Fprintln(stdout, hint)
+		}
 		os.Exit(1)
 	}
 
@@ -86,181 +278,528 @@ func main() {
 
 func runFunc(configFlags *genericclioptions.ConfigFlags) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, posArgs []string) error {
+		ctx, cancel := commandContext(cmd, configFlags)
+		defer cancel()
+		if dryRunFlag {
+			return runDryRun(stdout, configFlags, posArgs)
+		}
+		if serverDryRunFlag {
+			return runServerDryRun(ctx, &kubeClient{configFlags: configFlags}, configFlags, posArgs)
+		}
+		if localFlag {
+			return runLocal(ctx, posArgs)
+		}
+		if groupByFlag == "" && spansMultipleKinds(posArgs) {
+			groupByFlag = "kind"
+		}
+		if len(contextsFlag) > 0 || allContextsFlag {
+			if watchFlag || waitForFlag != "" || rolloutFlag || treeFlag || interactiveFlag || hasNamePatterns(posArgs) ||
+				outputFileFlag != "" || outputJSONFlag != "" || pushMetricsFlag != "" {
+				return errors.New("--contexts doesn't support --watch, --wait-for, --rollout, --tree, --interactive, name patterns, --output-file, --output-json and --push-metrics")
+			}
+			return runContexts(ctx, configFlags, posArgs)
+		}
+		kc := &kubeClient{configFlags: configFlags}
+		if (watchFlag || waitForFlag != "") && pushMetricsFlag != "" {
+			return errors.New("--push-metrics is not supported with --watch")
+		}
+		if (watchFlag || waitForFlag != "") && treeFlag {
+			return errors.New("--tree is not supported with --watch")
+		}
+		if (watchFlag || waitForFlag != "" || rolloutFlag || treeFlag) && podsFlag {
+			return errors.New("--pods is not supported with --watch, --wait-for, --rollout and --tree")
+		}
+		if (watchFlag || waitForFlag != "" || rolloutFlag || treeFlag) && quietFlag {
+			return errors.New("--quiet is not supported with --watch, --wait-for, --rollout and --tree")
+		}
+		if !watchFlag && waitForFlag == "" && len(notifyFlag) > 0 {
+			return errors.New("--notify can only be used with --watch")
+		}
+		if refreshFlag > 0 {
+			return runRefresh(ctx, kc, configFlags, posArgs)
+		}
+		if rolloutFlag {
+			return runRollout(ctx, kc, configFlags, posArgs)
+		}
+		if watchFlag || waitForFlag != "" {
+			return runWatch(ctx, kc, configFlags, posArgs)
+		}
 
-		clientCfg := configFlags.ToRawKubeConfigLoader()
-		kubeconfigNamespace, _, err := clientCfg.Namespace()
+		if interactiveFlag || hasNamePatterns(posArgs) {
+			infos, err := selectObjects(configFlags, posArgs)
+			if err != nil {
+				return err
+			}
+			return printObjects(ctx, kc, resource.InfoListVisitor(infos))
+		}
+
+		rb, err := newResourceBuilder(configFlags)
 		if err != nil {
-			return fmt.Errorf("failed to determine namespace from kubeconfig: %w", err)
-		}
-
-		rb := resource.NewBuilder(configFlags)
-
-		namespace := ptr.Deref(configFlags.Namespace, "")
-		if namespace != "" {
-			rb.NamespaceParam(namespace)
-		} else if kubeconfigNamespace != "" {
-			rb.NamespaceParam(kubeconfigNamespace)
-		}
-		return rb.DefaultNamespace().
-			AllNamespaces(allNamespacesFlag).
-			Unstructured().
-			ResourceTypeOrNameArgs(true, posArgs...).
-			FilenameParam(false, filenameOpts).
-			Latest().
-			Flatten().
-			ContinueOnError().
-			Do().
-			Visit(func(info *resource.Info, err error) error {
-				if err != nil {
-					return err
-				}
-				if err := printObject(info.Object); err != nil {
-					return fmt.Errorf("failed to print object %s %s/%s: %w",
-						info.Object.GetObjectKind().GroupVersionKind().Kind, info.Namespace, info.Name, err)
-				}
-				return nil
-			})
+			return err
+		}
+		rb = rb.ResourceTypeOrNameArgs(true, posArgs...).FilenameParam(false, filenameOpts)
+		if treeFlag {
+			return runTree(ctx, kc, configFlags, rb)
+		}
+		return printObjects(ctx, kc, queryLatest(rb))
+	}
+}
+
+// runLocal prints the conditions of the objects in the files as they are
+// stored in them. Enrichers that query the API server are skipped.
+func runLocal(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return errors.New("resource arguments are not supported with --local, specify the files with -f")
+	}
+	if len(filenameOpts.Filenames) == 0 && filenameOpts.Kustomize == "" {
+		return errors.New("--local requires objects to be specified with -f or --kustomize")
+	}
+	if watchFlag || waitForFlag != "" || expandAppFlag || podsFlag {
+		return errors.New("--watch, --wait-for, --expand-app and --pods are not supported with --local")
+	}
+	r := resource.NewLocalBuilder().
+		Unstructured().
+		FilenameParam(false, filenameOpts).
+		Flatten().
+		ContinueOnError().
+		Do()
+	return printObjects(ctx, nil, r)
+}
+
+// queryLatest returns the result of querying the latest state of the
+// objects selected with the builder, continuing past errors for individual
+// objects unless --strict is specified.
+func queryLatest(rb *resource.Builder) *resource.Result {
+	rb = rb.RequestChunksOf(chunkSizeFlag).
+		Latest().
+		Flatten()
+	if !strictFlag {
+		rb = rb.ContinueOnError()
 	}
+	return rb.Do()
 }
 
-type GenericCondition struct {
-	Type               string                 `json:"type"`
-	Status             metav1.ConditionStatus `json:"status"`
-	Reason             string                 `json:"reason"`
-	Message            string                 `json:"message"`
-	LastUpdateTime     *metav1.Time           `json:"lastUpdateTime"`
-	LastTransitionTime *metav1.Time           `json:"lastTransitionTime"`
-	LastHeartbeatTime  *metav1.Time           `json:"lastHeartbeatTime"`
-	ObservedGeneration int64                  `json:"observedGeneration"`
+// printObjects prints the conditions of the visited objects.
+func printObjects(ctx context.Context, kc *kubeClient, v resource.Visitor) error {
+	return printObjectsTo(ctx, kc, v, stdout, stderr)
 }
 
-func printObject(obj runtime.Object) error {
+// printObjectsTo prints the conditions of the visited objects to out, and
+// diagnostics to errOut.
+func printObjectsTo(ctx context.Context, kc *kubeClient, v resource.Visitor, out, errOut io.Writer) error {
+	p, err := newOutputPrinter(ctx, kc, out)
+	if err != nil {
+		return err
+	}
+	return printVisited(ctx, kc, v, p, errOut)
+}
+
+// newOutputPrinter returns the printer selected by the output flags, along
+// with the printers of the additional outputs (such as --output-json).
+func newOutputPrinter(ctx context.Context, kc *kubeClient, out io.Writer) (printer, error) {
+	var p printer
+	var err error
+	if outputFileFlag != "" {
+		p, err = newOutputFilePrinter(outputFileFlag, out)
+	} else {
+		p, err = newPrinter(out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if outputJSONFlag != "" {
+		jp, err := newJSONFilePrinter(outputJSONFlag)
+		if err != nil {
+			return nil, err
+		}
+		// like -o json, the JSON matches the conditions that are shown
+		p = teePrinter{p, withConditionFilters(jp)}
+	}
+	if pushMetricsFlag != "" {
+		p = &pushMetricsPrinter{printer: p, ctx: ctx, url: pushMetricsFlag}
+	}
+	if (recordFlag || historyFlag) && kc != nil {
+		store, err := newHistoryStore(currentContextName(kc.configFlags))
+		if err != nil {
+			return nil, err
+		}
+		p = &historyPrinter{printer: p, store: store}
+	}
+	return p, nil
+}
+
+// printVisited builds the reports of the visited objects and prints them
+// with p, followed by the objects that failed to be queried.
+func printVisited(ctx context.Context, kc *kubeClient, v resource.Visitor, p printer, errOut io.Writer) error {
+	// the same object can be specified more than once, e.g. with -f
+	// for both a directory and a file in it
+	seen := make(map[string]bool)
+	var counts healthCounts
+	var skipped int
+	printReport := func(r *objectReport) error {
+		counts.addShown(r.Conditions)
+		return p.Print(r)
+	}
+	// objects that fail to be queried are reported at the end, unless
+	// --strict is specified
+	var failures []error
+	fail := func(err error) error {
+		if strictFlag {
+			return err
+		}
+		failures = append(failures, err)
+		return nil
+	}
+	err := visitReports(ctx, kc, v, func(info *resource.Info, report *objectReport, managed []*objectReport, err error) error {
+		if info == nil {
+			return fail(err)
+		}
+		if seen[infoKey(info)] {
+			return nil
+		}
+		seen[infoKey(info)] = true
+		if groupByFlag == "kind" && errors.Is(err, errNoConditions) {
+			// listed among the kinds without conditions
+		} else if ignoreMissingFlag && errors.Is(err, errNoConditions) {
+			skipped++
+			return nil
+		} else if err != nil {
+			return fail(fmt.Errorf("failed to print object %s %s/%s: %w",
+				info.Object.GetObjectKind().GroupVersionKind().Kind, info.Namespace, info.Name, err))
+		}
+		if err := printReport(report); err != nil {
+			return err
+		}
+		for _, r := range managed {
+			if seen[objectKey(r.Object)] {
+				continue
+			}
+			seen[objectKey(r.Object)] = true
+			if err := printReport(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeoutFlag)
+	}
+	if err != nil {
+		return err
+	}
+	if len(seen) == 0 && len(failures) > 0 {
+		// nothing to report partial results of
+		return errors.Join(failures...)
+	}
+	if len(seen) == 0 {
+		// like kubectl get, so that an empty result (e.g. of a selector
+		// matching nothing) isn't mistaken for a hang or a silent failure
+		fmt.Fprintln(errOut, "No resources found.")
+	}
+	if skipped > 0 {
+		fmt.Fprintln(errOut, gray.Sprintf("Skipped %d objects without conditions or other status fields.", skipped))
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		printFailures(errOut, failures)
+		return fmt.Errorf("%d of the queries failed, see the errors above (or use --strict to stop at the first one)", len(failures))
+	}
+	if failOnUnhealthyFlag && counts.UnhealthyObjects > 0 {
+		return errUnhealthy
+	}
+	return nil
+}
+
+// infoKey returns a key that identifies the object of the info, in the same
+// form as objectKey.
+func infoKey(info *resource.Info) string {
+	// objects read with --local don't have a mapping
+	gk := info.Object.GetObjectKind().GroupVersionKind().GroupKind()
+	if info.Mapping != nil {
+		gk = info.Mapping.GroupVersionKind.GroupKind()
+	}
+	return gk.String() + "/" + info.Namespace + "/" + info.Name
+}
+
+// commandContext returns the context for running the command, bounded by
+// --timeout, and configures the API clients created from configFlags to use
+// it along with the client rate limits and retries of transient failures.
+func commandContext(cmd *cobra.Command, configFlags *genericclioptions.ConfigFlags) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeoutFlag > 0 {
+		ctx, cancel = context.WithTimeout(cmd.Context(), timeoutFlag)
+	} else {
+		ctx, cancel = context.WithCancel(cmd.Context())
+	}
+	configFlags.WrapConfigFn = func(c *rest.Config) *rest.Config {
+		c.Wrap(withRetries)
+		c.Wrap(withContext(ctx))
+		if qpsFlag > 0 {
+			c.QPS = qpsFlag
+		}
+		if burstFlag > 0 {
+			c.Burst = burstFlag
+		}
+		return c
+	}
+	return ctx, cancel
+}
+
+// resolveNamespace returns the namespace specified with the flags, or the
+// namespace of the current kubeconfig context.
+func resolveNamespace(configFlags *genericclioptions.ConfigFlags) (string, error) {
+	if namespace := ptr.Deref(configFlags.Namespace, ""); namespace != "" {
+		return namespace, nil
+	}
+	kubeconfigNamespace, _, err := configFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine namespace from kubeconfig: %w", err)
+	}
+	return kubeconfigNamespace, nil
+}
+
+// newResourceBuilder returns a builder for unstructured objects in the
+// namespace determined from the flags and the kubeconfig.
+func newResourceBuilder(configFlags *genericclioptions.ConfigFlags) (*resource.Builder, error) {
+	namespace, err := resolveNamespace(configFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	rb := resource.NewBuilder(configFlags)
+	if namespace != "" {
+		rb.NamespaceParam(namespace)
+	}
+	if selectorFlag != "" {
+		rb.LabelSelectorParam(selectorFlag)
+	}
+	if fieldSelectorFlag != "" {
+		rb.FieldSelectorParam(fieldSelectorFlag)
+	}
+	return rb.DefaultNamespace().
+		AllNamespaces(allNamespacesFlag).
+		Unstructured(), nil
+}
+
+// GenericCondition is the common form of the conditions of all kinds of
+// objects.
+type GenericCondition = conditions.Condition
+
+// objectReport holds the normalized conditions of an object along with the
+// object itself.
+type objectReport struct {
+	Object     *unstructured.Unstructured
+	Kind       string
+	Namespace  string
+	Name       string
+	Conditions []GenericCondition
+
+	// Header holds additional context lines about the object that are not
+	// conditions, printed under the object name.
+	Header []string
+}
+
+// displayName returns the namespaced name of the object (or just the name
+// for cluster-scoped objects).
+func (r *objectReport) displayName() string {
+	if r.Namespace != "" {
+		return r.Namespace + "/" + r.Name
+	}
+	return r.Name
+}
+
+func newObjectReport(ctx context.Context, kc *kubeClient, obj runtime.Object) (*objectReport, error) {
 	// Convert the object to unstructured if it is not already
 	unstructuredObj, ok := obj.(*unstructured.Unstructured)
 	if !ok {
 		// Object is not unstructured, convert it
 		objJSON, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 		if err != nil {
-			return fmt.Errorf("failed to convert object to unstructured: %w", err)
+			return nil, fmt.Errorf("failed to convert object to unstructured: %w", err)
 		}
 		unstructuredObj = &unstructured.Unstructured{Object: objJSON}
 	}
+	var managers map[string][]conditionManager
+	if showManagerFlag {
+		managers = conditionManagers(unstructuredObj.GetManagedFields())
+	}
+	pruneObject(unstructuredObj)
+	lastObject.Store(unstructuredObj)
 
-	// Extract status.conditions from the unstructured object
-	conditions, found, err := unstructured.NestedSlice(unstructuredObj.Object, "status", "conditions")
+	condElems, found, err := conditions.Extract(unstructuredObj)
 	if err != nil {
-		return fmt.Errorf("failed to extract conditions from object: %w", err)
+		return nil, err
 	}
-	if !found {
-		return fmt.Errorf("no status.conditions[] found in object")
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract object metadata: %w", err)
 	}
-
-	condElems := make([]GenericCondition, 0, len(conditions))
-	for i, c := range conditions {
-		condMap, ok := c.(map[string]any)
-		if !ok {
-			return fmt.Errorf("failed to convert condition#%d to map (type: %T)", i, c)
-		}
-		// convert untyped map to GenericCondition
-		b, err := json.Marshal(condMap)
-		if err != nil {
-			return fmt.Errorf("failed to marshal condition#%d: %w", i, err)
-		}
-		var c GenericCondition
-		if err := json.Unmarshal(b, &c); err != nil {
-			return fmt.Errorf("failed to unmarshal condition#%d: %w", i, err)
+	if showManagerFlag {
+		addManagerNotes(condElems, managers)
+	}
+	report := &objectReport{
+		Object:     unstructuredObj,
+		Kind:       obj.GetObjectKind().GroupVersionKind().Kind,
+		Namespace:  objMeta.GetNamespace(),
+		Name:       objMeta.GetName(),
+		Conditions: condElems,
+	}
+	if lintFlag {
+		if line := addLintViolations(unstructuredObj, condElems); line != "" {
+			report.Header = append(report.Header, line)
 		}
-		condElems = append(condElems, c)
 	}
 
-	sort.Slice(condElems, func(i, j int) bool {
-		return byCondition(condElems[i], condElems[j])
-	})
+	// enrichers may add synthetic conditions or other context for objects
+	// that don't have any conditions
+	enrichReport(ctx, kc, report)
+	gvk := report.Object.GroupVersionKind()
+	for i := range report.Conditions {
+		report.Conditions[i].NegativePolarity = isNegativePolarity(kc, gvk, report.Conditions[i].Type)
+	}
+	if !found && len(report.Conditions) == 0 && len(report.Header) == 0 {
+		addFallbackStatus(report)
+	}
+	if !found && len(report.Conditions) == 0 && len(report.Header) == 0 {
+		// the report is still returned for listing such objects
+		return report, errNoConditions
+	}
+	addComputedStatus(report)
+	if explainFlag {
+		addExplanations(report)
+	}
 
-	objMeta, err := meta.Accessor(obj)
-	if err != nil {
-		return fmt.Errorf("failed to extract object metadata: %w", err)
+	sortConditions(report.Conditions)
+	return report, nil
+}
+
+// pruneObject drops the fields of the object that are never used but often
+// make up most of its size, so that listing thousands of objects doesn't
+// consume excessive memory when the reports are buffered.
+func pruneObject(obj *unstructured.Unstructured) {
+	obj.SetManagedFields(nil)
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; ok {
+			delete(annotations, corev1.LastAppliedConfigAnnotation)
+			obj.SetAnnotations(annotations)
+		}
 	}
-	kind := obj.GetObjectKind().GroupVersionKind().Kind
-	fmt.Printf(bold.Sprintf("%s", kind))
-	if objMeta.GetNamespace() != "" {
-		fmt.Printf(bold.Sprintf(" %s/%s", objMeta.GetNamespace(), objMeta.GetName()))
-	} else {
-		fmt.Printf(bold.Sprintf(" %s", objMeta.GetName()))
+}
+
+func printObject(w io.Writer, r *objectReport, layout tableLayout) {
+	printObjectHeader(w, r)
+	if len(r.Conditions) == 0 {
+		return
 	}
-	fmt.Println()
 
-	printConditions(condElems)
-	return nil
+	printConditions(w, r.Conditions, layout)
+}
+
+// printObjectHeader prints the name of the object and its header lines.
+func printObjectHeader(w io.Writer, r *objectReport) {
+	fmt.Fprint(w, bold.Sprintf("%s", r.Kind))
+	fmt.Fprint(w, bold.Sprintf(" %s", r.displayName()))
+	fmt.Fprintln(w)
+	for _, line := range r.Header {
+		fmt.Fprintln(w, line)
+	}
+	if len(r.Conditions) == 0 {
+		fmt.Fprintln(w, gray.Sprint("No conditions reported."))
+	}
 }
 
 type colorFunc func(string) string
 
-func printConditions(conditions []GenericCondition) {
-	table := tablewriter.NewWriter(os.Stdout)
+func printConditions(w io.Writer, conditions []GenericCondition, layout tableLayout) {
+	table := tablewriter.NewWriter(w)
 	table.SetHeader([]string{"Condition Type", "Details"})
 	table.SetColWidth(100)
 	table.SetAutoWrapText(false)
 	table.SetRowLine(true)
 
+	messageWidth := layout.messageWidth(conditions)
+	conditions = dedupMessages(conditions)
 	for _, cond := range conditions {
-		colorFn := statusColor(cond.Type, cond.Status)
-		condType := colorFn(cond.Type) + "\n" + "(" + string(cond.Status) + ")"
-		details := formatConditionDetails(colorFn, cond)
+		colorFn := statusColor(cond)
+		condType := highlightRecent(cond, colorFn(statusIcon(cond)+cond.Type)) + "\n" + "(" + string(cond.Status) + ")"
+		details := formatConditionDetails(colorFn, cond, messageWidth, layout.compactTimes())
 		table.Append([]string{condType, details})
 	}
 
 	table.Render()
 }
 
-func statusColor(condType string, status metav1.ConditionStatus) func(string) string {
+func statusColor(cond GenericCondition) func(string) string {
 
-	status = invertPolarity(condType, status)
+	status := semanticStatus(cond)
 
 	var statusColor *color.Color
-	switch status {
-	case metav1.ConditionTrue:
-		statusColor = color.New(color.FgGreen)
-	case metav1.ConditionFalse:
-		statusColor = color.New(color.FgRed)
-	case metav1.ConditionUnknown:
-		statusColor = color.New(color.FgHiBlack)
+	switch {
+	case cond.Stale:
+		statusColor = activeTheme.muted
+	case isHeartbeatStale(cond):
+		statusColor = staleHeartbeatColor
+	case status == metav1.ConditionTrue:
+		statusColor = activeTheme.healthy
+	case status == metav1.ConditionFalse:
+		statusColor = activeTheme.unhealthy
+		if cond.Warning {
+			statusColor = activeTheme.warning
+		}
+	case status == metav1.ConditionUnknown:
+		statusColor = activeTheme.unknown
 	default: // shouldn't happen in practice
-		statusColor = color.New(color.FgHiBlack)
+		statusColor = activeTheme.muted
 	}
 	return func(s string) string {
 		return statusColor.Sprint(s)
 	}
 }
 
-func invertPolarity(condType string, status metav1.ConditionStatus) metav1.ConditionStatus {
-	if status == metav1.ConditionUnknown || !negativePolarityNodeConditions.Has(condType) {
-		return status
-	}
+// semanticStatus returns the status of the condition after taking its
+// polarity and the --treat-unknown-as policy into account, so that True
+// always means healthy and False means unhealthy.
+func semanticStatus(cond GenericCondition) metav1.ConditionStatus {
+	return conditions.SemanticStatus(cond, unknownAs())
+}
 
-	if status == metav1.ConditionTrue {
-		return metav1.ConditionFalse
-	} else {
+// isHealthy reports whether the condition is in a good state after taking its
+// polarity into account. Unknown status is not considered healthy, unless
+// --treat-unknown-as=healthy is specified.
+func isHealthy(cond GenericCondition) bool {
+	return conditions.IsHealthy(cond, unknownAs())
+}
+
+// unknownAs returns the status that Unknown is treated as with
+// --treat-unknown-as.
+func unknownAs() metav1.ConditionStatus {
+	switch treatUnknownAsFlag {
+	case "healthy":
 		return metav1.ConditionTrue
+	case "unhealthy":
+		return metav1.ConditionFalse
+	default:
+		return metav1.ConditionUnknown
 	}
 }
 
-func formatConditionDetails(colorize colorFunc, cond GenericCondition) string {
+func formatConditionDetails(colorize colorFunc, cond GenericCondition, messageWidth int, compactTimes bool) string {
 	var detail string
 	if cond.Reason != "" {
-		detail += fmt.Sprintf("%s\n", colorize(bold.Sprint(cond.Reason)))
+		detail += fmt.Sprintf("%s\n", wrapString(bold.Sprint(cond.Reason), messageWidth, colorize))
 	}
 	if cond.Message != "" {
-		cond.Message = wrapString(cond.Message, 80, colorize)
-		cond.Message = colorize(cond.Message)
-		detail += fmt.Sprintf("%s\n", cond.Message)
+		detail += fmt.Sprintf("%s\n", formatMessage(cond.Message, messageWidth, colorize))
+	}
+	for _, note := range cond.Notes {
+		detail += wrapString(note, messageWidth, func(s string) string { return gray.Sprint(s) }) + "\n"
 	}
 
 	expressTime := func(t *metav1.Time) string {
-		return fmt.Sprintf("%s %s",
-			humanize.RelTime(t.Time, time.Now(), "ago", "from now"),
-			gray.Sprintf("(%s)", t.Time.Format(time.RFC3339)),
-		)
+		return formatTime(t.Time, compactTimes, func(s string) string { return gray.Sprint(s) })
 	}
 
 	if cond.LastTransitionTime != nil {
@@ -271,67 +810,8 @@ func formatConditionDetails(colorize colorFunc, cond GenericCondition) string {
 	}
 	if cond.LastHeartbeatTime != nil {
 		// especially for corev1.Node
-		detail += fmt.Sprintf("Last Heartbeat: %s\n", expressTime(cond.LastHeartbeatTime))
+		detail += fmt.Sprintf("Last Heartbeat: %s%s\n", expressTime(cond.LastHeartbeatTime), staleHeartbeatMarker(cond))
 	}
 	detail = strings.TrimSuffix(detail, "\n")
 	return detail
 }
-
-func byCondition(i, j GenericCondition) bool {
-	// Rule 1: prioritize specific types
-	typePriority := map[string]int{
-		"Ready":     -2,
-		"Succeeded": -1, // e.g. Job
-	}
-	priI := typePriority[i.Type]
-	priJ := typePriority[j.Type]
-
-	if priI != priJ {
-		return priI < priJ
-	}
-
-	// Rule 2: status=False first, then Unknown, then True
-	statusOrder := map[metav1.ConditionStatus]int{
-		metav1.ConditionFalse:   0, // assumption: False means bad things
-		metav1.ConditionUnknown: 1, // assumption: Unknown means potentially bad things
-		metav1.ConditionTrue:    2, // assumption: True means good things
-	}
-
-	// calculate the semantic status of the condition
-	iStatus := invertPolarity(i.Type, i.Status)
-	jStatus := invertPolarity(j.Type, j.Status)
-	if iStatus != jStatus {
-		return statusOrder[iStatus] < statusOrder[jStatus]
-	}
-
-	// Rule 3: Sort by the last time it got changed in descending order
-	timeI := ptr.Deref(i.LastUpdateTime, ptr.Deref(i.LastTransitionTime, metav1.Time{})).Time
-	timeJ := ptr.Deref(j.LastUpdateTime, ptr.Deref(j.LastTransitionTime, metav1.Time{})).Time
-	return timeI.After(timeJ)
-}
-
-// wrapString wraps the input string to a given width n, splitting long words as needed.
-func wrapString[T ~string](input T, n int, colorize func(string) string) T {
-	if n <= 0 {
-		return input
-	}
-
-	var result strings.Builder
-	var line strings.Builder
-
-	for _, char := range input {
-		line.WriteRune(char)
-
-		if line.Len() >= n || char == '\n' {
-			result.WriteString(colorize(line.String()))
-			result.WriteString("\n")
-			line.Reset()
-		}
-	}
-
-	if line.Len() > 0 {
-		result.WriteString(colorize(line.String()))
-	}
-
-	return T(result.String())
-}
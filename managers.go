@@ -0,0 +1,107 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var showManagerFlag bool
+
+// conditionManager is a field manager that owns fields of a condition.
+type conditionManager struct {
+	Manager string
+	Time    *metav1.Time
+	// OwnsStatus is whether the manager owns the status of the condition,
+	// i.e. is the one that last set it.
+	OwnsStatus bool
+}
+
+// conditionManagers returns the field managers of each condition type,
+// parsed from the managed fields of the object. Conditions are a list
+// keyed by type, so the fields of a condition are listed under
+// f:status/f:conditions/k:{"type":"<type>"}.
+func conditionManagers(entries []metav1.ManagedFieldsEntry) map[string][]conditionManager {
+	out := make(map[string][]conditionManager)
+	for _, e := range entries {
+		if e.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Status struct {
+				Conditions map[string]map[string]any `json:"f:conditions"`
+			} `json:"f:status"`
+		}
+		if err := json.Unmarshal(e.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		for key, condFields := range fields.Status.Conditions {
+			var k struct {
+				Type string `json:"type"`
+			}
+			keyJSON, ok := strings.CutPrefix(key, "k:")
+			if !ok || json.Unmarshal([]byte(keyJSON), &k) != nil || k.Type == "" {
+				continue
+			}
+			_, ownsStatus := condFields["f:status"]
+			out[k.Type] = append(out[k.Type], conditionManager{Manager: e.Manager, Time: e.Time, OwnsStatus: ownsStatus})
+		}
+	}
+	return out
+}
+
+// addManagerNotes notes the field managers that set each condition, e.g.
+// "Set by kubelet (Update, 2 minutes ago)". Conditions whose fields are
+// owned by several managers list all of them, which usually means that
+// controllers are fighting over the condition.
+func addManagerNotes(conditions []GenericCondition, managers map[string][]conditionManager) {
+	for i := range conditions {
+		ms := managers[conditions[i].Type]
+		if len(ms) == 0 {
+			continue
+		}
+		// the owners of the status are the ones that set the condition
+		var setters, others []string
+		sort.SliceStable(ms, func(a, b int) bool {
+			return ms[a].Time != nil && (ms[b].Time == nil || ms[a].Time.After(ms[b].Time.Time))
+		})
+		for _, m := range ms {
+			s := m.Manager
+			if m.Time != nil {
+				s += fmt.Sprintf(" (%s)", humanize.RelTime(m.Time.Time, time.Now(), "ago", "from now"))
+			}
+			if m.OwnsStatus {
+				setters = append(setters, s)
+			} else {
+				others = append(others, s)
+			}
+		}
+		if len(setters) == 0 {
+			setters, others = others, nil
+		}
+		note := "Set by " + strings.Join(setters, ", ")
+		if len(others) > 0 {
+			note += "; also managed by " + strings.Join(others, ", ")
+		}
+		conditions[i].Notes = append(conditions[i].Notes, note)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// rawManifestURL returns the URL of the raw contents of a file viewed on
+// GitHub or GitLab (e.g. a manifest linked from a pull request), so that
+// "-f https://github.com/org/repo/blob/main/deploy.yaml" works like it does
+// in the browser. Other URLs and paths are returned as is.
+func rawManifestURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "https" {
+		return s
+	}
+	switch {
+	case u.Host == "github.com":
+		// /<org>/<repo>/blob/<ref>/<path>
+		parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 4)
+		if len(parts) < 4 || parts[2] != "blob" {
+			return s
+		}
+		u.Host = "raw.githubusercontent.com"
+		u.Path = "/" + parts[0] + "/" + parts[1] + "/" + parts[3]
+		u.RawQuery = ""
+	case u.Host == "gitlab.com" && strings.Contains(u.Path, "/-/blob/"):
+		u.Path = strings.Replace(u.Path, "/-/blob/", "/-/raw/", 1)
+	default:
+		return s
+	}
+	u.Fragment = ""
+	return u.String()
+}
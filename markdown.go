@@ -0,0 +1,86 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// healthBadges encode the health of conditions in reports that can't be
+// colored.
+var healthBadges = map[string]string{
+	"healthy":   "✅",
+	"unhealthy": "❌",
+	"warning":   "⚠️",
+	"unknown":   "❓",
+}
+
+// healthBadge returns the badge for the health of the condition.
+func healthBadge(cond GenericCondition) string {
+	return healthBadges[healthLabel(cond)]
+}
+
+// markdownPrinter prints each object's conditions as a Markdown table, e.g.
+// to be pasted into an incident ticket.
+type markdownPrinter struct {
+	w      io.Writer
+	counts healthCounts
+}
+
+func (p *markdownPrinter) Print(r *objectReport) error {
+	p.counts.add(r.Conditions)
+	fmt.Fprintf(p.w, "### %s %s\n\n", r.Kind, markdownEscape(r.displayName()))
+	for _, line := range r.Header {
+		fmt.Fprintf(p.w, "%s  \n", markdownEscape(ansiEscapePattern.ReplaceAllString(line, "")))
+	}
+	if len(r.Header) > 0 {
+		fmt.Fprintln(p.w)
+	}
+	if len(r.Conditions) == 0 {
+		fmt.Fprint(p.w, "No conditions reported.\n\n")
+		return nil
+	}
+	fmt.Fprintln(p.w, "| Condition Type | Status | Reason | Message | Last Transition |")
+	fmt.Fprintln(p.w, "|---|---|---|---|---|")
+	for _, cond := range r.Conditions {
+		message := markdownEscape(orDash(cond.Message))
+		for _, note := range cond.Notes {
+			message += "<br>_" + markdownEscape(note) + "_"
+		}
+		lastTransition := "-"
+		if cond.LastTransitionTime != nil {
+			lastTransition = plainTime(cond.LastTransitionTime.Time)
+		}
+		fmt.Fprintf(p.w, "| %s | %s %s | %s | %s | %s |\n", markdownEscape(cond.Type), healthBadge(cond), cond.Status,
+			markdownEscape(orDash(cond.Reason)), message, lastTransition)
+	}
+	fmt.Fprintln(p.w)
+	return nil
+}
+
+func (p *markdownPrinter) Flush() error {
+	_, err := fmt.Fprintf(p.w, "**%s**\n", p.counts)
+	return err
+}
+
+// markdownEscaper escapes the characters that would break the table or be
+// interpreted as formatting.
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", "<br>", "*", `\*`, "_", `\_`, "`", "\\`")
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
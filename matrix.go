@@ -0,0 +1,109 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// matrixPrinter buffers all reports and renders them as a grid where rows
+// are objects and columns are condition types.
+type matrixPrinter struct {
+	w       io.Writer
+	reports []*objectReport
+}
+
+func (p *matrixPrinter) Print(r *objectReport) error {
+	p.reports = append(p.reports, r)
+	return nil
+}
+
+func (p *matrixPrinter) Flush() error {
+	if len(p.reports) == 0 {
+		return nil
+	}
+	// reuse the pivot ordering so the columns with most problems come first
+	groups := groupByCondition(p.reports)
+	types := make([]string, 0, len(groups))
+	for _, g := range groups {
+		types = append(types, g.Type)
+	}
+
+	kinds := make(map[string]bool)
+	for _, r := range p.reports {
+		kinds[r.Kind] = true
+	}
+
+	table := tablewriter.NewWriter(p.w)
+	table.SetHeader(append([]string{"Object"}, types...))
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetCenterSeparator("")
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	alignment := []int{tablewriter.ALIGN_LEFT}
+	for range types {
+		alignment = append(alignment, tablewriter.ALIGN_CENTER)
+	}
+	table.SetColumnAlignment(alignment)
+
+	for _, r := range p.reports {
+		name := r.displayName()
+		if len(kinds) > 1 {
+			name = strings.ToLower(r.Kind) + "/" + name
+		}
+		byType := make(map[string]GenericCondition, len(r.Conditions))
+		for _, cond := range r.Conditions {
+			byType[cond.Type] = cond
+		}
+		row := []string{name}
+		for _, t := range types {
+			cond, ok := byType[t]
+			if !ok {
+				row = append(row, gray.Sprint("-"))
+				continue
+			}
+			row = append(row, matrixCell(cond))
+		}
+		table.Append(row)
+	}
+	table.Render()
+	return nil
+}
+
+// matrixCell returns a compact colored glyph for the semantic state of the
+// condition. Stale conditions are grayed out.
+func matrixCell(cond GenericCondition) string {
+	switch semanticStatus(cond) {
+	case metav1.ConditionTrue:
+		if cond.Stale {
+			return gray.Sprint("✓")
+		}
+		return activeTheme.healthy.Sprint("✓")
+	case metav1.ConditionFalse:
+		if cond.Stale {
+			return gray.Sprint("✗")
+		}
+		return activeTheme.unhealthy.Sprint("✗")
+	default:
+		return gray.Sprint("?")
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"sigs.k8s.io/yaml"
+)
+
+var maxMessageLinesFlag int
+var fullMessageFlag bool
+
+// minDedupMessageLength is the length above which a message repeated by
+// several conditions of an object is only printed for the first one.
+const minDedupMessageLength = 80
+
+// minYAMLLines is the number of lines that a message must end with to be
+// considered to embed a YAML document.
+const minYAMLLines = 3
+
+// maxYAMLAttempts is the number of lines that look like the start of a YAML
+// document that a message is parsed from, to bound the time spent on long
+// messages that aren't valid YAML after all.
+const maxYAMLAttempts = 3
+
+// yamlLinePattern matches the first line of a YAML mapping or sequence.
+var yamlLinePattern = regexp.MustCompile(`^\s*(- |[\w./"'-]+:(\s|$))`)
+
+// messageBlock is a part of a message: either text, or structured data
+// (JSON or YAML) that shouldn't be wrapped like text.
+type messageBlock struct {
+	text string
+	data bool
+}
+
+// formatMessage renders the message of a condition for the table. JSON
+// embedded in the message is pretty-printed, text is wrapped to width (the
+// lines of embedded data are cut instead, unless --full-message is given),
+// and the result is limited to --max-message-lines.
+func formatMessage(msg string, width int, colorize colorFunc) string {
+	var lines []string
+	for _, b := range splitMessage(msg, width) {
+		for _, line := range strings.Split(b.text, "\n") {
+			// tabs (e.g. of stack traces) would misalign the table
+			line = strings.ReplaceAll(line, "\t", "    ")
+			if b.data && !fullMessageFlag {
+				if width > 0 {
+					line = runewidth.Truncate(line, width, "…")
+				}
+				lines = append(lines, line)
+				continue
+			}
+			lines = append(lines, strings.Split(wrapString(line, width, func(s string) string { return s }), "\n")...)
+		}
+	}
+	var more int
+	if !fullMessageFlag && maxMessageLinesFlag > 0 && len(lines) > maxMessageLinesFlag {
+		more = len(lines) - maxMessageLinesFlag
+		lines = lines[:maxMessageLinesFlag]
+	}
+	for i, line := range lines {
+		lines[i] = colorize(line)
+	}
+	if more == 1 {
+		lines = append(lines, gray.Sprint("… 1 more line (use --full-message to show all)"))
+	} else if more > 1 {
+		lines = append(lines, gray.Sprintf("… %d more lines (use --full-message to show all)", more))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitMessage separates the JSON object or array embedded in the message,
+// if it's too long to fit in a line, or the YAML document that the message
+// ends with (such as a dump of an object) from the rest of the message.
+func splitMessage(msg string, width int) []messageBlock {
+	if start, end, ok := embeddedJSON(msg); ok && end-start > width {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(msg[start:end]), "", "  "); err == nil {
+			return textAround(msg[:start], messageBlock{text: buf.String(), data: true}, msg[end:])
+		}
+	}
+	trimmed := strings.TrimRight(msg, "\n")
+	lines := strings.Split(trimmed, "\n")
+	attempts := 0
+	// off is the offset of lines[i] in trimmed
+	for i, off := 0, 0; i+minYAMLLines <= len(lines) && attempts < maxYAMLAttempts; i, off = i+1, off+len(lines[i])+1 {
+		if !yamlLinePattern.MatchString(lines[i]) {
+			continue
+		}
+		attempts++
+		if doc := trimmed[off:]; looksLikeYAML(doc) {
+			return textAround(trimmed[:off], messageBlock{text: doc, data: true}, "")
+		}
+	}
+	return []messageBlock{{text: msg}}
+}
+
+func textAround(before string, data messageBlock, after string) []messageBlock {
+	var out []messageBlock
+	if s := strings.TrimSpace(before); s != "" {
+		out = append(out, messageBlock{text: s})
+	}
+	out = append(out, data)
+	if s := strings.TrimSpace(after); s != "" {
+		out = append(out, messageBlock{text: s})
+	}
+	return out
+}
+
+// embeddedJSON returns the bounds of the JSON object or array in the message
+// that starts at the first opening bracket.
+func embeddedJSON(msg string) (start, end int, ok bool) {
+	start = strings.IndexAny(msg, "{[")
+	if start < 0 {
+		return 0, 0, false
+	}
+	dec := json.NewDecoder(strings.NewReader(msg[start:]))
+	var v json.RawMessage
+	if err := dec.Decode(&v); err != nil {
+		return 0, 0, false
+	}
+	return start, start + int(dec.InputOffset()), true
+}
+
+// looksLikeYAML reports whether s is a multi-line YAML mapping or sequence.
+func looksLikeYAML(s string) bool {
+	if !yamlLinePattern.MatchString(s) {
+		return false
+	}
+	var v any
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return false
+	}
+	switch v.(type) {
+	case map[string]any, []any:
+		return true
+	}
+	return false
+}
+
+// dedupMessages returns the conditions with the long messages that are the
+// same as the message of an earlier condition replaced with a note referring
+// to it.
+func dedupMessages(conditions []GenericCondition) []GenericCondition {
+	if fullMessageFlag {
+		return conditions
+	}
+	out := slices.Clone(conditions)
+	first := make(map[string]string)
+	for i, cond := range out {
+		if len(cond.Message) < minDedupMessageLength {
+			continue
+		}
+		typ, ok := first[cond.Message]
+		if !ok {
+			first[cond.Message] = cond.Type
+			continue
+		}
+		out[i].Message = ""
+		out[i].Notes = append([]string{fmt.Sprintf("Same message as %s", typ)}, cond.Notes...)
+	}
+	return out
+}
+
+// wrapString wraps the input to the display width n at word boundaries, and
+// colorizes each line with colorize. Words that are longer than n (such as
+// long URLs) are only split when they don't fit in a line on their own. ANSI
+// escape sequences in the input don't take up any width, and the colors that
+// span several lines are reapplied on each of them.
+func wrapString[T ~string](input T, n int, colorize func(string) string) T {
+	if n <= 0 {
+		return input
+	}
+	var lines []string
+	for _, line := range strings.Split(string(input), "\n") {
+		w := lineWrapper{width: n}
+		w.wrap(line)
+		lines = append(lines, w.lines...)
+	}
+	for i, line := range lines {
+		lines[i] = colorize(line)
+	}
+	return T(strings.Join(lines, "\n"))
+}
+
+// lineWrapper wraps a single line of text.
+type lineWrapper struct {
+	width int
+	lines []string
+	cur   strings.Builder
+	// used is the display width of cur.
+	used int
+	// active holds the escape sequences in effect at the end of cur.
+	active  string
+	wrapped bool
+}
+
+func (w *lineWrapper) wrap(line string) {
+	for i, word := range strings.Split(line, " ") {
+		// spaces are dropped where the line is broken
+		sep := 0
+		if i > 0 && (w.used > 0 || !w.wrapped) {
+			sep = 1
+		}
+		width := displayWidth(word)
+		switch {
+		case w.used+sep+width <= w.width:
+			if sep > 0 {
+				w.cur.WriteByte(' ')
+				w.used++
+			}
+			w.write(word, false)
+		case width <= w.width:
+			w.breakLine()
+			w.write(word, false)
+		default:
+			if w.used > 0 {
+				w.breakLine()
+			}
+			w.write(word, true)
+		}
+	}
+	w.lines = append(w.lines, w.cur.String())
+}
+
+// write appends the word to the current line, splitting it into several
+// lines as needed if split is set.
+func (w *lineWrapper) write(word string, split bool) {
+	for word != "" {
+		if loc := ansiEscapePattern.FindStringIndex(word); loc != nil && loc[0] == 0 {
+			seq := word[:loc[1]]
+			if seq == "\x1b[0m" || seq == "\x1b[m" {
+				w.active = ""
+			} else {
+				w.active += seq
+			}
+			w.cur.WriteString(seq)
+			word = word[loc[1]:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		rw := runewidth.RuneWidth(r)
+		if split && w.used > 0 && w.used+rw > w.width {
+			w.breakLine()
+		}
+		w.cur.WriteRune(r)
+		w.used += rw
+		word = word[size:]
+	}
+}
+
+func (w *lineWrapper) breakLine() {
+	line := strings.TrimRight(w.cur.String(), " ")
+	if w.active != "" {
+		line += "\x1b[0m"
+	}
+	w.lines = append(w.lines, line)
+	w.cur.Reset()
+	w.cur.WriteString(w.active)
+	w.used = 0
+	w.wrapped = true
+}
+
+// displayWidth returns the number of terminal columns that s takes up,
+// excluding ANSI escape sequences and counting wide characters (such as CJK
+// characters and emoji) as two columns.
+func displayWidth(s string) int {
+	return runewidth.StringWidth(ansiEscapePattern.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWrapString(t *testing.T) {
+	const (
+		red   = "\x1b[31m"
+		bold  = "\x1b[1m"
+		reset = "\x1b[0m"
+	)
+	tests := []struct {
+		name  string
+		input string
+		n     int
+		want  []string
+	}{
+		{name: "fits", input: "hello world", n: 20, want: []string{"hello world"}},
+		{name: "word boundaries", input: "the quick brown fox", n: 10, want: []string{"the quick", "brown fox"}},
+		{name: "exact width", input: "abcde fghij", n: 5, want: []string{"abcde", "fghij"}},
+		{name: "newlines kept", input: "a b\nc d", n: 10, want: []string{"a b", "c d"}},
+		{name: "long word split", input: "https://example.com/a/b/c", n: 10, want: []string{"https://ex", "ample.com/", "a/b/c"}},
+		{name: "long word after short one", input: "see https://example.com/x", n: 10, want: []string{"see", "https://ex", "ample.com/", "x"}},
+		{name: "leading spaces", input: "  indented text", n: 20, want: []string{"  indented text"}},
+		{name: "double spaces", input: "a  b", n: 10, want: []string{"a  b"}},
+		{name: "spaces dropped at break", input: "aaaa   bbbb", n: 5, want: []string{"aaaa", "bbbb"}},
+		{name: "CJK", input: "日本語の テキスト", n: 8, want: []string{"日本語の", "テキスト"}},
+		{name: "CJK long word", input: "日本語のテキスト", n: 5, want: []string{"日本", "語の", "テキ", "スト"}},
+		{name: "emoji", input: "🔥🔥 fire", n: 5, want: []string{"🔥🔥", "fire"}},
+		{name: "ANSI takes no width", input: red + "abc" + reset + " def", n: 7, want: []string{red + "abc" + reset + " def"}},
+		{name: "ANSI across break", input: red + "abc def" + reset, n: 4,
+			want: []string{red + "abc" + reset, red + "def" + reset}},
+		{name: "stacked ANSI across break", input: red + bold + "abc def ghi" + reset + " jkl", n: 4,
+			want: []string{red + bold + "abc" + reset, red + bold + "def" + reset, red + bold + "ghi" + reset, "jkl"}},
+		{name: "ANSI across split word", input: red + "abcdefgh" + reset, n: 4,
+			want: []string{red + "abcd" + reset, red + "efgh" + reset}},
+		{name: "zero width", input: "the quick brown fox", n: 0, want: []string{"the quick brown fox"}},
+		{name: "negative width", input: "the quick brown fox", n: -1, want: []string{"the quick brown fox"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapString(tt.input, tt.n, func(s string) string { return s })
+			if want := strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("wrapString(%q, %d) =\n%q\nwant\n%q", tt.input, tt.n, got, want)
+			}
+			if tt.n <= 0 {
+				return
+			}
+			for _, line := range strings.Split(got, "\n") {
+				if w := displayWidth(line); w > tt.n {
+					t.Errorf("line %q is %d columns wide, more than %d", line, w, tt.n)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapStringColorizesEachLine(t *testing.T) {
+	got := wrapString("abc def", 4, func(s string) string { return "<" + s + ">" })
+	if want := "<abc>\n<def>"; got != want {
+		t.Errorf("wrapString() = %q, want %q", got, want)
+	}
+}
+
+func TestEmbeddedJSON(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{msg: "no json here"},
+		{msg: `failed: {"code": 500, "reason": "Internal"} (retrying)`, want: `{"code": 500, "reason": "Internal"}`},
+		{msg: `errors: [{"field": "spec"}, {"field": "status"}]`, want: `[{"field": "spec"}, {"field": "status"}]`},
+		{msg: `nested {"a": {"b": "}"}} and {"c": 1}`, want: `{"a": {"b": "}"}}`},
+		{msg: "not json {really} {\"a\": 1}"},
+		{msg: "unterminated {\"a\": 1"},
+		{msg: "{" + strings.Repeat(" x}", 10000)},
+	}
+	for _, tt := range tests {
+		start, end, ok := embeddedJSON(tt.msg)
+		if ok != (tt.want != "") || ok && tt.msg[start:end] != tt.want {
+			t.Errorf("embeddedJSON(%.40q) = %q, %v, want %q", tt.msg, tt.msg[start:end], ok, tt.want)
+		}
+	}
+}
+
+func TestSplitMessageYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want []messageBlock
+	}{
+		{name: "text", msg: "container exited: code 1", want: []messageBlock{{text: "container exited: code 1"}}},
+		{name: "trailing YAML", msg: "object is invalid:\nspec:\n  replicas: 3\n  paused: true\n",
+			want: []messageBlock{{text: "object is invalid:"}, {text: "spec:\n  replicas: 3\n  paused: true", data: true}}},
+		{name: "too few lines", msg: "reason: x\nkey: y", want: []messageBlock{{text: "reason: x\nkey: y"}}},
+		{name: "gives up after maxYAMLAttempts",
+			msg:  strings.Repeat("a: b: c\n", 10) + "spec:\n  replicas: 3\n  paused: true",
+			want: []messageBlock{{text: strings.Repeat("a: b: c\n", 10) + "spec:\n  replicas: 3\n  paused: true"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitMessage(tt.msg, 80); !slices.Equal(got, tt.want) {
+				t.Errorf("splitMessage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
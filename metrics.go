@@ -0,0 +1,153 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultPushgatewayJob is the job name metrics are grouped under when the
+// --push-metrics URL doesn't specify a grouping key.
+const defaultPushgatewayJob = "kubectl-cond"
+
+var metricStatuses = []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown}
+
+// writeMetrics writes the conditions of the reports as gauges in the
+// Prometheus text exposition format, modeled after the condition metrics
+// of kube-state-metrics (one series per possible status, set to 1 for the
+// current status).
+func writeMetrics(w io.Writer, reports []*objectReport, now time.Time) error {
+	var b bytes.Buffer
+	b.WriteString("# HELP kubectl_cond_condition The status of the condition of the object.\n")
+	b.WriteString("# TYPE kubectl_cond_condition gauge\n")
+	for _, r := range reports {
+		for _, cond := range r.Conditions {
+			for _, status := range metricStatuses {
+				var v int
+				if cond.Status == status {
+					v = 1
+				}
+				fmt.Fprintf(&b, "kubectl_cond_condition{%s,status=%q} %d\n", metricLabels(r, cond), status, v)
+			}
+		}
+	}
+	b.WriteString("# HELP kubectl_cond_condition_healthy Whether the condition of the object is in a healthy state, taking its polarity into account.\n")
+	b.WriteString("# TYPE kubectl_cond_condition_healthy gauge\n")
+	for _, r := range reports {
+		for _, cond := range r.Conditions {
+			var v int
+			if isHealthy(cond) {
+				v = 1
+			}
+			fmt.Fprintf(&b, "kubectl_cond_condition_healthy{%s} %d\n", metricLabels(r, cond), v)
+		}
+	}
+	b.WriteString("# HELP kubectl_cond_condition_reason The reason of the current status of the condition of the object.\n")
+	b.WriteString("# TYPE kubectl_cond_condition_reason gauge\n")
+	for _, r := range reports {
+		for _, cond := range r.Conditions {
+			if cond.Reason != "" {
+				fmt.Fprintf(&b, "kubectl_cond_condition_reason{%s,reason=%s} 1\n", metricLabels(r, cond), metricLabelValue(cond.Reason))
+			}
+		}
+	}
+	b.WriteString("# HELP kubectl_cond_last_run_timestamp_seconds The time the conditions were collected.\n")
+	b.WriteString("# TYPE kubectl_cond_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(&b, "kubectl_cond_last_run_timestamp_seconds %d\n", now.Unix())
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeConditionMetric writes the kube_cond gauge of the exporter: one series
+// per condition with its current status and reason as labels, set to 1.
+func writeConditionMetric(w io.Writer, reports []*objectReport) error {
+	var b bytes.Buffer
+	b.WriteString("# HELP kube_cond The current status and reason of the condition of the object.\n")
+	b.WriteString("# TYPE kube_cond gauge\n")
+	for _, r := range reports {
+		for _, cond := range r.Conditions {
+			fmt.Fprintf(&b, "kube_cond{%s,status=%q,reason=%s} 1\n", metricLabels(r, cond), cond.Status, metricLabelValue(cond.Reason))
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func metricLabels(r *objectReport, cond GenericCondition) string {
+	return fmt.Sprintf("kind=%s,namespace=%s,name=%s,type=%s",
+		metricLabelValue(r.Kind), metricLabelValue(r.Namespace), metricLabelValue(r.Name), metricLabelValue(cond.Type))
+}
+
+// metricLabelValue quotes and escapes a label value.
+func metricLabelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// pushMetrics replaces the metrics of the group on the Pushgateway at
+// gatewayURL with the conditions of the reports.
+func pushMetrics(ctx context.Context, gatewayURL string, reports []*objectReport, now time.Time) error {
+	var b bytes.Buffer
+	if err := writeMetrics(&b, reports, now); err != nil {
+		return err
+	}
+	u := strings.TrimSuffix(gatewayURL, "/")
+	if !strings.Contains(u, "/metrics/job/") {
+		u += "/metrics/job/" + defaultPushgatewayJob
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, &b)
+	if err != nil {
+		return fmt.Errorf("invalid --push-metrics URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to push metrics to %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// pushMetricsPrinter collects the printed reports to push their conditions
+// to a Pushgateway once the output is flushed.
+type pushMetricsPrinter struct {
+	printer
+	ctx     context.Context
+	url     string
+	reports []*objectReport
+}
+
+func (p *pushMetricsPrinter) Print(r *objectReport) error {
+	p.reports = append(p.reports, r)
+	return p.printer.Print(r)
+}
+
+func (p *pushMetricsPrinter) Flush() error {
+	if err := p.printer.Flush(); err != nil {
+		return err
+	}
+	return pushMetrics(p.ctx, p.url, p.reports, time.Now())
+}
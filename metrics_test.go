@@ -0,0 +1,43 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWriteConditionMetric(t *testing.T) {
+	r := testReport(
+		GenericCondition{Type: "Ready", Status: metav1.ConditionFalse, Reason: "ContainersNotReady"},
+		GenericCondition{Type: "PodScheduled", Status: metav1.ConditionTrue},
+	)
+	var b bytes.Buffer
+	if err := writeConditionMetric(&b, []*objectReport{r}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`kube_cond{kind="Pod",namespace="default",name="web",type="Ready",status="False",reason="ContainersNotReady"} 1`,
+		`kube_cond{kind="Pod",namespace="default",name="web",type="PodScheduled",status="True",reason=""} 1`,
+	}
+	for _, line := range want {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("metrics don't contain %s:\n%s", line, b.String())
+		}
+	}
+}
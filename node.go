@@ -0,0 +1,136 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// nodeUsage holds human-readable resource usage descriptions for a Node,
+// keyed by the pressure condition they relate to.
+type nodeUsage map[string]string
+
+func enrichNode(ctx context.Context, kc *kubeClient, r *objectReport) {
+	markStaleHeartbeats(r)
+
+	if unschedulable, _, _ := unstructured.NestedBool(r.Object.Object, "spec", "unschedulable"); unschedulable {
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:      "SchedulingDisabled",
+			Status:    metav1.ConditionTrue,
+			Reason:    "Cordoned",
+			Message:   "spec.unschedulable is set, new pods will not be scheduled on this node",
+			Synthetic: true,
+			Warning:   true,
+		})
+	}
+
+	if !nodeMetricsFlag || kc == nil {
+		return
+	}
+	usage, err := fetchNodeUsage(ctx, kc, r.Object)
+	if err != nil {
+		r.Header = append(r.Header, gray.Sprintf("Some resource usage is unavailable: %v", err))
+	}
+	for i, cond := range r.Conditions {
+		if u, ok := usage[cond.Type]; ok {
+			r.Conditions[i].Notes = append(r.Conditions[i].Notes, u)
+		}
+	}
+}
+
+// fetchNodeUsage queries the memory usage of the node from the metrics API
+// and the disk and process usage from the kubelet stats summary (through the
+// API server's node proxy). Partial results are returned along with an error
+// if some of the sources are unavailable.
+func fetchNodeUsage(ctx context.Context, kc *kubeClient, node *unstructured.Unstructured) (nodeUsage, error) {
+	cs, err := kc.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	usage := make(nodeUsage)
+	var errs []error
+
+	b, err := cs.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/nodes", node.GetName()).DoRaw(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("metrics API: %w", err))
+	} else if err := parseNodeMetrics(b, node, usage); err != nil {
+		errs = append(errs, fmt.Errorf("metrics API: %w", err))
+	}
+
+	b, err = cs.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", node.GetName(), "proxy", "stats", "summary").DoRaw(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("kubelet stats: %w", err))
+	} else if err := parseNodeStatsSummary(b, usage); err != nil {
+		errs = append(errs, fmt.Errorf("kubelet stats: %w", err))
+	}
+	return usage, errors.Join(errs...)
+}
+
+func parseNodeMetrics(b []byte, node *unstructured.Unstructured, usage nodeUsage) error {
+	var metrics struct {
+		Usage struct {
+			Memory resource.Quantity `json:"memory"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(b, &metrics); err != nil {
+		return fmt.Errorf("failed to decode node metrics: %w", err)
+	}
+	allocatable, _, _ := unstructured.NestedString(node.Object, "status", "allocatable", "memory")
+	total, err := resource.ParseQuantity(allocatable)
+	if err != nil || total.IsZero() {
+		return fmt.Errorf("node has no allocatable memory")
+	}
+	used := metrics.Usage.Memory.Value()
+	usage["MemoryPressure"] = fmt.Sprintf("Memory usage: %d%% (%s of %s allocatable)",
+		used*100/total.Value(), humanize.IBytes(uint64(used)), humanize.IBytes(uint64(total.Value())))
+	return nil
+}
+
+func parseNodeStatsSummary(b []byte, usage nodeUsage) error {
+	var summary struct {
+		Node struct {
+			Fs *struct {
+				CapacityBytes uint64 `json:"capacityBytes"`
+				UsedBytes     uint64 `json:"usedBytes"`
+			} `json:"fs"`
+			Rlimit *struct {
+				MaxPID  int64 `json:"maxpid"`
+				CurProc int64 `json:"curproc"`
+			} `json:"rlimit"`
+		} `json:"node"`
+	}
+	if err := json.Unmarshal(b, &summary); err != nil {
+		return fmt.Errorf("failed to decode stats summary: %w", err)
+	}
+	if fs := summary.Node.Fs; fs != nil && fs.CapacityBytes > 0 {
+		usage["DiskPressure"] = fmt.Sprintf("Disk usage: %d%% (%s of %s)",
+			fs.UsedBytes*100/fs.CapacityBytes, humanize.IBytes(fs.UsedBytes), humanize.IBytes(fs.CapacityBytes))
+	}
+	if rl := summary.Node.Rlimit; rl != nil && rl.MaxPID > 0 {
+		usage["PIDPressure"] = fmt.Sprintf("PID usage: %d%% (%d of %d)",
+			rl.CurProc*100/rl.MaxPID, rl.CurProc, rl.MaxPID)
+	}
+	return nil
+}
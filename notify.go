@@ -0,0 +1,387 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// notifyTimeout is the maximum time a single notification can take, so that
+// a slow notification target doesn't hold up the others.
+const notifyTimeout = 10 * time.Second
+
+// notifyQueueSize is the number of notifications waiting to be delivered
+// beyond which new ones are dropped, so that slow notification targets
+// don't hold up the watch.
+const notifyQueueSize = 100
+
+const defaultNotifyTemplate = `[{{.Cluster}}] {{.Kind}} {{.Ref}}: {{.Type}}=` +
+	`{{if .OldStatus}}{{.OldStatus}} → {{end}}{{.Status}}{{with .Reason}} ({{.}}){{end}}{{with .Message}}: {{.}}{{end}}`
+
+var notifyFlag []string
+var notifyTemplateFlag string
+
+// notification is the data about a condition transition available to
+// notification templates.
+type notification struct {
+	Time      time.Time `json:"time"`
+	Cluster   string    `json:"cluster"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	// APIVersion and UID identify the object the condition belongs to.
+	APIVersion string    `json:"apiVersion,omitempty"`
+	UID        types.UID `json:"uid,omitempty"`
+	// Ref is the namespace/name of the object, or its name if it's not
+	// namespaced.
+	Ref       string                 `json:"ref"`
+	Type      string                 `json:"type"`
+	OldStatus metav1.ConditionStatus `json:"oldStatus,omitempty"`
+	Status    metav1.ConditionStatus `json:"status"`
+	Reason    string                 `json:"reason,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+	Healthy   bool                   `json:"healthy"`
+	// OldHealthy is whether the condition was healthy before the
+	// transition.
+	OldHealthy bool `json:"oldHealthy"`
+}
+
+// notifier delivers notifications to a target.
+type notifier interface {
+	Notify(ctx context.Context, n notification, text string) error
+}
+
+// notifiers sends the condition transitions to the targets specified with
+// --notify, formatted with --notify-template. Notifications are queued and
+// delivered in the background, in the order of the transitions.
+type notifiers struct {
+	cluster  string
+	tmpl     *template.Template
+	targets  []notifier
+	errorOut func(err error)
+
+	queue chan queuedNotification
+	start sync.Once
+	wg    sync.WaitGroup
+}
+
+type queuedNotification struct {
+	data notification
+	text string
+}
+
+// newNotifiers parses the --notify targets and the template. It returns nil
+// if there are no targets.
+func newNotifiers(kc *kubeClient) (*notifiers, error) {
+	if len(notifyFlag) == 0 {
+		return nil, nil
+	}
+	text := notifyTemplateFlag
+	if text == "" {
+		text = defaultNotifyTemplate
+	}
+	tmpl, err := template.New("notification").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --notify-template: %w", err)
+	}
+	n := &notifiers{
+		cluster: currentContextName(kc.configFlags),
+		tmpl:    tmpl,
+		queue:   make(chan queuedNotification, notifyQueueSize),
+		errorOut: func(err error) {
+			fmt.Fprintln(stderr, gray.Sprintf("Failed to send notification: %v", err))
+		},
+	}
+	for _, target := range notifyFlag {
+		kind, value, _ := strings.Cut(target, "=")
+		switch kind {
+		case "webhook":
+			if value == "" {
+				return nil, errors.New("--notify=webhook requires a URL, e.g. --notify=webhook=https://hooks.example.com/...")
+			}
+			n.targets = append(n.targets, &webhookNotifier{url: value})
+		case "exec":
+			if value == "" {
+				return nil, errors.New("--notify=exec requires a command, e.g. --notify='exec=logger -t kubectl-cond'")
+			}
+			n.targets = append(n.targets, &execNotifier{command: value})
+		case "desktop":
+			if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+				return nil, fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+			}
+			n.targets = append(n.targets, desktopNotifier{})
+		case "events":
+			n.targets = append(n.targets, &eventNotifier{kc: kc})
+		default:
+			return nil, fmt.Errorf("unsupported notification target %q, expected one of: (webhook=<url>, exec=<command>, desktop, events)", target)
+		}
+	}
+	return n, nil
+}
+
+// currentContextName returns the name of the kubeconfig context in use, or
+// "in-cluster" when running with the in-cluster config.
+func currentContextName(configFlags *genericclioptions.ConfigFlags) string {
+	if configFlags.Context != nil && *configFlags.Context != "" {
+		return *configFlags.Context
+	}
+	raw, err := configFlags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return ""
+	}
+	if raw.CurrentContext == "" && inCluster(configFlags) {
+		return inClusterContextName
+	}
+	return raw.CurrentContext
+}
+
+// Transition queues a notification for a condition transition. Conditions
+// observed for the first time or removed don't trigger notifications, so
+// that the initial state of the objects doesn't flood the targets. The
+// notification is dropped if the queue is full.
+func (n *notifiers) Transition(ctx context.Context, t conditionTransition) {
+	if n == nil || t.Old == nil || t.New == nil {
+		return
+	}
+	data := notification{
+		Time:       t.Time,
+		Cluster:    n.cluster,
+		Kind:       t.Kind,
+		Namespace:  t.Namespace,
+		Name:       t.Name,
+		APIVersion: t.APIVersion,
+		UID:        t.UID,
+		Ref:        t.displayName(),
+		Type:       t.New.Type,
+		OldStatus:  t.Old.Status,
+		Status:     t.New.Status,
+		Reason:     t.New.Reason,
+		Message:    t.New.Message,
+		Healthy:    isHealthy(*t.New),
+		OldHealthy: isHealthy(*t.Old),
+	}
+	if data.OldStatus == data.Status {
+		data.OldStatus = ""
+	}
+	var b strings.Builder
+	if err := n.tmpl.Execute(&b, data); err != nil {
+		n.errorOut(fmt.Errorf("failed to render --notify-template: %w", err))
+		return
+	}
+	n.start.Do(func() {
+		n.wg.Add(1)
+		go n.deliver(ctx)
+	})
+	select {
+	case n.queue <- queuedNotification{data: data, text: b.String()}:
+	default:
+		n.errorOut(fmt.Errorf("dropped the notification about %s %s %s: too many notifications pending", data.Kind, data.Ref, data.Type))
+	}
+}
+
+// deliver sends the queued notifications to the targets until the queue is
+// closed.
+func (n *notifiers) deliver(ctx context.Context) {
+	defer n.wg.Done()
+	for q := range n.queue {
+		for _, target := range n.targets {
+			ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+			if err := target.Notify(ctx, q.data, q.text); err != nil {
+				n.errorOut(err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Close waits for the queued notifications to be delivered.
+func (n *notifiers) Close() {
+	if n == nil {
+		return
+	}
+	close(n.queue)
+	n.wg.Wait()
+}
+
+// webhookNotifier posts notifications as JSON, with the rendered message in
+// the "text" field (as expected by Slack-compatible incoming webhooks) and
+// the transition data in the "transition" field.
+type webhookNotifier struct {
+	url string
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, n notification, text string) error {
+	body, err := json.Marshal(struct {
+		Text       string       `json:"text"`
+		Transition notification `json:"transition"`
+	}{text, n})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// execNotifier runs a shell command for each notification, with the
+// rendered message on its stdin and the transition data in KUBECTL_COND_*
+// environment variables.
+type execNotifier struct {
+	command string
+}
+
+func (e *execNotifier) Notify(ctx context.Context, n notification, text string) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", e.command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", e.command)
+	}
+	cmd.Stdin = strings.NewReader(text + "\n")
+	cmd.Stdout = stderr
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(),
+		"KUBECTL_COND_MESSAGE="+text,
+		"KUBECTL_COND_CLUSTER="+n.Cluster,
+		"KUBECTL_COND_KIND="+n.Kind,
+		"KUBECTL_COND_NAMESPACE="+n.Namespace,
+		"KUBECTL_COND_NAME="+n.Name,
+		"KUBECTL_COND_TYPE="+n.Type,
+		"KUBECTL_COND_OLD_STATUS="+string(n.OldStatus),
+		"KUBECTL_COND_STATUS="+string(n.Status),
+		"KUBECTL_COND_REASON="+n.Reason,
+	)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("notification command failed: %w", err)
+	}
+	return nil
+}
+
+// desktopNotifier shows notifications with notify-send on Linux and
+// osascript on macOS.
+type desktopNotifier struct{}
+
+func (desktopNotifier) Notify(ctx context.Context, n notification, text string) error {
+	title := "kubectl cond"
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(text), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	} else {
+		cmd = exec.CommandContext(ctx, "notify-send", title, text)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("desktop notification failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// maxEventMessageLength is the length that the messages of the Events
+// created by eventNotifier are truncated to, to stay within the limits of
+// the API server.
+const maxEventMessageLength = 1024
+
+// eventNotifier creates a Warning Event involving the object when the status
+// of a condition changes to unhealthy, so that it shows up in kubectl
+// describe and kubectl get events.
+type eventNotifier struct {
+	kc *kubeClient
+}
+
+func (e *eventNotifier) Notify(ctx context.Context, n notification, text string) error {
+	// Events are only created when a condition flips from healthy to
+	// unhealthy, since the messages of some conditions change on every sync
+	// (e.g. the progress of a Deployment) and would flood the namespace
+	if n.Healthy || !n.OldHealthy || n.OldStatus == "" {
+		return nil
+	}
+	cs, err := e.kc.Clientset()
+	if err != nil {
+		return err
+	}
+	// events of cluster-scoped objects are conventionally in the default
+	// namespace
+	namespace := n.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	text = truncateBytes(text, maxEventMessageLength)
+	now := metav1.NewTime(n.Time)
+	_, err = cs.CoreV1().Events(namespace).Create(ctx, &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: n.Name + ".", Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: n.APIVersion,
+			Kind:       n.Kind,
+			Namespace:  n.Namespace,
+			Name:       n.Name,
+			UID:        n.UID,
+		},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "ConditionUnhealthy",
+		Message:        text,
+		Source:         corev1.EventSource{Component: "kubectl-cond"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+	return nil
+}
+
+// truncateBytes shortens s to at most n bytes with a trailing "...", without
+// splitting a UTF-8 encoded rune.
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	end := n - len("...")
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "..."
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// blockingNotifier blocks every notification until release is closed.
+type blockingNotifier struct {
+	release chan struct{}
+	mu      sync.Mutex
+	got     []string
+}
+
+func (b *blockingNotifier) Notify(ctx context.Context, n notification, text string) error {
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.got = append(b.got, text)
+	return nil
+}
+
+func TestNotifiersDontBlockWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	target := &blockingNotifier{release: make(chan struct{})}
+	var mu sync.Mutex
+	var errs []string
+	n := &notifiers{
+		tmpl:    template.Must(template.New("notification").Parse(defaultNotifyTemplate)),
+		targets: []notifier{target},
+		queue:   make(chan queuedNotification, notifyQueueSize),
+		errorOut: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err.Error())
+		},
+	}
+
+	const total = notifyQueueSize + 10
+	fw := watch.NewFakeWithChanSize(total, false)
+	for i := 0; i < total; i++ {
+		obj := &unstructured.Unstructured{}
+		obj.SetKind("Pod")
+		obj.SetName("web")
+		fw.Modify(obj)
+	}
+	events := make(chan watch.Event)
+	errCh := make(chan error, 1)
+	go runWatchSource(ctx, func() (watch.Interface, error) { return fw, nil }, events, errCh)
+
+	deadline := time.After(5 * time.Second)
+	for i := 0; i < total; i++ {
+		select {
+		case <-events:
+		case <-deadline:
+			t.Fatalf("received %d of %d events, the watch is held up by the notification target", i, total)
+		}
+		old := GenericCondition{Type: "Ready", Status: metav1.ConditionTrue}
+		cur := GenericCondition{Type: "Ready", Status: metav1.ConditionFalse}
+		n.Transition(ctx, conditionTransition{Kind: "Pod", Name: "web", Old: &old, New: &cur})
+	}
+
+	close(target.release)
+	n.Close()
+	// one notification can be taken off the queue by the delivering
+	// goroutine before the rest fill it up
+	if got := len(target.got); got != notifyQueueSize && got != notifyQueueSize+1 {
+		t.Errorf("delivered %d notifications, want %d", got, notifyQueueSize)
+	}
+	if len(errs) != total-len(target.got) {
+		t.Errorf("got %d errors for %d dropped notifications", len(errs), total-len(target.got))
+	}
+	for _, err := range errs {
+		if !strings.Contains(err, "dropped") {
+			t.Errorf("unexpected error: %s", err)
+		}
+	}
+}
+
+func TestEventNotifierSkipsTransitions(t *testing.T) {
+	// the notifier returns before using the client for skipped transitions
+	e := &eventNotifier{}
+	tests := []notification{
+		{Type: "Ready", OldStatus: metav1.ConditionFalse, Status: metav1.ConditionTrue, OldHealthy: false, Healthy: true},
+		{Type: "Ready", OldStatus: metav1.ConditionFalse, Status: metav1.ConditionUnknown, OldHealthy: false, Healthy: false},
+		{Type: "Ready", Status: metav1.ConditionFalse, OldHealthy: false, Healthy: false},
+	}
+	for _, n := range tests {
+		if err := e.Notify(context.Background(), n, "text"); err != nil {
+			t.Errorf("Notify(%s → %s) = %v, want it skipped", n.OldStatus, n.Status, err)
+		}
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"abcdefghijkl", 10, "abcdefg..."},
+		// "é" takes two bytes and would be split at byte 7
+		{"abcdeféghij", 10, "abcdef..."},
+		{"日本語のテキスト", 10, "日本..."},
+	}
+	for _, tt := range tests {
+		got := truncateBytes(tt.s, tt.n)
+		if got != tt.want || !utf8.ValidString(got) || len(got) > tt.n {
+			t.Errorf("truncateBytes(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// csvPhaseHistoryLength is the number of recent phases of a
+// ClusterServiceVersion shown in the header.
+const csvPhaseHistoryLength = 5
+
+// enrichClusterServiceVersion replaces the status.conditions of an Operator
+// Lifecycle Manager ClusterServiceVersion, which are a history of the phases
+// of the install rather than conditions, with a synthetic condition for the
+// current phase and rows for the requirements of the operator that are not
+// met.
+func enrichClusterServiceVersion(r *objectReport) {
+	obj := r.Object.Object
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	reason, _, _ := unstructured.NestedString(obj, "status", "reason")
+	message, _, _ := unstructured.NestedString(obj, "status", "message")
+	transitionTime, _, _ := unstructured.NestedString(obj, "status", "lastTransitionTime")
+
+	var phases []string
+	conds := r.Conditions[:0]
+	for _, cond := range r.Conditions {
+		if cond.Synthetic {
+			conds = append(conds, cond)
+		}
+	}
+	r.Conditions = conds
+	history, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	for _, v := range history {
+		if h, ok := v.(map[string]any); ok {
+			// the phase is repeated when only the reason changes
+			if p, _, _ := unstructured.NestedString(h, "phase"); p != "" && (len(phases) == 0 || phases[len(phases)-1] != p) {
+				phases = append(phases, p)
+			}
+		}
+	}
+	if len(phases) > csvPhaseHistoryLength {
+		phases = append([]string{"..."}, phases[len(phases)-csvPhaseHistoryLength:]...)
+	}
+	if len(phases) > 1 {
+		r.Header = append(r.Header, fmt.Sprintf("Phases: %s", strings.Join(phases, " → ")))
+	}
+
+	cond := GenericCondition{
+		Type:      "Succeeded",
+		Reason:    reason,
+		Message:   message,
+		Synthetic: true,
+	}
+	if t, err := time.Parse(time.RFC3339, transitionTime); err == nil {
+		cond.LastTransitionTime = &metav1.Time{Time: t}
+	}
+	switch phase {
+	case "Succeeded":
+		cond.Status = metav1.ConditionTrue
+	case "Failed":
+		cond.Status = metav1.ConditionFalse
+	case "":
+		cond.Status = metav1.ConditionUnknown
+	default:
+		// Pending, InstallReady, Installing, Replacing, Deleting...
+		cond.Status = metav1.ConditionFalse
+		cond.Warning = true
+	}
+	if phase != "" {
+		cond.Notes = append(cond.Notes, fmt.Sprintf("Phase: %s", phase))
+	}
+	r.Conditions = append(r.Conditions, cond)
+
+	requirements, _, _ := unstructured.NestedSlice(obj, "status", "requirementStatus")
+	var satisfied int
+	for _, v := range requirements {
+		req, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		status, _, _ := unstructured.NestedString(req, "status")
+		if status == "Present" {
+			satisfied++
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(req, "kind")
+		name, _, _ := unstructured.NestedString(req, "name")
+		reqMessage, _, _ := unstructured.NestedString(req, "message")
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:      kind + "/" + name,
+			Status:    metav1.ConditionFalse,
+			Reason:    status,
+			Message:   reqMessage,
+			Notes:     csvDependentNotes(req),
+			Synthetic: true,
+		})
+	}
+	if len(requirements) > 0 {
+		r.Header = append(r.Header, fmt.Sprintf("Requirements: %d of %d met", satisfied, len(requirements)))
+	}
+}
+
+// csvDependentNotes returns notes for the unmet dependents of a requirement,
+// such as the permissions a ServiceAccount requirement is missing.
+func csvDependentNotes(req map[string]any) []string {
+	dependents, _, _ := unstructured.NestedSlice(req, "dependents")
+	var out []string
+	for _, v := range dependents {
+		dep, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if status, _, _ := unstructured.NestedString(dep, "status"); status == "Satisfied" {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(dep, "kind")
+		message, _, _ := unstructured.NestedString(dep, "message")
+		out = append(out, fmt.Sprintf("%s: %s", kind, message))
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// enrichClusterVersion adds the current and desired versions of an
+// OpenShift cluster to the header, and marks the conditions that are often
+// False without affecting the health of the cluster (e.g. when no update
+// channel is configured) as warnings.
+func enrichClusterVersion(r *objectReport) {
+	obj := r.Object.Object
+	desired, _, _ := unstructured.NestedString(obj, "status", "desired", "version")
+
+	// history is ordered from the most recent update
+	var current, updating string
+	history, _, _ := unstructured.NestedSlice(obj, "status", "history")
+	for _, v := range history {
+		h, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		state, _, _ := unstructured.NestedString(h, "state")
+		version, _, _ := unstructured.NestedString(h, "version")
+		if state == "Completed" {
+			current = version
+			break
+		}
+		if updating == "" {
+			updating = version
+		}
+	}
+
+	switch {
+	case current == "" && updating != "":
+		r.Header = append(r.Header, fmt.Sprintf("Version: installing %s", updating))
+	case desired != "" && desired != current:
+		r.Header = append(r.Header, fmt.Sprintf("Version: %s (updating to %s)", current, desired))
+	case current != "":
+		r.Header = append(r.Header, fmt.Sprintf("Version: %s", current))
+	}
+
+	for i, cond := range r.Conditions {
+		switch cond.Type {
+		case "RetrievedUpdates", "Upgradeable":
+			if cond.Status == metav1.ConditionFalse {
+				r.Conditions[i].Warning = true
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func newOverviewCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "overview [-n <namespace> | -A]",
+		Short: "Summarize the health of all objects with conditions in a namespace, per kind",
+		Long: "Discover all namespaced resource types (including custom resources), list their objects in the namespace " +
+			"(or all namespaces with -A), and print how many of the objects reporting conditions are unhealthy per kind, " +
+			"followed by the unhealthy objects and their worst condition.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+
+			namespace := ""
+			if !allNamespacesFlag {
+				ns, err := resolveNamespace(configFlags)
+				if err != nil {
+					return err
+				}
+				namespace = ns
+			}
+			kinds, err := collectOverview(ctx, &kubeClient{configFlags: configFlags}, configFlags, namespace)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s", timeoutFlag)
+			}
+			if len(kinds) == 0 && err != nil {
+				return err
+			} else if err != nil {
+				fmt.Fprintln(stderr, gray.Sprintf("Some resource types couldn't be listed: %v", err))
+			}
+			counts := printOverview(stdout, kinds, allNamespacesFlag)
+			if failOnUnhealthyFlag && counts.UnhealthyObjects > 0 {
+				return errUnhealthy
+			}
+			return nil
+		},
+	}
+}
+
+// kindOverview is the health of the objects of a kind that report
+// conditions.
+type kindOverview struct {
+	gk        schema.GroupKind
+	counts    healthCounts
+	unhealthy []objectSummary
+}
+
+// collectOverview lists the objects of all namespaced resource types in the
+// namespace (all namespaces if empty) and summarizes the health of those
+// reporting conditions by kind. Enrichers that query the API server are
+// skipped to keep it fast. Resource types that fail to be listed (e.g. due
+// to RBAC) are reported in the returned error along with the partial result.
+func collectOverview(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, namespace string) ([]*kindOverview, error) {
+	dyn, err := kc.Dynamic()
+	if err != nil {
+		return nil, err
+	}
+	targets, discoveryErr := discoverScanTargets(configFlags, false)
+	if len(targets) == 0 && discoveryErr != nil {
+		return nil, discoveryErr
+	}
+
+	kinds := make(map[schema.GroupKind]*kindOverview)
+	var mu sync.Mutex
+	err = scanObjects(ctx, dyn, targets, namespace, func(obj *unstructured.Unstructured) {
+		r, err := newObjectReport(ctx, nil, obj)
+		if err != nil || len(r.Conditions) == 0 {
+			return
+		}
+		gk := obj.GroupVersionKind().GroupKind()
+		mu.Lock()
+		defer mu.Unlock()
+		k, ok := kinds[gk]
+		if !ok {
+			k = &kindOverview{gk: gk}
+			kinds[gk] = k
+		}
+		k.counts.add(r.Conditions)
+		if s := summarizeObject(r); s.worst != nil {
+			k.unhealthy = append(k.unhealthy, s)
+		}
+	})
+
+	out := make([]*kindOverview, 0, len(kinds))
+	for _, k := range kinds {
+		sort.Slice(k.unhealthy, func(i, j int) bool {
+			a, b := k.unhealthy[i].report, k.unhealthy[j].report
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+		out = append(out, k)
+	}
+	// kinds with the most unhealthy objects first
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].counts.UnhealthyObjects != out[j].counts.UnhealthyObjects {
+			return out[i].counts.UnhealthyObjects > out[j].counts.UnhealthyObjects
+		}
+		return out[i].gk.String() < out[j].gk.String()
+	})
+	return out, errors.Join(err, discoveryErr)
+}
+
+// printOverview prints the table of kinds followed by the unhealthy objects
+// of each kind, and returns the health of all objects.
+func printOverview(w io.Writer, kinds []*kindOverview, namespaced bool) healthCounts {
+	var total healthCounts
+	if len(kinds) == 0 {
+		fmt.Fprintln(w, "No objects with conditions found.")
+		return total
+	}
+	// the group is only shown to tell apart kinds with the same name
+	names := make(map[string]int)
+	for _, k := range kinds {
+		names[k.gk.Kind]++
+	}
+	kindName := func(k *kindOverview) string {
+		if names[k.gk.Kind] > 1 {
+			return k.gk.String()
+		}
+		return k.gk.Kind
+	}
+
+	table := newPlainTable(w, "Kind", "Objects", "Unhealthy", "Conditions", "Unhealthy Conditions")
+	for _, k := range kinds {
+		unhealthy := fmt.Sprint(k.counts.UnhealthyObjects)
+		if k.counts.UnhealthyObjects > 0 {
+			unhealthy = activeTheme.unhealthy.Sprint(unhealthy)
+		} else {
+			unhealthy = activeTheme.healthy.Sprint(unhealthy)
+		}
+		table.Append([]string{kindName(k), fmt.Sprint(k.counts.Objects), unhealthy,
+			fmt.Sprint(k.counts.Conditions), fmt.Sprint(k.counts.Unhealthy + k.counts.Unknown)})
+		total.Objects += k.counts.Objects
+		total.UnhealthyObjects += k.counts.UnhealthyObjects
+		total.Conditions += k.counts.Conditions
+		total.Healthy += k.counts.Healthy
+		total.Unhealthy += k.counts.Unhealthy
+		total.Unknown += k.counts.Unknown
+		total.Stale += k.counts.Stale
+	}
+	table.Render()
+
+	for _, k := range kinds {
+		if len(k.unhealthy) == 0 {
+			continue
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, bold.Sprintf("Unhealthy %s:", kindName(k)))
+		table := newPlainTable(w)
+		for _, s := range k.unhealthy {
+			name := s.report.Name
+			if namespaced {
+				name = s.report.displayName()
+			}
+			cond := *s.worst
+			worst := statusColor(cond)(cond.Type + "=" + string(cond.Status))
+			if cond.Reason != "" {
+				worst += " (" + cond.Reason + ")"
+			}
+			table.Append([]string{name, worst, strings.Join(strings.Fields(cond.Message), " ")})
+		}
+		table.Render()
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, total.String())
+	return total
+}
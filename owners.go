@@ -0,0 +1,122 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxOwnerDepth limits how many levels of controller owners are resolved.
+const maxOwnerDepth = 5
+
+var noOwnersFlag bool
+
+// ownerCache memoizes the owner chains above the controllers that were
+// looked up, as objects controlled by the same parent (such as the pods of
+// a ReplicaSet) share the rest of the chain.
+type ownerCache struct {
+	mu     sync.Mutex
+	chains map[string][]string
+}
+
+// enrichOwners adds a header listing the controller of the object and the
+// controllers above it (e.g. "ReplicaSet/foo-abc123 → Deployment/foo"),
+// which is usually the object to inspect next. Without cluster access, only
+// the direct controller is shown.
+func enrichOwners(ctx context.Context, kc *kubeClient, r *objectReport) {
+	ref := metav1.GetControllerOf(r.Object)
+	if ref == nil {
+		return
+	}
+	chain := []string{ownerName(*ref)}
+	if kc != nil {
+		chain = kc.owners.chain(ctx, kc, r.Namespace, *ref, maxOwnerDepth)
+	}
+	sep := " → "
+	if plainFlag {
+		sep = " -> "
+	}
+	r.Header = append(r.Header, gray.Sprint("Controlled by: ")+strings.Join(chain, sep))
+}
+
+// chain returns the names of the owner referenced by ref and the controllers
+// above it, up to depth levels.
+func (c *ownerCache) chain(ctx context.Context, kc *kubeClient, namespace string, ref metav1.OwnerReference, depth int) []string {
+	key := namespace + "/" + string(ref.UID)
+	c.mu.Lock()
+	chain, ok := c.chains[key]
+	c.mu.Unlock()
+	if ok {
+		return chain
+	}
+
+	chain = c.resolve(ctx, kc, namespace, ref, depth)
+	c.mu.Lock()
+	if c.chains == nil {
+		c.chains = make(map[string][]string)
+	}
+	c.chains[key] = chain
+	c.mu.Unlock()
+	return chain
+}
+
+func (c *ownerCache) resolve(ctx context.Context, kc *kubeClient, namespace string, ref metav1.OwnerReference, depth int) []string {
+	name := ownerName(ref)
+	if depth <= 1 {
+		return []string{name}
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return []string{name}
+	}
+	mapper, err := kc.configFlags.ToRESTMapper()
+	if err != nil {
+		return []string{name}
+	}
+	m, err := mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: ref.Kind}, gv.Version)
+	if err != nil {
+		return []string{name}
+	}
+	dyn, err := kc.Dynamic()
+	if err != nil {
+		return []string{name}
+	}
+	if m.Scope.Name() == meta.RESTScopeNameRoot {
+		namespace = ""
+	}
+	owner, err := dyn.Resource(m.Resource).Namespace(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []string{name + color.YellowString(" (not found)")}
+	} else if err != nil {
+		return []string{name}
+	}
+	next := metav1.GetControllerOf(owner)
+	if next == nil {
+		return []string{name}
+	}
+	return append([]string{name}, c.chain(ctx, kc, namespace, *next, depth-1)...)
+}
+
+func ownerName(ref metav1.OwnerReference) string {
+	return ref.Kind + "/" + ref.Name
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// hasNamePatterns reports whether any of the object names in the resource
+// arguments contain wildcards.
+func hasNamePatterns(args []string) bool {
+	for _, set := range splitWatchArgs(args) {
+		names := set[1:]
+		if _, name, ok := strings.Cut(set[0], "/"); ok {
+			names = []string{name}
+		}
+		for _, name := range names {
+			if strings.ContainsAny(name, "*?[") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectObjects returns the objects matching the arguments (where names can
+// be wildcard patterns), letting the user pick from them when there's more
+// than one and the command is running in a terminal.
+func selectObjects(configFlags *genericclioptions.ConfigFlags, args []string) ([]*resource.Info, error) {
+	var candidates []*resource.Info
+	if hasNamePatterns(args) {
+		for _, set := range splitWatchArgs(args) {
+			typ, patterns := set[0], set[1:]
+			if t, name, ok := strings.Cut(typ, "/"); ok {
+				typ, patterns = t, []string{name}
+			}
+			rb, err := newResourceBuilder(configFlags)
+			if err != nil {
+				return nil, err
+			}
+			infos, err := queryLatest(rb.ResourceTypeOrNameArgs(true, typ)).Infos()
+			if err != nil {
+				return nil, err
+			}
+			for _, info := range infos {
+				if matchesAny(patterns, info.Name) {
+					candidates = append(candidates, info)
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no objects match %q", strings.Join(args, " "))
+		}
+	} else {
+		rb, err := newResourceBuilder(configFlags)
+		if err != nil {
+			return nil, err
+		}
+		infos, err := queryLatest(rb.ResourceTypeOrNameArgs(true, args...).FilenameParam(false, filenameOpts)).Infos()
+		if err != nil {
+			return nil, err
+		}
+		candidates = infos
+	}
+
+	terminal := isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+	if interactiveFlag && !terminal {
+		return nil, errors.New("--interactive requires a terminal")
+	}
+	if len(candidates) < 2 || !terminal {
+		return candidates, nil
+	}
+	return pickObjects(os.Stdin, stderr, candidates)
+}
+
+// matchesAny reports whether the name matches any of the patterns. Names
+// without wildcards must match exactly.
+func matchesAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pickObjects lists the objects and prompts until the user selects some of
+// them by number, or narrows down the list by typing a fuzzy filter.
+func pickObjects(in io.Reader, out io.Writer, infos []*resource.Info) ([]*resource.Info, error) {
+	shown := infos
+	scanner := bufio.NewScanner(in)
+	for {
+		for i, info := range shown {
+			fmt.Fprintf(out, "%3d) %s %s\n", i+1, bold.Sprint(info.Mapping.GroupVersionKind.Kind), infoDisplayName(info))
+		}
+		fmt.Fprint(out, gray.Sprint("Select numbers (e.g. 1,3-5), type to filter, Enter for all listed, q to quit: "))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("no objects selected")
+		}
+		input := strings.TrimSpace(scanner.Text())
+		switch {
+		case input == "":
+			return shown, nil
+		case input == "q":
+			return nil, errors.New("no objects selected")
+		}
+		if selected, ok := parseSelection(input, shown); ok {
+			return selected, nil
+		}
+		var filtered []*resource.Info
+		for _, info := range infos {
+			if fuzzyMatch(input, info.Mapping.GroupVersionKind.Kind+" "+infoDisplayName(info)) {
+				filtered = append(filtered, info)
+			}
+		}
+		if len(filtered) == 0 {
+			fmt.Fprintf(out, "No objects match %q.\n", input)
+			continue
+		}
+		shown = filtered
+	}
+}
+
+func infoDisplayName(info *resource.Info) string {
+	if info.Namespace != "" {
+		return info.Namespace + "/" + info.Name
+	}
+	return info.Name
+}
+
+// parseSelection parses a comma-separated list of numbers and ranges of the
+// listed objects.
+func parseSelection(input string, shown []*resource.Info) ([]*resource.Info, bool) {
+	var out []*resource.Info
+	for _, part := range strings.Split(input, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, false
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil {
+				return nil, false
+			}
+		}
+		if from < 1 || to > len(shown) || from > to {
+			return nil, false
+		}
+		out = append(out, shown[from-1:to]...)
+	}
+	return out, true
+}
+
+// fuzzyMatch reports whether the characters of the query appear in s in
+// order, ignoring case.
+func fuzzyMatch(query, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// conditionGroup is the set of objects that report a particular condition
+// type, split by whether the condition is healthy on the object.
+type conditionGroup struct {
+	Type      string
+	Unhealthy []string
+	Healthy   []string
+
+	// ByStatus lists the objects by the status of the condition, with a
+	// sample condition of each status for coloring.
+	ByStatus map[metav1.ConditionStatus][]string
+	samples  map[metav1.ConditionStatus]GenericCondition
+}
+
+// groupByCondition pivots the reports so that each condition type lists the
+// objects reporting it. Groups with the most unhealthy objects come first.
+func groupByCondition(reports []*objectReport) []*conditionGroup {
+	kinds := make(map[string]bool)
+	for _, r := range reports {
+		kinds[r.Kind] = true
+	}
+
+	groups := make(map[string]*conditionGroup)
+	for _, r := range reports {
+		name := r.displayName()
+		if len(kinds) > 1 {
+			// disambiguate objects of different kinds with the same name
+			name = strings.ToLower(r.Kind) + "/" + name
+		}
+		for _, cond := range r.Conditions {
+			g, ok := groups[cond.Type]
+			if !ok {
+				g = &conditionGroup{Type: cond.Type, ByStatus: make(map[metav1.ConditionStatus][]string),
+					samples: make(map[metav1.ConditionStatus]GenericCondition)}
+				groups[cond.Type] = g
+			}
+			g.ByStatus[cond.Status] = append(g.ByStatus[cond.Status], name)
+			if _, ok := g.samples[cond.Status]; !ok {
+				g.samples[cond.Status] = cond
+			}
+			if isHealthy(cond) {
+				g.Healthy = append(g.Healthy, name)
+			} else {
+				g.Unhealthy = append(g.Unhealthy, name)
+			}
+		}
+	}
+
+	out := make([]*conditionGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Unhealthy) != len(out[j].Unhealthy) {
+			return len(out[i].Unhealthy) > len(out[j].Unhealthy)
+		}
+		return out[i].Type < out[j].Type
+	})
+	return out
+}
+
+// byConditionPrinter buffers all reports and prints them grouped by
+// condition type.
+type byConditionPrinter struct {
+	w       io.Writer
+	reports []*objectReport
+}
+
+func (p *byConditionPrinter) Print(r *objectReport) error {
+	p.reports = append(p.reports, r)
+	return nil
+}
+
+func (p *byConditionPrinter) Flush() error {
+	printByCondition(p.w, p.reports)
+	return nil
+}
+
+// maxHealthyNames is the number of objects listed for the healthy statuses
+// of a condition type before the rest are only counted.
+const maxHealthyNames = 5
+
+func printByCondition(w io.Writer, reports []*objectReport) {
+	for _, g := range groupByCondition(reports) {
+		total := len(g.Unhealthy) + len(g.Healthy)
+		if len(g.Unhealthy) == 0 {
+			fmt.Fprintf(w, "%s %s\n", bold.Sprint(g.Type), gray.Sprintf("(none unhealthy, %d healthy)", total))
+		} else {
+			fmt.Fprintf(w, "%s %s\n", bold.Sprint(g.Type), gray.Sprintf("(%d/%d unhealthy)", len(g.Unhealthy), total))
+		}
+		statuses := make([]metav1.ConditionStatus, 0, len(g.ByStatus))
+		for status := range g.ByStatus {
+			statuses = append(statuses, status)
+		}
+		// unhealthy statuses first, like the conditions of an object
+		sort.Slice(statuses, func(i, j int) bool {
+			return bySeverity(g.samples[statuses[i]], g.samples[statuses[j]])
+		})
+		for _, status := range statuses {
+			names := g.ByStatus[status]
+			sample := g.samples[status]
+			list := strings.Join(names, ", ")
+			if isHealthy(sample) && len(names) > maxHealthyNames {
+				list = strings.Join(names[:maxHealthyNames], ", ") + gray.Sprintf(" … and %d more", len(names)-maxHealthyNames)
+			}
+			fmt.Fprintf(w, "  %s %s\n", statusColor(sample)(fmt.Sprintf("%-8s", fmt.Sprintf("%s:", status))), list)
+		}
+	}
+}
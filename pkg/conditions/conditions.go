@@ -0,0 +1,124 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conditions extracts the status conditions of Kubernetes objects
+// into a common model, and interprets them: whether a condition is healthy
+// (taking condition types with negative polarity, such as MemoryPressure,
+// into account), whether it's stale, in which order to list them, and
+// whether they follow the contract of metav1.Condition.
+//
+// It's the library behind kubectl-cond, and can be used to evaluate the
+// conditions of arbitrary (including custom) resources the same way:
+//
+//	conds, _, err := conditions.Extract(obj)
+//	if err != nil {
+//		return err
+//	}
+//	conditions.ResolvePolarity(conds, conditions.NewPolarityRules(), obj.GroupVersionKind().GroupKind())
+//	conditions.Sort(conds, conditions.BySeverity(metav1.ConditionUnknown), false)
+//	for _, c := range conds {
+//		fmt.Println(c.Type, conditions.Health(c, metav1.ConditionUnknown))
+//	}
+package conditions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Condition is the common form of the status conditions of all kinds of
+// objects, such as metav1.Condition, corev1.NodeCondition or the conditions
+// of custom resources, along with what's derived about them.
+type Condition struct {
+	Type               string                 `json:"type"`
+	Status             metav1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+	LastUpdateTime     *metav1.Time           `json:"lastUpdateTime,omitempty"`
+	LastTransitionTime *metav1.Time           `json:"lastTransitionTime,omitempty"`
+	LastHeartbeatTime  *metav1.Time           `json:"lastHeartbeatTime,omitempty"`
+	ObservedGeneration int64                  `json:"observedGeneration,omitempty"`
+
+	// Notes are additional context lines about the condition derived by
+	// kubectl-cond rather than reported by the object.
+	Notes []string `json:"notes,omitempty"`
+	// Synthetic is set for conditions derived by kubectl-cond from other
+	// fields of the object.
+	Synthetic bool `json:"synthetic,omitempty"`
+	// Warning is set when an unhealthy condition reflects an operational
+	// state that needs attention rather than a failure.
+	Warning bool `json:"warning,omitempty"`
+	// Stale is set when the condition was last updated for an older
+	// generation of the object than its current one.
+	Stale bool `json:"stale,omitempty"`
+
+	// NegativePolarity is set when True status of the condition means
+	// unhealthy, resolved for the kind of the object it belongs to (see
+	// ResolvePolarity).
+	NegativePolarity bool `json:"-"`
+}
+
+// Extract returns the conditions in status.conditions of the object, and
+// whether the object has that field at all. Conditions are marked as stale
+// if they describe an older generation of the object.
+func Extract(obj *unstructured.Unstructured) ([]Condition, bool, error) {
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to extract conditions from object: %w", err)
+	}
+
+	out := make([]Condition, 0, len(conditions))
+	for i, c := range conditions {
+		condMap, ok := c.(map[string]any)
+		if !ok {
+			return nil, false, fmt.Errorf("failed to convert condition#%d to map (type: %T)", i, c)
+		}
+		// convert untyped map to Condition
+		b, err := json.Marshal(condMap)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to marshal condition#%d: %w", i, err)
+		}
+		var c Condition
+		if err := json.Unmarshal(b, &c); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal condition#%d: %w", i, err)
+		}
+		out = append(out, c)
+	}
+
+	observedGeneration, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	MarkStale(out, obj.GetGeneration(), observedGeneration)
+	return out, found, nil
+}
+
+// MarkStale flags the conditions whose observedGeneration is behind the
+// generation of the object, as they describe a previous version of its spec
+// and may no longer be accurate. Conditions that don't report their own
+// observedGeneration are compared using the one of the whole status.
+func MarkStale(conditions []Condition, generation, statusObservedGeneration int64) {
+	for i := range conditions {
+		c := &conditions[i]
+		observed := c.ObservedGeneration
+		if observed == 0 {
+			observed = statusObservedGeneration
+		}
+		if observed == 0 || observed >= generation {
+			continue
+		}
+		c.Stale = true
+		c.Notes = append(c.Notes, fmt.Sprintf("Reflects generation %d, object is at %d", observed, generation))
+	}
+}
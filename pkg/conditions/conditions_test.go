@@ -0,0 +1,107 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    any
+		want      []string
+		wantFound bool
+		wantErr   bool
+	}{
+		{name: "no status", status: nil},
+		{name: "no conditions", status: map[string]any{"phase": "Running"}},
+		{name: "empty conditions", status: map[string]any{"conditions": []any{}}, wantFound: true},
+		{name: "conditions not a list", status: map[string]any{"conditions": "Ready"}, wantErr: true},
+		{name: "condition not an object", status: map[string]any{"conditions": []any{"Ready"}}, wantErr: true},
+		{name: "status not a string", status: map[string]any{"conditions": []any{
+			map[string]any{"type": "Ready", "status": int64(1)},
+		}}, wantErr: true},
+		{name: "malformed time", status: map[string]any{"conditions": []any{
+			map[string]any{"type": "Ready", "status": "True", "lastTransitionTime": "yesterday"},
+		}}, wantErr: true},
+		{name: "missing fields", status: map[string]any{"conditions": []any{
+			map[string]any{"type": "Ready"},
+			map[string]any{},
+		}}, want: []string{"Ready/", "/"}, wantFound: true},
+		{name: "unknown fields", status: map[string]any{"conditions": []any{
+			map[string]any{"type": "Ready", "status": "True", "severity": "Info"},
+		}}, want: []string{"Ready/True"}, wantFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]any{}}
+			if tt.status != nil {
+				obj.Object["status"] = tt.status
+			}
+			conds, found, err := Extract(obj)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Extract() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if found != tt.wantFound {
+				t.Errorf("Extract() found = %v, want %v", found, tt.wantFound)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for _, c := range conds {
+				got = append(got, c.Type+"/"+string(c.Status))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Extract() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Extract()[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractMarksStale(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"generation": int64(3)},
+		"status": map[string]any{
+			"observedGeneration": int64(2),
+			"conditions": []any{
+				map[string]any{"type": "Ready", "status": "True"},
+				map[string]any{"type": "Available", "status": "True", "observedGeneration": int64(3)},
+				map[string]any{"type": "Progressing", "status": "True", "observedGeneration": int64(1)},
+			},
+		},
+	}}
+	conds, _, err := Extract(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"Ready": true, "Available": false, "Progressing": true}
+	for _, c := range conds {
+		if c.Stale != want[c.Type] {
+			t.Errorf("%s: Stale = %v, want %v", c.Type, c.Stale, want[c.Type])
+		}
+		if c.Stale && len(c.Notes) == 0 {
+			t.Errorf("%s: stale condition has no note", c.Type)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InvertPolarity returns the status of the condition with True meaning
+// healthy, i.e. inverted for conditions with negative polarity.
+func InvertPolarity(cond Condition) metav1.ConditionStatus {
+	status := cond.Status
+	if status == metav1.ConditionUnknown || !cond.NegativePolarity {
+		return status
+	}
+
+	if status == metav1.ConditionTrue {
+		return metav1.ConditionFalse
+	} else {
+		return metav1.ConditionTrue
+	}
+}
+
+// SemanticStatus returns the status of the condition after taking its
+// polarity into account, so that True always means healthy and False means
+// unhealthy. Unknown status is mapped to unknownAs, which can be Unknown to
+// keep it neutral.
+func SemanticStatus(cond Condition, unknownAs metav1.ConditionStatus) metav1.ConditionStatus {
+	status := InvertPolarity(cond)
+	if status == metav1.ConditionUnknown {
+		return unknownAs
+	}
+	return status
+}
+
+// IsHealthy reports whether the condition is in a good state after taking
+// its polarity into account, with Unknown status mapped to unknownAs.
+func IsHealthy(cond Condition, unknownAs metav1.ConditionStatus) bool {
+	return SemanticStatus(cond, unknownAs) == metav1.ConditionTrue
+}
+
+// Health describes the health of the condition in words: one of "healthy",
+// "unhealthy", "warning" (unhealthy but not a failure) or "unknown".
+func Health(cond Condition, unknownAs metav1.ConditionStatus) string {
+	switch {
+	case SemanticStatus(cond, unknownAs) == metav1.ConditionUnknown:
+		return "unknown"
+	case IsHealthy(cond, unknownAs):
+		return "healthy"
+	case cond.Warning:
+		return "warning"
+	default:
+		return "unhealthy"
+	}
+}
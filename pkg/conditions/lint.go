@@ -0,0 +1,121 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Violation is a way a condition of an object doesn't follow the contract
+// of metav1.Condition.
+type Violation struct {
+	// Index is the position of the condition in status.conditions.
+	Index int `json:"index"`
+	// Type is the type of the condition, if it has one.
+	Type string `json:"type,omitempty"`
+	// Field is the path of the offending field within the condition, or
+	// empty if the violation is about the whole condition.
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + ": " + v.Message
+}
+
+// metav1ConditionFields are the fields of metav1.Condition.
+var metav1ConditionFields = map[string]bool{
+	"type": true, "status": true, "observedGeneration": true, "lastTransitionTime": true, "reason": true, "message": true,
+}
+
+// Lint validates the conditions in status.conditions of the object against
+// the contract of metav1.Condition, the same way the API server validates
+// them for built-in types that use it: the type, status, lastTransitionTime
+// and reason are required and must be well-formed, and types must be unique.
+// Fields that aren't part of metav1.Condition are reported as well, and so
+// are observed generations ahead of the generation of the object.
+func Lint(obj *unstructured.Unstructured) ([]Violation, error) {
+	raw, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract conditions from object: %w", err)
+	}
+
+	var out []Violation
+	conds := make([]metav1.Condition, len(raw))
+	// indexes are the positions of the conditions that could be parsed, which
+	// are the only ones validated further
+	var indexes []int
+	for i, c := range raw {
+		condMap, ok := c.(map[string]any)
+		if !ok {
+			out = append(out, Violation{Index: i, Message: fmt.Sprintf("must be an object, got %T", c)})
+			continue
+		}
+		typ, _ := condMap["type"].(string)
+		var unknown []string
+		for k := range condMap {
+			if !metav1ConditionFields[k] {
+				unknown = append(unknown, k)
+			}
+		}
+		sort.Strings(unknown)
+		for _, k := range unknown {
+			out = append(out, Violation{Index: i, Type: typ, Field: k, Message: "not a field of metav1.Condition"})
+		}
+		b, err := json.Marshal(condMap)
+		if err == nil {
+			err = json.Unmarshal(b, &conds[i])
+		}
+		if err != nil {
+			out = append(out, Violation{Index: i, Type: typ, Message: err.Error()})
+			continue
+		}
+		indexes = append(indexes, i)
+		if gen := obj.GetGeneration(); gen > 0 && conds[i].ObservedGeneration > gen {
+			out = append(out, Violation{Index: i, Type: typ, Field: "observedGeneration",
+				Message: fmt.Sprintf("%d is ahead of metadata.generation %d", conds[i].ObservedGeneration, gen)})
+		}
+	}
+
+	parsed := make([]metav1.Condition, len(indexes))
+	for j, i := range indexes {
+		parsed[j] = conds[i]
+	}
+	fldPath := field.NewPath("status", "conditions")
+	for _, e := range metav1validation.ValidateConditions(parsed, fldPath) {
+		v := Violation{Message: strings.TrimPrefix(e.Error(), e.Field+": ")}
+		// the field path is status.conditions[i].field
+		index, rest, _ := strings.Cut(strings.TrimPrefix(e.Field, fldPath.String()+"["), "]")
+		if j, err := strconv.Atoi(index); err == nil && j < len(indexes) {
+			i := indexes[j]
+			v.Index, v.Type, v.Field = i, conds[i].Type, strings.TrimPrefix(rest, ".")
+		}
+		out = append(out, v)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Index < out[j].Index })
+	return out, nil
+}
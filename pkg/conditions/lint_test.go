@@ -0,0 +1,88 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestLint(t *testing.T) {
+	valid := func(typ string) map[string]any {
+		return map[string]any{"type": typ, "status": "True", "reason": "AsExpected", "lastTransitionTime": "2024-01-01T00:00:00Z"}
+	}
+	with := func(c map[string]any, kv ...any) map[string]any {
+		for i := 0; i < len(kv); i += 2 {
+			if kv[i+1] == nil {
+				delete(c, kv[i].(string))
+			} else {
+				c[kv[i].(string)] = kv[i+1]
+			}
+		}
+		return c
+	}
+	tests := []struct {
+		name  string
+		conds []any
+		// want are the index/field pairs of the expected violations
+		want []string
+	}{
+		{name: "valid", conds: []any{valid("Ready"), valid("Available")}},
+		{name: "not an object", conds: []any{"Ready"}, want: []string{"0/"}},
+		{name: "missing type", conds: []any{with(valid(""), "type", nil)}, want: []string{"0/type", "0/type"}},
+		{name: "invalid type", conds: []any{valid("not a type!")}, want: []string{"0/type"}},
+		{name: "invalid status", conds: []any{with(valid("Ready"), "status", "Yes")}, want: []string{"0/status"}},
+		{name: "missing reason", conds: []any{with(valid("Ready"), "reason", nil)}, want: []string{"0/reason"}},
+		{name: "invalid reason", conds: []any{with(valid("Ready"), "reason", "as expected")}, want: []string{"0/reason"}},
+		{name: "missing lastTransitionTime", conds: []any{with(valid("Ready"), "lastTransitionTime", nil)}, want: []string{"0/lastTransitionTime"}},
+		{name: "malformed lastTransitionTime", conds: []any{with(valid("Ready"), "lastTransitionTime", "yesterday")}, want: []string{"0/"}},
+		{name: "unknown field", conds: []any{with(valid("Ready"), "severity", "Info")}, want: []string{"0/severity"}},
+		{name: "duplicate type", conds: []any{valid("Ready"), valid("Ready")}, want: []string{"1/type"}},
+		{name: "observedGeneration ahead", conds: []any{with(valid("Ready"), "observedGeneration", int64(5))}, want: []string{"0/observedGeneration"}},
+		{name: "sorted by index", conds: []any{valid("Ready"), with(valid("Available"), "status", "Yes"), with(valid("Synced"), "severity", "Info")},
+			want: []string{"1/status", "2/severity"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]any{
+				"metadata": map[string]any{"generation": int64(2)},
+				"status":   map[string]any{"conditions": tt.conds},
+			}}
+			violations, err := Lint(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range violations {
+				got = append(got, fmt.Sprintf("%d/%s", v.Index, v.Field))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Lint() = %v (%v), want %v", got, violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintMalformedConditions(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"status": map[string]any{"conditions": "Ready"},
+	}}
+	if _, err := Lint(obj); err == nil {
+		t.Error("Lint() succeeded, want error")
+	}
+}
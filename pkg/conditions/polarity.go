@@ -0,0 +1,121 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// WellKnownNegativeTypes are the condition types whose True status is
+// unhealthy regardless of the kind of the object.
+var WellKnownNegativeTypes = sets.New(
+	// kubernetes builtin Node conditions
+	"MemoryPressure",
+	"DiskPressure",
+	"NetworkUnavailable",
+	"PIDPressure",
+
+	// node-problem-detector Node conditions
+	"ReadonlyFilesystem",
+	"KernelDeadlock",
+	"FrequentKubeletRestart",
+	"FrequentDockerRestart",
+	"FrequentContainerdRestart",
+	"KubeletUnhealthy",
+	"ContainerRuntimeUnhealthy",
+
+	// synthetic conditions of kubectl-cond
+	"SchedulingDisabled",
+	"Terminating",
+	"Misscheduled",
+)
+
+// PolarityRules maps condition types to whether they have negative
+// polarity, i.e. True means unhealthy, on top of WellKnownNegativeTypes.
+type PolarityRules struct {
+	types  map[string]bool
+	scoped map[schema.GroupKind]map[string]bool
+}
+
+// NewPolarityRules returns rules that only consist of the well-known types.
+func NewPolarityRules() *PolarityRules {
+	return &PolarityRules{
+		types:  make(map[string]bool),
+		scoped: make(map[schema.GroupKind]map[string]bool),
+	}
+}
+
+// Add parses a polarity rule in the form of [-][KIND[.GROUP]/]TYPE, e.g.
+// "Stalled" or "Kustomization.kustomize.toolkit.fluxcd.io/Reconciling". A
+// leading "-" marks the type as having positive polarity, which overrides
+// the well-known types.
+func (p *PolarityRules) Add(rule string) error {
+	s := strings.TrimSpace(rule)
+	negative := !strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	gkStr, typ, scoped := strings.Cut(s, "/")
+	if !scoped {
+		typ = gkStr
+	}
+	if typ == "" || (scoped && gkStr == "") || strings.Contains(typ, "/") {
+		return fmt.Errorf("invalid polarity rule %q, expected [-][KIND[.GROUP]/]TYPE (e.g. Stalled or Kustomization.kustomize.toolkit.fluxcd.io/Reconciling)", rule)
+	}
+	if !scoped {
+		p.types[typ] = negative
+		return nil
+	}
+	gk := schema.ParseGroupKind(gkStr)
+	if p.scoped[gk] == nil {
+		p.scoped[gk] = make(map[string]bool)
+	}
+	p.scoped[gk][typ] = negative
+	return nil
+}
+
+// Lookup reports whether True status of the condition type means unhealthy
+// for objects of the GroupKind, and whether the polarity of the type is
+// known at all. Rules scoped to the GroupKind take precedence over the rules
+// for all kinds, which take precedence over the well-known types.
+func (p *PolarityRules) Lookup(gk schema.GroupKind, condType string) (negative, ok bool) {
+	if negative, ok := p.scoped[gk][condType]; ok {
+		return negative, true
+	}
+	if negative, ok := p.types[condType]; ok {
+		return negative, true
+	}
+	if WellKnownNegativeTypes.Has(condType) {
+		return true, true
+	}
+	return false, false
+}
+
+// IsNegative reports whether True status of the condition type means
+// unhealthy for objects of the GroupKind.
+func (p *PolarityRules) IsNegative(gk schema.GroupKind, condType string) bool {
+	negative, _ := p.Lookup(gk, condType)
+	return negative
+}
+
+// ResolvePolarity sets NegativePolarity of the conditions of an object of
+// the GroupKind according to the rules.
+func ResolvePolarity(conditions []Condition, rules *PolarityRules, gk schema.GroupKind) {
+	for i := range conditions {
+		conditions[i].NegativePolarity = rules.IsNegative(gk, conditions[i].Type)
+	}
+}
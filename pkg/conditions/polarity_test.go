@@ -0,0 +1,78 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPolarityRulesLookup(t *testing.T) {
+	kustomization := schema.GroupKind{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"}
+	helmRelease := schema.GroupKind{Group: "helm.toolkit.fluxcd.io", Kind: "HelmRelease"}
+	node := schema.GroupKind{Kind: "Node"}
+	tests := []struct {
+		name         string
+		rules        []string
+		gk           schema.GroupKind
+		condType     string
+		wantNegative bool
+		wantOK       bool
+	}{
+		{name: "unknown type", gk: node, condType: "Ready"},
+		{name: "well-known type", gk: node, condType: "MemoryPressure", wantNegative: true, wantOK: true},
+		{name: "rule for all kinds", rules: []string{"Stalled"}, gk: kustomization, condType: "Stalled", wantNegative: true, wantOK: true},
+		{name: "positive rule overrides well-known", rules: []string{"-MemoryPressure"}, gk: node, condType: "MemoryPressure", wantOK: true},
+		{name: "scoped rule", rules: []string{"Kustomization.kustomize.toolkit.fluxcd.io/Reconciling"}, gk: kustomization, condType: "Reconciling", wantNegative: true, wantOK: true},
+		{name: "scoped rule of another kind", rules: []string{"Kustomization.kustomize.toolkit.fluxcd.io/Reconciling"}, gk: helmRelease, condType: "Reconciling"},
+		{name: "scoped rule overrides rule for all kinds", rules: []string{"Reconciling", "-Kustomization.kustomize.toolkit.fluxcd.io/Reconciling"}, gk: kustomization, condType: "Reconciling", wantOK: true},
+		{name: "scoped rule overrides well-known", rules: []string{"-Node/DiskPressure"}, gk: node, condType: "DiskPressure", wantOK: true},
+		// the rules of the config file are added before the ones of
+		// --negative-polarity, which override them
+		{name: "later rule overrides earlier", rules: []string{"Stalled", "-Stalled"}, gk: kustomization, condType: "Stalled", wantOK: true},
+		{name: "later scoped rule overrides earlier", rules: []string{"-Node/Ready", "Node/Ready"}, gk: node, condType: "Ready", wantNegative: true, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPolarityRules()
+			for _, r := range tt.rules {
+				if err := p.Add(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			negative, ok := p.Lookup(tt.gk, tt.condType)
+			if negative != tt.wantNegative || ok != tt.wantOK {
+				t.Errorf("Lookup(%s, %s) = (%v, %v), want (%v, %v)", tt.gk, tt.condType, negative, ok, tt.wantNegative, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPolarityRulesAddInvalid(t *testing.T) {
+	for _, rule := range []string{"", "-", "Node/", "/Ready", "a/b/c"} {
+		if err := NewPolarityRules().Add(rule); err == nil {
+			t.Errorf("Add(%q) succeeded, want error", rule)
+		}
+	}
+}
+
+func TestResolvePolarity(t *testing.T) {
+	conds := []Condition{{Type: "Ready"}, {Type: "DiskPressure"}}
+	ResolvePolarity(conds, NewPolarityRules(), schema.GroupKind{Kind: "Node"})
+	if conds[0].NegativePolarity || !conds[1].NegativePolarity {
+		t.Errorf("ResolvePolarity() = %+v", conds)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// Order reports whether condition i should be listed before j.
+type Order func(i, j Condition) bool
+
+// Sort sorts the conditions in the order, or in the reverse order.
+func Sort(conditions []Condition, less Order, reverse bool) {
+	sort.SliceStable(conditions, func(i, j int) bool {
+		if reverse {
+			return less(conditions[j], conditions[i])
+		}
+		return less(conditions[i], conditions[j])
+	})
+}
+
+// BySeverity lists the conditions that summarize the state of the object
+// (such as Ready) first, then the unhealthy conditions, then the recently
+// changed ones. Unknown status is mapped to unknownAs, see SemanticStatus.
+func BySeverity(unknownAs metav1.ConditionStatus) Order {
+	return func(i, j Condition) bool {
+		// Rule 1: prioritize specific types
+		typePriority := map[string]int{
+			"Terminating": -3, // synthetic
+			"Ready":       -2,
+			"Succeeded":   -1, // e.g. Job
+		}
+		priI := typePriority[i.Type]
+		priJ := typePriority[j.Type]
+		if priI != priJ {
+			return priI < priJ
+		}
+
+		// Rule 2: status=False first, then Unknown, then True
+		statusOrder := map[metav1.ConditionStatus]int{
+			metav1.ConditionFalse:   0, // assumption: False means bad things
+			metav1.ConditionUnknown: 1, // assumption: Unknown means potentially bad things
+			metav1.ConditionTrue:    2, // assumption: True means good things
+		}
+
+		// calculate the semantic status of the condition
+		iStatus := SemanticStatus(i, unknownAs)
+		jStatus := SemanticStatus(j, unknownAs)
+		if iStatus != jStatus {
+			return statusOrder[iStatus] < statusOrder[jStatus]
+		}
+
+		// Rule 3: Sort by the last time it got changed in descending order
+		timeI := ptr.Deref(i.LastUpdateTime, ptr.Deref(i.LastTransitionTime, metav1.Time{})).Time
+		timeJ := ptr.Deref(j.LastUpdateTime, ptr.Deref(j.LastTransitionTime, metav1.Time{})).Time
+		return timeI.After(timeJ)
+	}
+}
+
+// ByType lists the conditions in the alphabetical order of their types.
+func ByType(i, j Condition) bool {
+	return i.Type < j.Type
+}
+
+// ByLastTransitionTime lists the oldest transitions first, and the
+// conditions without a transition time last.
+func ByLastTransitionTime(i, j Condition) bool {
+	if i.LastTransitionTime == nil || j.LastTransitionTime == nil {
+		return i.LastTransitionTime != nil && j.LastTransitionTime == nil
+	}
+	if !i.LastTransitionTime.Equal(j.LastTransitionTime) {
+		return i.LastTransitionTime.Before(j.LastTransitionTime)
+	}
+	return i.Type < j.Type
+}
+
+// ByStatus groups the conditions by their status as reported (regardless of
+// their polarity) in the order of False, Unknown, True.
+func ByStatus(i, j Condition) bool {
+	order := map[metav1.ConditionStatus]int{
+		metav1.ConditionFalse:   0,
+		metav1.ConditionUnknown: 1,
+		metav1.ConditionTrue:    2,
+	}
+	if i.Status != j.Status {
+		return order[i.Status] < order[j.Status]
+	}
+	return i.Type < j.Type
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSort(t *testing.T) {
+	at := func(min int) *metav1.Time {
+		t := metav1.NewTime(time.Date(2024, 1, 1, 0, min, 0, 0, time.UTC))
+		return &t
+	}
+	conds := func() []Condition {
+		return []Condition{
+			{Type: "Available", Status: metav1.ConditionTrue, LastTransitionTime: at(1)},
+			{Type: "MemoryPressure", Status: metav1.ConditionTrue, LastTransitionTime: at(4), NegativePolarity: true},
+			{Type: "Progressing", Status: metav1.ConditionUnknown},
+			{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: at(2)},
+			{Type: "Degraded", Status: metav1.ConditionFalse, LastTransitionTime: at(3), NegativePolarity: true},
+			{Type: "Synced", Status: metav1.ConditionFalse, LastTransitionTime: at(5)},
+		}
+	}
+	tests := []struct {
+		name    string
+		less    Order
+		reverse bool
+		want    string
+	}{
+		{name: "severity", less: BySeverity(metav1.ConditionUnknown),
+			want: "Ready Synced MemoryPressure Progressing Degraded Available"},
+		{name: "severity with unknown as healthy", less: BySeverity(metav1.ConditionTrue),
+			want: "Ready Synced MemoryPressure Degraded Available Progressing"},
+		{name: "severity reversed", less: BySeverity(metav1.ConditionUnknown), reverse: true,
+			want: "Available Degraded Progressing MemoryPressure Synced Ready"},
+		{name: "type", less: ByType,
+			want: "Available Degraded MemoryPressure Progressing Ready Synced"},
+		{name: "type reversed", less: ByType, reverse: true,
+			want: "Synced Ready Progressing MemoryPressure Degraded Available"},
+		{name: "last transition time", less: ByLastTransitionTime,
+			want: "Available Ready Degraded MemoryPressure Synced Progressing"},
+		{name: "last transition time reversed", less: ByLastTransitionTime, reverse: true,
+			want: "Progressing Synced MemoryPressure Degraded Ready Available"},
+		{name: "status", less: ByStatus,
+			want: "Degraded Synced Progressing Available MemoryPressure Ready"},
+		{name: "status reversed", less: ByStatus, reverse: true,
+			want: "Ready MemoryPressure Available Progressing Synced Degraded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := conds()
+			Sort(c, tt.less, tt.reverse)
+			var got []string
+			for _, cond := range c {
+				got = append(got, cond.Type)
+			}
+			if s := strings.Join(got, " "); s != tt.want {
+				t.Errorf("Sort() = %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortBySeverityPrioritizesSummaryTypes(t *testing.T) {
+	c := []Condition{
+		{Type: "Failed", Status: metav1.ConditionFalse},
+		{Type: "Succeeded", Status: metav1.ConditionTrue},
+		{Type: "Ready", Status: metav1.ConditionTrue},
+		{Type: "Terminating", Status: metav1.ConditionTrue, NegativePolarity: true},
+	}
+	Sort(c, BySeverity(metav1.ConditionUnknown), false)
+	var got []string
+	for _, cond := range c {
+		got = append(got, cond.Type)
+	}
+	if s, want := strings.Join(got, " "), "Terminating Ready Succeeded Failed"; s != want {
+		t.Errorf("Sort() = %s, want %s", s, want)
+	}
+}
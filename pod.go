@@ -0,0 +1,284 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Prefixes of the types of the synthetic conditions describing the state of
+// each container of a pod, which are listed after the pod's own conditions.
+const (
+	containerConditionPrefix     = "Container "
+	initContainerConditionPrefix = "Init container "
+)
+
+func enrichPod(r *objectReport) {
+	if line := podRestartSummary(r.Object, time.Now()); line != "" {
+		r.Header = append(r.Header, line)
+	}
+	r.Conditions = append(r.Conditions, containerConditions(r.Object)...)
+	markReadinessGates(r)
+}
+
+// markReadinessGates marks the conditions that are readiness gates of the
+// pod, and adds the ones that aren't reported yet, since a pod waiting for
+// a readiness gate (e.g. set by a load balancer controller) otherwise looks
+// like it's missing an ordinary condition.
+func markReadinessGates(r *objectReport) {
+	gates, _, _ := unstructured.NestedSlice(r.Object.Object, "spec", "readinessGates")
+	var unmet []string
+	for _, g := range gates {
+		gate, ok := g.(map[string]any)
+		if !ok {
+			continue
+		}
+		condType, _, _ := unstructured.NestedString(gate, "conditionType")
+		if condType == "" {
+			continue
+		}
+		i := slices.IndexFunc(r.Conditions, func(c GenericCondition) bool { return c.Type == condType })
+		if i < 0 {
+			unmet = append(unmet, condType)
+			r.Conditions = append(r.Conditions, GenericCondition{
+				Type:      condType,
+				Status:    metav1.ConditionFalse,
+				Reason:    "ReadinessGateUnmet",
+				Message:   "The condition of this readiness gate isn't reported yet, the pod isn't Ready until it's True.",
+				Synthetic: true,
+			})
+			continue
+		}
+		cond := &r.Conditions[i]
+		if cond.Status == metav1.ConditionTrue {
+			cond.Notes = append(cond.Notes, "Readiness gate met")
+		} else {
+			unmet = append(unmet, condType)
+			cond.Notes = append(cond.Notes, "Readiness gate unmet: the pod isn't Ready until this condition is True")
+		}
+	}
+	if len(unmet) == 0 {
+		return
+	}
+	for i := range r.Conditions {
+		if r.Conditions[i].Type == "Ready" && r.Conditions[i].Status != metav1.ConditionTrue {
+			r.Conditions[i].Notes = append(r.Conditions[i].Notes, "Waiting for readiness gates: "+strings.Join(unmet, ", "))
+		}
+	}
+}
+
+// isContainerCondition reports whether the condition type is one of the
+// synthetic container conditions.
+func isContainerCondition(condType string) bool {
+	return strings.HasPrefix(condType, containerConditionPrefix) ||
+		strings.HasPrefix(condType, initContainerConditionPrefix)
+}
+
+// containerConditions returns a synthetic condition for each container of
+// the pod describing its state, as the pod's Ready condition doesn't tell
+// which container is failing and why.
+func containerConditions(pod *unstructured.Unstructured) []GenericCondition {
+	var out []GenericCondition
+	for _, field := range []struct {
+		name   string
+		prefix string
+		init   bool
+	}{
+		{"initContainerStatuses", initContainerConditionPrefix, true},
+		{"containerStatuses", containerConditionPrefix, false},
+	} {
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field.name)
+		for _, s := range statuses {
+			cs, ok := s.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(cs, "name")
+			cond := containerCondition(cs, field.init)
+			cond.Type = field.prefix + name
+			cond.Synthetic = true
+			out = append(out, cond)
+		}
+	}
+	return out
+}
+
+func containerCondition(cs map[string]any, init bool) GenericCondition {
+	var cond GenericCondition
+	ready, _, _ := unstructured.NestedBool(cs, "ready")
+	state, _, _ := unstructured.NestedMap(cs, "state")
+	if waiting, ok := state["waiting"].(map[string]any); ok {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason, _, _ = unstructured.NestedString(waiting, "reason")
+		cond.Message, _, _ = unstructured.NestedString(waiting, "message")
+		switch cond.Reason {
+		case "", "ContainerCreating", "PodInitializing":
+			// not a failure, the container is yet to be started
+			cond.Warning = true
+		}
+		if cond.Reason == "" {
+			cond.Reason = "Waiting"
+		}
+		if strings.Contains(cond.Reason, "Image") {
+			image, _, _ := unstructured.NestedString(cs, "image")
+			cond.Notes = append(cond.Notes, "Image: "+image)
+		}
+	} else if running, ok := state["running"].(map[string]any); ok {
+		cond.LastTransitionTime = parseContainerTime(running, "startedAt")
+		started, _, _ := unstructured.NestedBool(cs, "started")
+		switch {
+		case ready:
+			cond.Status, cond.Reason = metav1.ConditionTrue, "Running"
+		case init:
+			cond.Status, cond.Reason = metav1.ConditionFalse, "Initializing"
+			cond.Warning = true
+		case !started:
+			cond.Status, cond.Reason = metav1.ConditionFalse, "Starting"
+			cond.Message = "container is running but its startup probe hasn't succeeded yet"
+			cond.Warning = true
+		default:
+			cond.Status, cond.Reason = metav1.ConditionFalse, "NotReady"
+			cond.Message = "container is running but its readiness probe is failing"
+		}
+	} else if terminated, ok := state["terminated"].(map[string]any); ok {
+		cond.LastTransitionTime = parseContainerTime(terminated, "finishedAt")
+		exitCode, _, _ := unstructured.NestedInt64(terminated, "exitCode")
+		cond.Reason, _, _ = unstructured.NestedString(terminated, "reason")
+		cond.Message, _, _ = unstructured.NestedString(terminated, "message")
+		cond.Status = metav1.ConditionTrue
+		if exitCode != 0 {
+			cond.Status = metav1.ConditionFalse
+			cond.Notes = append(cond.Notes, fmt.Sprintf("Exit code: %d", exitCode))
+		}
+		if cond.Reason == "" {
+			cond.Reason = "Terminated"
+		}
+	} else {
+		cond.Status = metav1.ConditionUnknown
+	}
+
+	if restarts, _, _ := unstructured.NestedInt64(cs, "restartCount"); restarts > 0 {
+		note := fmt.Sprintf("Restarts: %d", restarts)
+		if last, ok, _ := unstructured.NestedMap(cs, "lastState", "terminated"); ok {
+			reason, _, _ := unstructured.NestedString(last, "reason")
+			exitCode, _, _ := unstructured.NestedInt64(last, "exitCode")
+			if reason == "" {
+				reason = "Terminated"
+			}
+			note += fmt.Sprintf(" (last: %s, exit code %d", reason, exitCode)
+			if t := parseContainerTime(last, "finishedAt"); t != nil {
+				note += ", " + humanize.RelTime(t.Time, time.Now(), "ago", "from now")
+			}
+			note += ")"
+		}
+		cond.Notes = append(cond.Notes, note)
+	}
+	return cond
+}
+
+func parseContainerTime(state map[string]any, field string) *metav1.Time {
+	s, _, _ := unstructured.NestedString(state, field)
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return &metav1.Time{Time: t}
+}
+
+// recentRestartWindow is how long ago a container must have last terminated
+// for its restart to be reported as recent.
+const recentRestartWindow = 10 * time.Minute
+
+// podRestartSummary returns a line describing the container restarts of the
+// pod: the total, the containers that restarted recently or are crash
+// looping (telling an ongoing crash loop from a past one), and the most
+// recent termination. It returns an empty string if no container has
+// restarted.
+func podRestartSummary(pod *unstructured.Unstructured, now time.Time) string {
+	var (
+		total         int64
+		recent        int
+		crashLooping  []string
+		lastFinished  time.Time
+		lastContainer string
+		lastReason    string
+		lastExitCode  int64
+	)
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+		for _, s := range statuses {
+			cs, ok := s.(map[string]any)
+			if !ok {
+				continue
+			}
+			restarts, _, _ := unstructured.NestedInt64(cs, "restartCount")
+			total += restarts
+			name, _, _ := unstructured.NestedString(cs, "name")
+			if waiting, _, _ := unstructured.NestedString(cs, "state", "waiting", "reason"); waiting == "CrashLoopBackOff" {
+				crashLooping = append(crashLooping, strconv.Quote(name))
+			}
+
+			finishedAt, _, _ := unstructured.NestedString(cs, "lastState", "terminated", "finishedAt")
+			t, err := time.Parse(time.RFC3339, finishedAt)
+			if err != nil {
+				continue
+			}
+			if restarts > 0 && now.Sub(t) <= recentRestartWindow {
+				recent++
+			}
+			if !t.After(lastFinished) {
+				continue
+			}
+			lastFinished = t
+			lastContainer = name
+			lastReason, _, _ = unstructured.NestedString(cs, "lastState", "terminated", "reason")
+			lastExitCode, _, _ = unstructured.NestedInt64(cs, "lastState", "terminated", "exitCode")
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+
+	line := fmt.Sprintf("%s %d", gray.Sprint("Restarts:"), total)
+	window := fmt.Sprintf("%d minutes", int(recentRestartWindow.Minutes()))
+	switch recent {
+	case 0:
+		line += ", none in the last " + window
+	case 1:
+		line += ", 1 container restarted in the last " + window
+	default:
+		line += fmt.Sprintf(", %d containers restarted in the last %s", recent, window)
+	}
+	if len(crashLooping) > 0 {
+		line += fmt.Sprintf(", %s in CrashLoopBackOff", strings.Join(crashLooping, ", "))
+	}
+	if !lastFinished.IsZero() {
+		if lastReason == "" {
+			lastReason = "Terminated"
+		}
+		line += fmt.Sprintf(" (last %s: container %q %s, exit code %d)",
+			humanize.RelTime(lastFinished, now, "ago", "from now"),
+			lastContainer, lastReason, lastExitCode)
+	}
+	return line
+}
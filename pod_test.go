@@ -0,0 +1,64 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPodRestartSummary(t *testing.T) {
+	defer func(v bool) { color.NoColor = v }(color.NoColor)
+	color.NoColor = true
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	container := func(name string, restarts int64, finishedAgo time.Duration, waiting string) map[string]any {
+		cs := map[string]any{"name": name, "restartCount": restarts}
+		if finishedAgo > 0 {
+			cs["lastState"] = map[string]any{"terminated": map[string]any{
+				"reason": "Error", "exitCode": int64(1), "finishedAt": now.Add(-finishedAgo).Format(time.RFC3339),
+			}}
+		}
+		if waiting != "" {
+			cs["state"] = map[string]any{"waiting": map[string]any{"reason": waiting}}
+		}
+		return cs
+	}
+	tests := []struct {
+		name       string
+		containers []any
+		want       string
+	}{
+		{name: "no restarts", containers: []any{container("api", 0, 0, "")}},
+		{name: "past crash loop", containers: []any{container("api", 12, 3*time.Hour, "")},
+			want: `Restarts: 12, none in the last 10 minutes (last 3 hours ago: container "api" Error, exit code 1)`},
+		{name: "ongoing crash loop", containers: []any{container("api", 12, 2*time.Minute, "CrashLoopBackOff"), container("sidecar", 0, 0, "")},
+			want: `Restarts: 12, 1 container restarted in the last 10 minutes, "api" in CrashLoopBackOff (last 2 minutes ago: container "api" Error, exit code 1)`},
+		{name: "several recent", containers: []any{container("api", 2, 5*time.Minute, ""), container("sidecar", 1, time.Minute, "")},
+			want: `Restarts: 3, 2 containers restarted in the last 10 minutes (last 1 minute ago: container "sidecar" Error, exit code 1)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &unstructured.Unstructured{Object: map[string]any{
+				"status": map[string]any{"containerStatuses": tt.containers},
+			}}
+			if got := podRestartSummary(pod, now); got != tt.want {
+				t.Errorf("podRestartSummary() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
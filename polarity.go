@@ -0,0 +1,51 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/ahmetb/kubectl-cond/pkg/conditions"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var negativePolarityFlag []string
+
+// polarity holds the condition types whose polarity is configured with the
+// config file or --negative-polarity, on top of the well-known ones.
+var polarity = conditions.NewPolarityRules()
+
+// isNegativePolarity reports whether True status of the condition type
+// means unhealthy for objects of the kind. Types that are neither configured
+// nor well-known (or declared by the enricher of the kind) can be inferred
+// from the schema of custom resources.
+func isNegativePolarity(kc *kubeClient, gvk schema.GroupVersionKind, condType string) bool {
+	if negative, ok := polarity.Lookup(gvk.GroupKind(), condType); ok {
+		return negative
+	}
+	if isEnricherNegativeType(gvk.GroupKind(), condType) {
+		return true
+	}
+	return inferPolarityFlag && kc != nil && kc.SchemaPolarity().isNegative(gvk, condType)
+}
+
+// setupPolarity applies the polarity rules from the config file followed by
+// the ones specified with --negative-polarity.
+func setupPolarity(cfg *config) error {
+	for _, rule := range append(cfg.NegativePolarity, negativePolarityFlag...) {
+		if err := polarity.Add(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
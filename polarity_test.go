@@ -0,0 +1,59 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/ahmetb/kubectl-cond/pkg/conditions"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsNegativePolarityPrecedence(t *testing.T) {
+	job := schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}
+	node := schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+	clusterVersion := schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "ClusterVersion"}
+	tests := []struct {
+		name     string
+		config   []string
+		flag     []string
+		gvk      schema.GroupVersionKind
+		condType string
+		want     bool
+	}{
+		{name: "well-known", gvk: node, condType: "DiskPressure", want: true},
+		{name: "enricher", gvk: job, condType: "Failed", want: true},
+		{name: "enricher type of another kind", gvk: node, condType: "Failed"},
+		{name: "ClusterVersion Failing", gvk: clusterVersion, condType: "Failing", want: true},
+		{name: "Failing of another kind", gvk: job, condType: "Failing"},
+		{name: "config overrides well-known", config: []string{"-DiskPressure"}, gvk: node, condType: "DiskPressure"},
+		{name: "config overrides enricher", config: []string{"-Job.batch/Failed"}, gvk: job, condType: "Failed"},
+		{name: "flag overrides enricher", flag: []string{"-Failed"}, gvk: job, condType: "Failed"},
+		{name: "flag overrides config", config: []string{"Complete"}, flag: []string{"-Complete"}, gvk: job, condType: "Complete"},
+		{name: "config applies without flag", config: []string{"Complete"}, flag: []string{"Suspended"}, gvk: job, condType: "Complete", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(p *conditions.PolarityRules, f []string) { polarity, negativePolarityFlag = p, f }(polarity, negativePolarityFlag)
+			polarity, negativePolarityFlag = conditions.NewPolarityRules(), tt.flag
+			if err := setupPolarity(&config{NegativePolarity: tt.config}); err != nil {
+				t.Fatal(err)
+			}
+			if got := isNegativePolarity(nil, tt.gvk, tt.condType); got != tt.want {
+				t.Errorf("isNegativePolarity(%s, %s) = %v, want %v", tt.gvk.Kind, tt.condType, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ahmetb/kubectl-cond/pkg/conditions"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+)
+
+// printer renders object reports. Printers that need to see all objects
+// before producing output (such as pivoted views) buffer the reports and
+// print them on Flush.
+type printer interface {
+	Print(r *objectReport) error
+	Flush() error
+}
+
+// newPrinter returns the printer selected by the output flags, which ends
+// its output with a summary of the health of the printed objects.
+func newPrinter(w io.Writer) (printer, error) {
+	if quietFlag {
+		return withConditionFilters(&quietPrinter{w: w}), nil
+	}
+	if p, ok, err := newTemplatePrinter(w, outputFlag); ok {
+		if err != nil {
+			return nil, err
+		}
+		return withConditionFilters(p), nil
+	}
+	// machine-readable formats carry the summary in the document
+	switch outputFlag {
+	case "json":
+		return withConditionFilters(&jsonPrinter{w: w}), nil
+	case "yaml":
+		return withConditionFilters(&yamlPrinter{jsonPrinter{w: w}}), nil
+	case "html", "markdown", "md":
+		// reports are meant to be shared, so they shouldn't contain
+		// escape codes even when printed to a terminal
+		color.NoColor = true
+		if outputFlag == "html" {
+			return withConditionFilters(withDetailFields(&htmlPrinter{w: w})), nil
+		}
+		return withConditionFilters(withDetailFields(&markdownPrinter{w: w})), nil
+	}
+	p, err := newFormatPrinter(w)
+	if err != nil {
+		return nil, err
+	}
+	// the summary only accounts for the shown conditions
+	return &summaryPrinter{printer: withConditionFilters(withDetailFields(p)), w: w}, nil
+}
+
+// withConditionFilters wraps the printer to hide the conditions excluded by
+// --problems-only, --fresh-only and --type.
+func withConditionFilters(p printer) printer {
+	if !hasConditionFilters() {
+		return p
+	}
+	return &conditionFilterPrinter{p}
+}
+
+func hasConditionFilters() bool {
+	return problemsOnlyFlag || freshOnlyFlag || len(typeFlag) > 0
+}
+
+// shownConditions returns the conditions that are not excluded by the
+// condition filters.
+func shownConditions(conds []GenericCondition) []GenericCondition {
+	var shown []GenericCondition
+	for _, cond := range conds {
+		if problemsOnlyFlag && isHealthy(cond) {
+			continue
+		}
+		if freshOnlyFlag && cond.Stale {
+			continue
+		}
+		if !matchesAny(typeFlag, cond.Type) {
+			continue
+		}
+		shown = append(shown, cond)
+	}
+	return shown
+}
+
+// conditionFilterPrinter hides the conditions excluded by the filter flags,
+// and the objects that don't have any other conditions.
+type conditionFilterPrinter struct {
+	printer
+}
+
+func (p *conditionFilterPrinter) Print(r *objectReport) error {
+	shown := shownConditions(r.Conditions)
+	if len(shown) == 0 {
+		return nil
+	}
+	filtered := *r
+	filtered.Conditions = shown
+	return p.printer.Print(&filtered)
+}
+
+func newFormatPrinter(w io.Writer) (printer, error) {
+	if plainFlag {
+		return &plainPrinter{w: w}, nil
+	}
+	if groupByFlag == "condition" {
+		return &byConditionPrinter{w: w}, nil
+	}
+	if summaryFlag {
+		return &objectSummaryPrinter{w: w}, nil
+	}
+	if fleetFlag {
+		return &fleetPrinter{w: w}, nil
+	}
+	switch outputFlag {
+	case "", "table":
+		if groupByFlag == "kind" {
+			return newKindGroupPrinter(&tablePrinter{w: w, layout: newTableLayout(w)}, w), nil
+		}
+		return &tablePrinter{w: w, layout: newTableLayout(w)}, nil
+	case "wide":
+		if groupByFlag == "kind" {
+			return newKindGroupPrinter(&widePrinter{w: w}, w), nil
+		}
+		return &widePrinter{w: w}, nil
+	case "matrix":
+		return &matrixPrinter{w: w}, nil
+	case "timeline":
+		return &timelinePrinter{w: w}, nil
+	case "ndjson", "jsonl":
+		return nil, fmt.Errorf("output format %q is only supported with --watch", outputFlag)
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", outputFlag)
+	}
+}
+
+// tablePrinter prints each object's conditions as a table as soon as the
+// object is received.
+type tablePrinter struct {
+	w      io.Writer
+	layout tableLayout
+}
+
+func (p *tablePrinter) Print(r *objectReport) error {
+	printObject(p.w, r, p.layout)
+	return nil
+}
+
+func (p *tablePrinter) Flush() error { return nil }
+
+// summaryPrinter prints a line summarizing the health of all printed
+// objects after their output, so that large runs end with a verdict
+// that's visible at a glance.
+type summaryPrinter struct {
+	printer
+	w      io.Writer
+	counts healthCounts
+}
+
+func (p *summaryPrinter) Print(r *objectReport) error {
+	p.counts.addShown(r.Conditions)
+	return p.printer.Print(r)
+}
+
+func (p *summaryPrinter) Flush() error {
+	if err := p.printer.Flush(); err != nil {
+		return err
+	}
+	if p.counts.Objects == 0 {
+		return nil
+	}
+	if plainFlag {
+		_, err := fmt.Fprintf(p.w, "Summary: %s\n", p.counts)
+		return err
+	}
+	_, err := fmt.Fprintf(p.w, "\n%s\n", bold.Sprint(p.counts))
+	return err
+}
+
+// plainPrinter prints each object's conditions as linear "label: value"
+// lines without tables, colors or symbols, for screen readers and terminals
+// that can't render them.
+type plainPrinter struct {
+	w io.Writer
+}
+
+func (p *plainPrinter) Print(r *objectReport) error {
+	fmt.Fprintf(p.w, "Object: %s %s\n", r.Kind, r.displayName())
+	for _, line := range r.Header {
+		fmt.Fprintln(p.w, line)
+	}
+	if len(r.Conditions) == 0 {
+		fmt.Fprintln(p.w, "No conditions reported.")
+	}
+	for _, cond := range r.Conditions {
+		fmt.Fprintf(p.w, "Condition: %s\n", cond.Type)
+		fmt.Fprintf(p.w, "Status: %s, %s\n", cond.Status, healthLabel(cond))
+		if cond.Reason != "" {
+			fmt.Fprintf(p.w, "Reason: %s\n", cond.Reason)
+		}
+		if cond.Message != "" {
+			fmt.Fprintf(p.w, "Message: %s\n", cond.Message)
+		}
+		for _, note := range cond.Notes {
+			fmt.Fprintf(p.w, "Note: %s\n", note)
+		}
+		if cond.LastTransitionTime != nil {
+			fmt.Fprintf(p.w, "Last transition: %s\n", plainTime(cond.LastTransitionTime.Time))
+		}
+		if cond.LastUpdateTime != nil {
+			fmt.Fprintf(p.w, "Last update: %s\n", plainTime(cond.LastUpdateTime.Time))
+		}
+		if cond.LastHeartbeatTime != nil {
+			fmt.Fprintf(p.w, "Last heartbeat: %s\n", plainTime(cond.LastHeartbeatTime.Time))
+		}
+	}
+	_, err := fmt.Fprintln(p.w)
+	return err
+}
+
+func (p *plainPrinter) Flush() error { return nil }
+
+// healthLabel describes the health of the condition in words, taking its
+// polarity into account.
+func healthLabel(cond GenericCondition) string {
+	return conditions.Health(cond, unknownAs())
+}
+
+func plainTime(t time.Time) string {
+	return formatTime(t, false, func(s string) string { return s })
+}
+
+// newPlainTable returns a borderless, left-aligned table in the style of
+// kubectl get output.
+func newPlainTable(w io.Writer, header ...string) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetColumnSeparator("")
+	table.SetCenterSeparator("")
+	table.SetAutoWrapText(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	return table
+}
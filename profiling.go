@@ -0,0 +1,96 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+var profileFlag string
+var profileOutputFlag string
+
+// cpuProfileFile is the file the CPU profile is being written to.
+var cpuProfileFile *os.File
+
+// startProfiling starts recording the profile selected with --profile, like
+// kubectl's profiling flags. The CPU profile is recorded for the duration of
+// the command, while other profiles are snapshots written by stopProfiling.
+func startProfiling() error {
+	switch profileFlag {
+	case "none":
+		return nil
+	case "cpu":
+		f, err := os.Create(profileOutputFlag)
+		if err != nil {
+			return fmt.Errorf("failed to create profile output: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		cpuProfileFile = f
+		return nil
+	case "block":
+		runtime.SetBlockProfileRate(1)
+	case "mutex":
+		runtime.SetMutexProfileFraction(1)
+	}
+	if pprof.Lookup(profileName()) == nil {
+		return fmt.Errorf("unknown profile %q, expected one of: (none, cpu, mem, heap, goroutine, threadcreate, block, mutex)", profileFlag)
+	}
+	return nil
+}
+
+// stopProfiling stops the CPU profile or writes the selected profile to the
+// --profile-output file.
+func stopProfiling() error {
+	switch profileFlag {
+	case "none":
+		return nil
+	case "cpu":
+		if cpuProfileFile == nil {
+			return nil
+		}
+		pprof.StopCPUProfile()
+		return cpuProfileFile.Close()
+	}
+	p := pprof.Lookup(profileName())
+	if p == nil {
+		return nil
+	}
+	if p.Name() == "heap" {
+		// collect garbage to get up-to-date statistics
+		runtime.GC()
+	}
+	f, err := os.Create(profileOutputFlag)
+	if err != nil {
+		return fmt.Errorf("failed to create profile output: %w", err)
+	}
+	if err := p.WriteTo(f, 0); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s profile: %w", p.Name(), err)
+	}
+	return f.Close()
+}
+
+func profileName() string {
+	if profileFlag == "mem" {
+		return "heap"
+	}
+	return profileFlag
+}
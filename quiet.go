@@ -0,0 +1,45 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+var quietFlag bool
+
+// quietPrinter prints a single line for each unhealthy object with its
+// worst condition, and nothing for healthy objects. The health of the
+// objects is reported with the exit code.
+type quietPrinter struct {
+	w io.Writer
+}
+
+func (p *quietPrinter) Print(r *objectReport) error {
+	s := summarizeObject(r)
+	if s.bad == 0 && s.unknown == 0 {
+		return nil
+	}
+	cond := *s.worst
+	line := fmt.Sprintf("%s %s: %s=%s", r.Kind, r.displayName(), cond.Type, cond.Status)
+	if cond.Reason != "" {
+		line += " (" + cond.Reason + ")"
+	}
+	_, err := fmt.Fprintln(p.w, line)
+	return err
+}
+
+func (p *quietPrinter) Flush() error { return nil }
@@ -0,0 +1,94 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// forbiddenMessagePattern matches the message of the Forbidden errors
+// returned by the RBAC authorizer, e.g.
+//
+//	pods is forbidden: User "jane" cannot list resource "pods" in API group "" in the namespace "team-a"
+var forbiddenMessagePattern = regexp.MustCompile(`cannot (\S+) resource "([^"]+)" in API group "([^"]*)"(?: in the namespace "([^"]+)")?`)
+
+// rbacHints returns a human-readable explanation of the access that was
+// denied for each Forbidden error in err, along with the RBAC rule that would
+// grant it.
+func rbacHints(err error) []string {
+	var hints []string
+	seen := make(map[string]bool)
+	for _, e := range flattenErrors(err) {
+		if !apierrors.IsForbidden(e) {
+			continue
+		}
+		hint := rbacHint(e)
+		if hint != "" && !seen[hint] {
+			seen[hint] = true
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+func rbacHint(err error) string {
+	m := forbiddenMessagePattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return ""
+	}
+	verb, res, group, namespace := m[1], m[2], m[3], m[4]
+
+	scope := "at the cluster scope (ClusterRole)"
+	if namespace != "" {
+		scope = fmt.Sprintf("in namespace %s (Role or ClusterRole with a RoleBinding)", namespace)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Access denied: cannot %s %s %s.\n", verb, res, scope)
+	fmt.Fprintf(&b, "The following RBAC rule is needed:\n")
+	fmt.Fprintf(&b, "  - apiGroups: [%q]\n", group)
+	fmt.Fprintf(&b, "    resources: [%q]\n", res)
+	fmt.Fprintf(&b, "    verbs: [%q]", verb)
+	return b.String()
+}
+
+// flattenErrors returns the individual errors in aggregated or wrapped
+// errors.
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		var out []error
+		for _, e := range agg.Errors() {
+			out = append(out, flattenErrors(e)...)
+		}
+		return out
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var out []error
+		for _, e := range joined.Unwrap() {
+			out = append(out, flattenErrors(e)...)
+		}
+		return out
+	}
+	return []error{err}
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
+
+var highlightRecentFlag time.Duration
+
+// recentMarker is prepended to the type of conditions that transitioned
+// within the --highlight-recent window.
+const recentMarker = "⚡"
+
+var recentColor = color.New(color.FgBlack, color.BgYellow)
+
+// isRecent reports whether the condition transitioned within the
+// --highlight-recent window.
+func isRecent(cond GenericCondition) bool {
+	if highlightRecentFlag <= 0 || cond.LastTransitionTime == nil {
+		return false
+	}
+	return time.Since(cond.LastTransitionTime.Time) <= highlightRecentFlag
+}
+
+// highlightRecent marks the condition type s (already colorized) if the
+// condition transitioned recently.
+func highlightRecent(cond GenericCondition, s string) string {
+	if !isRecent(cond) {
+		return s
+	}
+	return recentColor.Sprint(recentMarker) + " " + s
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func newRecordCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var dbPath string
+	cmd := &cobra.Command{
+		Use:   "record <object-type>[,<object-type>...] [<object-name>...] [--db <path>]",
+		Short: "Watch the requested objects and store every condition transition in a SQLite database",
+		Long: "Watch the requested objects like --watch, and store every condition transition (and object deletion) " +
+			"with its time in a SQLite database, to be queried later with the query command. " +
+			"Runs until interrupted; the database can be queried while recording.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFlag != "" && outputFlag != "ndjson" && outputFlag != "jsonl" {
+				return fmt.Errorf("output format %q is not supported by record", outputFlag)
+			}
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+
+			db, err := openTransitionDB(dbPath, currentContextName(configFlags))
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			err = runWatch(ctx, &kubeClient{configFlags: configFlags}, configFlags, args, db)
+			if errors.Is(err, errUnhealthy) {
+				// the health of the objects doesn't matter when recording
+				return nil
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&dbPath, "db", defaultTransitionDBPath(), "Path of the SQLite database to store the transitions in.")
+	return cmd
+}
+
+func newQueryCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var dbPath, kind, name, cluster string
+	var since time.Duration
+	cmd := &cobra.Command{
+		Use:   "query [TYPE[=STATUS]] [--db <path>]",
+		Short: "Print the condition transitions stored in a SQLite database by the record command",
+		Long: "Print the condition transitions stored by the record command, oldest first, filtered by condition type " +
+			"(which can be a glob pattern), status, namespace (only if -n is given), kind, name and cluster. " +
+			"For example, \"query Ready=False -n prod --since 24h\" lists the Ready=False transitions in the prod namespace in the last day.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := transitionQuery{Kind: kind, Name: name, Cluster: cluster}
+			if since > 0 {
+				q.Since = time.Now().Add(-since)
+			}
+			if len(args) > 0 {
+				typ, status, err := parseTransitionFilter(args[0])
+				if err != nil {
+					return err
+				}
+				q.Type, q.Status = typ, status
+			}
+			if cmd.Flags().Changed("namespace") && !allNamespacesFlag {
+				q.Namespace = *configFlags.Namespace
+			}
+
+			db, err := openTransitionDB(dbPath, "")
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			transitions, err := db.query(q)
+			if err != nil {
+				return err
+			}
+			switch outputFlag {
+			case "":
+				printStoredTransitions(transitions)
+				return nil
+			case "ndjson", "jsonl":
+				enc := json.NewEncoder(stdout)
+				for _, t := range transitions {
+					if err := enc.Encode(t); err != nil {
+						return err
+					}
+				}
+				return nil
+			default:
+				return fmt.Errorf("output format %q is not supported by query", outputFlag)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&dbPath, "db", defaultTransitionDBPath(), "Path of the SQLite database written by the record command.")
+	cmd.Flags().DurationVar(&since, "since", 24*time.Hour, "Only print the transitions within the given duration. Zero means all recorded transitions.")
+	cmd.Flags().StringVar(&kind, "kind", "", "Only print the transitions of objects of the given kind (e.g. Pod).")
+	cmd.Flags().StringVar(&name, "name", "", "Only print the transitions of objects with the given name.")
+	cmd.Flags().StringVar(&cluster, "cluster", "", "Only print the transitions recorded from the given kubeconfig context.")
+	return cmd
+}
+
+// parseTransitionFilter parses a filter in the form of TYPE[=STATUS]. The
+// status matches all statuses when omitted.
+func parseTransitionFilter(s string) (string, metav1.ConditionStatus, error) {
+	typ, status, ok := strings.Cut(s, "=")
+	if typ == "" {
+		return "", "", fmt.Errorf("invalid filter %q, expected TYPE[=STATUS] (e.g. Ready=False)", s)
+	}
+	if !ok {
+		return typ, "", nil
+	}
+	for _, st := range []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown} {
+		if strings.EqualFold(status, string(st)) {
+			return typ, st, nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid status %q, expected one of: (True, False, Unknown)", status)
+}
+
+// printStoredTransitions prints the transitions as a table. The cluster
+// column is only shown when they were recorded from several clusters.
+func printStoredTransitions(transitions []storedTransition) {
+	if len(transitions) == 0 {
+		fmt.Fprintln(stderr, "No transitions found.")
+		return
+	}
+	clusters := make(map[string]bool)
+	for _, t := range transitions {
+		clusters[t.Cluster] = true
+	}
+	header := []string{"Time", "Kind", "Name", "Event", "Type", "Status", "Reason", "Message"}
+	if len(clusters) > 1 {
+		header = append([]string{"Cluster"}, header...)
+	}
+	table := newPlainTable(stdout, header...)
+	for _, t := range transitions {
+		name := t.Name
+		if t.Namespace != "" {
+			name = t.Namespace + "/" + t.Name
+		}
+		cond := GenericCondition{Type: t.Type, Status: t.Status}
+		status := orDash(string(t.Status))
+		if t.Status != "" {
+			status = statusColor(cond)(status)
+		}
+		if t.OldStatus != "" && t.OldStatus != t.Status {
+			arrow := " → "
+			if plainFlag {
+				arrow = " to "
+			}
+			status = string(t.OldStatus) + arrow + status
+		}
+		row := []string{formatTime(t.Time, false, func(s string) string { return gray.Sprint(s) }), t.Kind, name, t.Event, orDash(t.Type), status,
+			orDash(t.Reason), orDash(strings.Join(strings.Fields(t.Message), " "))}
+		if len(clusters) > 1 {
+			row = append([]string{orDash(t.Cluster)}, row...)
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var refreshFlag time.Duration
+
+// clearScreen moves the cursor to the top left corner and clears the
+// terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// changedColor highlights the parts of the output that changed since the
+// previous refresh, like watch -d.
+var changedColor = color.New(color.ReverseVideo)
+
+// screenBuffer holds the output of a refresh until it's complete, so that
+// the screen is redrawn at once. Tables are sized for the terminal since the
+// output ends up on the standard output.
+type screenBuffer struct {
+	bytes.Buffer
+}
+
+// runRefresh queries and prints the objects selected with the arguments
+// every --refresh interval, redrawing the screen like watch kubectl cond
+// does and highlighting the cells that changed since the previous refresh,
+// until interrupted (or --timeout).
+func runRefresh(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, args []string) error {
+	if watchFlag || waitForFlag != "" || rolloutFlag || treeFlag || interactiveFlag {
+		return errors.New("--refresh can't be used with --watch, --wait-for, --rollout, --tree and --interactive")
+	}
+	terminal := isatty.IsTerminal(os.Stdout.Fd())
+	title := fmt.Sprintf("Every %s: kubectl cond %s", refreshFlag, strings.Join(os.Args[1:], " "))
+
+	// other output formats are not meant to be read on the screen or
+	// don't have cells to highlight
+	highlight := outputFlag == "" || outputFlag == "table" || outputFlag == "wide"
+
+	var prev map[string]*objectReport
+	first := true
+	ticker := time.NewTicker(refreshFlag)
+	defer ticker.Stop()
+	for {
+		var out, errOut screenBuffer
+		p := &refreshPrinter{cur: make(map[string]*objectReport)}
+		if highlight {
+			p.prev = prev
+		}
+		err := refreshOnce(ctx, kc, configFlags, args, p, &out, &errOut)
+		if ctx.Err() != nil {
+			return nil
+		}
+		prev = p.cur
+
+		if terminal {
+			fmt.Fprint(stdout, clearScreen)
+		} else if !first {
+			fmt.Fprintln(stdout)
+		}
+		first = false
+		fmt.Fprintln(stdout, refreshTitle(title, time.Now()))
+		fmt.Fprintln(stdout)
+		stdout.Write(out.Bytes())
+		stderr.Write(errOut.Bytes())
+		if err != nil && !errors.Is(err, errUnhealthy) {
+			fmt.Fprintln(stderr, activeTheme.unhealthy.Sprintf("Refresh failed: %v", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshOnce queries the objects and prints them to out through p, which
+// wraps the printer selected by the output flags.
+func refreshOnce(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, args []string, p *refreshPrinter, out, errOut *screenBuffer) error {
+	rb, err := newResourceBuilder(configFlags)
+	if err != nil {
+		return err
+	}
+	rb = rb.ResourceTypeOrNameArgs(true, args...).FilenameParam(false, filenameOpts)
+	if p.printer, err = newOutputPrinter(ctx, kc, out); err != nil {
+		return err
+	}
+	return printVisited(ctx, kc, queryLatest(rb), p, errOut)
+}
+
+// refreshTitle returns the title line of a refresh with the time aligned to
+// the right edge of the terminal.
+func refreshTitle(title string, now time.Time) string {
+	stamp := now.In(displayLocation).Format(time.DateTime)
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if pad := width - runewidth.StringWidth(title) - len(stamp); err == nil && pad > 0 {
+		return gray.Sprint(title + strings.Repeat(" ", pad) + stamp)
+	}
+	return gray.Sprint(title + "  " + stamp)
+}
+
+// refreshPrinter highlights the header lines and the reasons and messages of
+// the conditions that changed since the previous refresh, before printing
+// them. Conditions are matched by their type, so conditions (or objects)
+// that appear or go away don't highlight the ones that follow them. The
+// types aren't highlighted since the condition filters match them.
+type refreshPrinter struct {
+	printer
+
+	// prev holds the reports of the previous refresh by object, and is nil
+	// for the first one.
+	prev map[string]*objectReport
+	cur  map[string]*objectReport
+}
+
+func (p *refreshPrinter) Print(r *objectReport) error {
+	key := objectKey(r.Object)
+	p.cur[key] = &objectReport{
+		Header:     slices.Clone(r.Header),
+		Conditions: slices.Clone(r.Conditions),
+	}
+	if p.prev == nil {
+		return p.printer.Print(r)
+	}
+
+	old, ok := p.prev[key]
+	if !ok {
+		old = &objectReport{}
+	}
+	for i, line := range r.Header {
+		if !slices.Contains(old.Header, line) {
+			r.Header[i] = highlightChange(line)
+		}
+	}
+	for i, cond := range r.Conditions {
+		var prevCond GenericCondition
+		if j := slices.IndexFunc(old.Conditions, func(c GenericCondition) bool { return c.Type == cond.Type }); j >= 0 {
+			prevCond = old.Conditions[j]
+		}
+		// a change of the status is shown on the reason (or the message)
+		statusChanged := cond.Status != prevCond.Status
+		if cond.Reason != "" && (statusChanged || cond.Reason != prevCond.Reason) {
+			r.Conditions[i].Reason = highlightChange(cond.Reason)
+			statusChanged = false
+		}
+		if cond.Message != "" && (statusChanged || cond.Message != prevCond.Message) {
+			r.Conditions[i].Message = highlightChange(cond.Message)
+		}
+	}
+	return p.printer.Print(r)
+}
+
+// highlightChange highlights s (which may already be colorized) as changed,
+// restoring the highlight after the resets of the colors in it.
+func highlightChange(s string) string {
+	if color.NoColor {
+		return s
+	}
+	return changedColor.Sprint(strings.ReplaceAll(s, "\x1b[0m", "\x1b[0m\x1b[7m"))
+}
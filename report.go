@@ -0,0 +1,277 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// reportDocument is the machine-readable form of an object report.
+type reportDocument struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Name       string            `json:"name"`
+	Header     []string          `json:"header,omitempty"`
+	Conditions []reportCondition `json:"conditions"`
+}
+
+// reportCondition is a condition along with its health, after taking its
+// polarity into account, so that consumers don't need to know which
+// condition types are negative.
+type reportCondition struct {
+	GenericCondition
+	Health string `json:"health"`
+}
+
+func newReportDocument(r *objectReport) reportDocument {
+	doc := reportDocument{
+		APIVersion: r.Object.GetAPIVersion(),
+		Kind:       r.Kind,
+		Namespace:  r.Namespace,
+		Name:       r.Name,
+		Conditions: make([]reportCondition, 0, len(r.Conditions)),
+	}
+	for _, cond := range r.Conditions {
+		doc.Conditions = append(doc.Conditions, reportCondition{cond, healthLabel(cond)})
+	}
+	// header lines may be colored for the terminal
+	for _, line := range r.Header {
+		doc.Header = append(doc.Header, ansiEscapePattern.ReplaceAllString(line, ""))
+	}
+	return doc
+}
+
+var ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// reportList is the machine-readable form of the reports of a run.
+type reportList struct {
+	Items   []reportDocument `json:"items"`
+	Summary healthCounts     `json:"summary"`
+}
+
+// jsonPrinter prints the reports as a single JSON document once all of them
+// are received.
+type jsonPrinter struct {
+	w    io.Writer
+	list reportList
+}
+
+func (p *jsonPrinter) Print(r *objectReport) error {
+	p.list.Items = append(p.list.Items, newReportDocument(r))
+	p.list.Summary.add(r.Conditions)
+	return nil
+}
+
+func (p *jsonPrinter) Flush() error {
+	if p.list.Items == nil {
+		p.list.Items = []reportDocument{}
+	}
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p.list)
+}
+
+// yamlPrinter prints the reports as a single YAML document once all of them
+// are received.
+type yamlPrinter struct {
+	jsonPrinter
+}
+
+func (p *yamlPrinter) Flush() error {
+	if p.list.Items == nil {
+		p.list.Items = []reportDocument{}
+	}
+	b, err := yaml.Marshal(p.list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reports: %w", err)
+	}
+	_, err = p.w.Write(b)
+	return err
+}
+
+// htmlPrinter prints the reports as a standalone HTML page once all of them
+// are received.
+type htmlPrinter struct {
+	w       io.Writer
+	reports []*objectReport
+}
+
+func (p *htmlPrinter) Print(r *objectReport) error {
+	p.reports = append(p.reports, r)
+	return nil
+}
+
+func (p *htmlPrinter) Flush() error {
+	var counts healthCounts
+	for _, r := range p.reports {
+		counts.add(r.Conditions)
+	}
+	return htmlReportTemplate.Execute(p.w, struct {
+		Generated time.Time
+		Reports   []*objectReport
+		Summary   healthCounts
+	}{time.Now(), p.reports, counts})
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"health": healthLabel,
+	"badge":  healthBadge,
+	"time":   func(t *metav1.Time) string { return absoluteTime(t.Time) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Conditions report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.healthy { color: #1a7f37; } .unhealthy { color: #cf222e; } .warning { color: #9a6700; } .unknown { color: #6e7781; }
+.note, .header { color: #6e7781; }
+</style>
+</head>
+<body>
+<p class="header">Generated at {{.Generated.Format "2006-01-02T15:04:05Z07:00"}}</p>
+<p><b>{{.Summary}}</b></p>
+{{range .Reports}}
+<h2>{{.Kind}} {{.Object.GetNamespace}}{{if .Object.GetNamespace}}/{{end}}{{.Name}}</h2>
+{{range .Header}}<p class="header">{{.}}</p>
+{{end}}{{if .Conditions}}<table>
+<tr><th>Condition Type</th><th>Status</th><th>Details</th></tr>
+{{range .Conditions}}<tr class="{{health .}}">
+<td>{{.Type}}</td><td>{{badge .}} {{.Status}}</td>
+<td>{{if .Reason}}<b>{{.Reason}}</b><br>{{end}}{{.Message}}{{range .Notes}}<br><span class="note">{{.}}</span>{{end}}{{if .LastTransitionTime}}<br>Last Transition: {{time .LastTransitionTime}}{{end}}</td>
+</tr>
+{{end}}</table>
+{{else}}<p>No conditions reported.</p>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// outputFilePrinter writes the reports to a file in the format determined
+// by its extension, and prints a summary of them to the terminal once done.
+type outputFilePrinter struct {
+	printer
+	f      *os.File
+	out    io.Writer
+	counts healthCounts
+}
+
+// newOutputFilePrinter creates the file at path and returns a printer
+// writing to it. Files with a .json, .html or .md extension are written in
+// that format, while other files get the output that would otherwise be
+// printed to the terminal, without colors.
+func newOutputFilePrinter(path string, out io.Writer) (*outputFilePrinter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	// the terminal only gets the summary, and the reports should be
+	// rendered without colors for the file
+	color.NoColor = true
+	var p printer
+	switch strings.ToLower(filepath.Ext(path)) {
+	// the filter and detail field flags apply like they do for -o
+	case ".json":
+		p = withConditionFilters(&jsonPrinter{w: f})
+	case ".html", ".htm":
+		p = withConditionFilters(withDetailFields(&htmlPrinter{w: f}))
+	case ".md", ".markdown":
+		p = withConditionFilters(withDetailFields(&markdownPrinter{w: f}))
+	default:
+		if p, err = newPrinter(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return &outputFilePrinter{printer: p, f: f, out: out}, nil
+}
+
+func (p *outputFilePrinter) Print(r *objectReport) error {
+	p.counts.addShown(r.Conditions)
+	return p.printer.Print(r)
+}
+
+func (p *outputFilePrinter) Flush() error {
+	if err := p.printer.Flush(); err != nil {
+		p.f.Close()
+		return err
+	}
+	if err := p.f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	_, err := fmt.Fprintf(p.out, "Wrote %s: %s.\n", p.f.Name(), p.counts)
+	return err
+}
+
+// teePrinter prints the reports with all of its printers.
+type teePrinter []printer
+
+func (t teePrinter) Print(r *objectReport) error {
+	for _, p := range t {
+		if err := p.Print(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t teePrinter) Flush() error {
+	for _, p := range t {
+		if err := p.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonFilePrinter writes the reports as JSON to a file.
+type jsonFilePrinter struct {
+	jsonPrinter
+	f *os.File
+}
+
+func newJSONFilePrinter(path string) (*jsonFilePrinter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON output file: %w", err)
+	}
+	return &jsonFilePrinter{jsonPrinter: jsonPrinter{w: f}, f: f}, nil
+}
+
+func (p *jsonFilePrinter) Flush() error {
+	if err := p.jsonPrinter.Flush(); err != nil {
+		p.f.Close()
+		return err
+	}
+	if err := p.f.Close(); err != nil {
+		return fmt.Errorf("failed to write JSON output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// testReport returns the report of a pod with the given conditions.
+func testReport(conds ...GenericCondition) *objectReport {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace("default")
+	obj.SetName("web")
+	return &objectReport{Object: obj, Kind: "Pod", Namespace: "default", Name: "web", Conditions: conds}
+}
+
+func TestOutputFilePrinterAppliesConditionFilters(t *testing.T) {
+	defer func(v []string) { typeFlag = v }(typeFlag)
+	typeFlag = []string{"Ready"}
+
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		p, err := newOutputFilePrinter(path, &strings.Builder{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := testReport(
+			GenericCondition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "PodReady"},
+			GenericCondition{Type: "PodScheduled", Status: metav1.ConditionFalse, Reason: "Unschedulable"},
+		)
+		if err := p.Print(r); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	text := write("report.txt")
+	if !strings.Contains(text, "Ready") || strings.Contains(text, "PodScheduled") {
+		t.Errorf("text report doesn't contain only the Ready condition:\n%s", text)
+	}
+
+	var list reportList
+	if err := json.Unmarshal([]byte(write("report.json")), &list); err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, item := range list.Items {
+		for _, cond := range item.Conditions {
+			types = append(types, cond.Type)
+		}
+	}
+	if len(types) != 1 || types[0] != "Ready" {
+		t.Errorf("JSON report has conditions %v, want [Ready]", types)
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ahmetb/kubectl-cond/pkg/conditions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
+)
+
+// rolloutPollInterval is how often the workload and its ReplicaSets and
+// pods are queried while following a rollout.
+const rolloutPollInterval = 2 * time.Second
+
+// deploymentRevisionAnnotation holds the revision of a Deployment and of
+// its ReplicaSets.
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+var rolloutFlag bool
+
+var (
+	replicaSetsResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	podsResource        = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+)
+
+// rolloutState describes the progress of a rollout, in the same terms as
+// kubectl rollout status.
+type rolloutState struct {
+	Done    bool
+	Message string
+	// Err is set when the rollout can't make progress anymore.
+	Err error
+}
+
+// runRollout follows the rollout of a Deployment, StatefulSet or DaemonSet,
+// printing the conditions of the workload, of its current and old
+// ReplicaSets and of its pods that aren't ready whenever they change, until
+// the rollout completes (or --timeout expires).
+func runRollout(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, args []string) error {
+	if watchFlag || waitForFlag != "" || treeFlag {
+		return errors.New("--rollout can't be used with --watch, --wait-for and --tree")
+	}
+	rb, err := newResourceBuilder(configFlags)
+	if err != nil {
+		return err
+	}
+	infos, err := rb.ResourceTypeOrNameArgs(true, args...).FilenameParam(false, filenameOpts).
+		Latest().Flatten().Do().Infos()
+	if err != nil {
+		return err
+	}
+	if len(infos) != 1 {
+		return fmt.Errorf("--rollout requires exactly one workload, got %d objects", len(infos))
+	}
+	info := infos[0]
+	gk := info.Mapping.GroupVersionKind.GroupKind()
+	switch gk {
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		schema.GroupKind{Group: "apps", Kind: "StatefulSet"},
+		schema.GroupKind{Group: "apps", Kind: "DaemonSet"}:
+	default:
+		return fmt.Errorf("--rollout only supports Deployments, StatefulSets and DaemonSets, got %s", gk.Kind)
+	}
+	dyn, err := kc.Dynamic()
+	if err != nil {
+		return err
+	}
+
+	var last []byte
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+	for {
+		obj, err := dyn.Resource(info.Mapping.Resource).Namespace(info.Namespace).Get(ctx, info.Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s", timeoutFlag)
+			}
+			return err
+		}
+		state := workloadRolloutState(obj)
+		var buf bytes.Buffer
+		if err := printRollout(ctx, &buf, kc, dyn, obj); err != nil {
+			return err
+		}
+		if !bytes.Equal(buf.Bytes(), last) {
+			if last != nil {
+				fmt.Fprintln(stdout)
+			}
+			fmt.Fprintf(stdout, "%s %s\n", gray.Sprint(time.Now().In(displayLocation).Format(time.TimeOnly)), bold.Sprint(state.Message))
+			stdout.Write(buf.Bytes())
+			last = buf.Bytes()
+		}
+		if state.Err != nil {
+			return state.Err
+		}
+		if state.Done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s waiting for the rollout: %s", timeoutFlag, state.Message)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// printRollout prints the conditions of the workload, its ReplicaSets (for
+// Deployments) and its pods that aren't ready.
+func printRollout(ctx context.Context, w io.Writer, kc *kubeClient, dyn dynamic.Interface, obj *unstructured.Unstructured) error {
+	layout := newTableLayout(stdout)
+	report, err := newObjectReport(ctx, kc, obj.DeepCopy())
+	if err != nil && !errors.Is(err, errNoConditions) {
+		return err
+	}
+	printObject(w, report, layout)
+
+	selector, err := workloadSelector(obj)
+	if err != nil {
+		return err
+	}
+	if obj.GetKind() == "Deployment" {
+		rsList, err := dyn.Resource(replicaSetsResource).Namespace(obj.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list ReplicaSets: %w", err)
+		}
+		revision := obj.GetAnnotations()[deploymentRevisionAnnotation]
+		var current, old []*objectReport
+		for i := range rsList.Items {
+			rs := &rsList.Items[i]
+			if !metav1.IsControlledBy(rs, obj) {
+				continue
+			}
+			replicas, _, _ := unstructured.NestedInt64(rs.Object, "spec", "replicas")
+			rsRevision := rs.GetAnnotations()[deploymentRevisionAnnotation]
+			if rsRevision != revision && replicas == 0 {
+				continue
+			}
+			r, err := newObjectReport(ctx, kc, rs)
+			if err != nil && !errors.Is(err, errNoConditions) {
+				return err
+			}
+			role := fmt.Sprintf("(revision %s, %d replicas)", rsRevision, replicas)
+			if rsRevision == revision {
+				r.Header = append([]string{"New ReplicaSet " + gray.Sprint(role)}, r.Header...)
+				current = append(current, r)
+			} else {
+				r.Header = append([]string{"Old ReplicaSet " + gray.Sprint(role)}, r.Header...)
+				old = append(old, r)
+			}
+		}
+		for _, r := range append(current, old...) {
+			fmt.Fprintln(w)
+			printObject(w, r, layout)
+		}
+	}
+
+	pods, err := dyn.Resource(podsResource).Namespace(obj.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for i := range pods.Items {
+		r, err := newObjectReport(ctx, kc, &pods.Items[i])
+		if err != nil {
+			continue
+		}
+		if podReady(r) {
+			continue
+		}
+		fmt.Fprintln(w)
+		printObject(w, r, layout)
+	}
+	return nil
+}
+
+// podReady reports whether the pod of the report has the Ready condition.
+func podReady(r *objectReport) bool {
+	for _, cond := range r.Conditions {
+		if cond.Type == "Ready" {
+			return cond.Status == metav1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// workloadSelector returns the label selector of the pods of the workload.
+func workloadSelector(obj *unstructured.Unstructured) (string, error) {
+	raw, _, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+	var ls metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &ls); err != nil {
+		return "", fmt.Errorf("failed to parse selector: %w", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&ls)
+	if err != nil {
+		return "", fmt.Errorf("invalid selector: %w", err)
+	}
+	return selector.String(), nil
+}
+
+// workloadRolloutState returns the progress of the rollout of the workload,
+// based on the same fields as kubectl rollout status.
+func workloadRolloutState(obj *unstructured.Unstructured) rolloutState {
+	status := func(field string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj.Object, "status", field)
+		return v
+	}
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if obj.GetGeneration() > observed {
+		return rolloutState{Message: "Waiting for the controller to observe the update"}
+	}
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+
+	switch obj.GetKind() {
+	case "Deployment":
+		conds, _, _ := conditions.Extract(obj)
+		for _, c := range conds {
+			if c.Type == "Progressing" && c.Reason == "ProgressDeadlineExceeded" {
+				return rolloutState{Message: "Rollout exceeded its progress deadline",
+					Err: fmt.Errorf("deployment %q exceeded its progress deadline", obj.GetName())}
+			}
+		}
+		updated, total, available := status("updatedReplicas"), status("replicas"), status("availableReplicas")
+		switch {
+		case updated < replicas:
+			return rolloutState{Message: fmt.Sprintf("Waiting for rollout to finish: %d of %d new replicas have been updated", updated, replicas)}
+		case total > updated:
+			return rolloutState{Message: fmt.Sprintf("Waiting for rollout to finish: %d old replicas are pending termination", total-updated)}
+		case available < updated:
+			return rolloutState{Message: fmt.Sprintf("Waiting for rollout to finish: %d of %d updated replicas are available", available, updated)}
+		}
+	case "StatefulSet":
+		partition, _, _ := unstructured.NestedInt64(obj.Object, "spec", "updateStrategy", "rollingUpdate", "partition")
+		ready, updated := status("readyReplicas"), status("updatedReplicas")
+		currentRevision, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+		updateRevision, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+		switch {
+		case ready < replicas:
+			return rolloutState{Message: fmt.Sprintf("Waiting for %d pods to be ready", replicas-ready)}
+		case partition > 0 && updated < replicas-partition:
+			return rolloutState{Message: fmt.Sprintf("Waiting for partitioned rollout to finish: %d of %d new pods have been updated", updated, replicas-partition)}
+		case partition == 0 && currentRevision != updateRevision:
+			return rolloutState{Message: fmt.Sprintf("Waiting for rollout to finish: %d of %d pods have been updated", updated, replicas)}
+		}
+	case "DaemonSet":
+		desired, updated, available := status("desiredNumberScheduled"), status("updatedNumberScheduled"), status("numberAvailable")
+		switch {
+		case updated < desired:
+			return rolloutState{Message: fmt.Sprintf("Waiting for rollout to finish: %d of %d updated pods have been scheduled", updated, desired)}
+		case available < desired:
+			return rolloutState{Message: fmt.Sprintf("Waiting for rollout to finish: %d of %d updated pods are available", available, desired)}
+		}
+	}
+	return rolloutState{Done: true, Message: fmt.Sprintf("%s %q successfully rolled out", obj.GetKind(), obj.GetName())}
+}
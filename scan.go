@@ -0,0 +1,224 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/pager"
+)
+
+// scanTarget is a resource type whose objects are listed by scanObjects.
+type scanTarget struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// conditionlessKinds are the built-in kinds that have neither status
+// conditions nor an enricher synthesizing them, so listing them when
+// scanning all resource types is wasted effort.
+var conditionlessKinds = sets.New(
+	schema.GroupKind{Kind: "ConfigMap"},
+	schema.GroupKind{Kind: "Endpoints"},
+	schema.GroupKind{Kind: "Event"},
+	schema.GroupKind{Kind: "LimitRange"},
+	schema.GroupKind{Kind: "PodTemplate"},
+	schema.GroupKind{Kind: "ResourceQuota"},
+	schema.GroupKind{Kind: "Secret"},
+	schema.GroupKind{Kind: "ServiceAccount"},
+	schema.GroupKind{Group: "apps", Kind: "ControllerRevision"},
+	schema.GroupKind{Group: "discovery.k8s.io", Kind: "EndpointSlice"},
+	schema.GroupKind{Group: "events.k8s.io", Kind: "Event"},
+	schema.GroupKind{Group: "networking.k8s.io", Kind: "IngressClass"},
+	schema.GroupKind{Group: "networking.k8s.io", Kind: "NetworkPolicy"},
+	schema.GroupKind{Group: "node.k8s.io", Kind: "RuntimeClass"},
+	schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+	schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"},
+	schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "Role"},
+	schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"},
+	schema.GroupKind{Group: "scheduling.k8s.io", Kind: "PriorityClass"},
+	schema.GroupKind{Group: "storage.k8s.io", Kind: "CSIDriver"},
+	schema.GroupKind{Group: "storage.k8s.io", Kind: "CSINode"},
+	schema.GroupKind{Group: "storage.k8s.io", Kind: "CSIStorageCapacity"},
+	schema.GroupKind{Group: "storage.k8s.io", Kind: "StorageClass"},
+)
+
+// discoverScanTargets returns the preferred version of all resource types
+// that can be listed and can have conditions. Built-in types in
+// conditionlessKinds are skipped, as are custom resources whose OpenAPI
+// schema rules out status.conditions. Cluster-scoped types are only included
+// if clusterScoped is set. Partial discovery results are returned along
+// with the discovery error.
+func discoverScanTargets(configFlags *genericclioptions.ConfigFlags, clusterScoped bool) ([]scanTarget, error) {
+	discoveryClient, err := configFlags.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	lists, err := discoveryClient.ServerPreferredResources()
+	if len(lists) == 0 && err != nil {
+		return nil, fmt.Errorf("failed to discover resource types: %w", err)
+	}
+	type candidate struct {
+		gvk schema.GroupVersionKind
+		scanTarget
+	}
+	var candidates []candidate
+	customGroupVersions := sets.New[schema.GroupVersion]()
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") {
+				continue
+			}
+			if !r.Namespaced && !clusterScoped {
+				continue
+			}
+			gk := schema.GroupKind{Group: gv.Group, Kind: r.Kind}
+			if conditionlessKinds.Has(gk) {
+				continue
+			}
+			if _, ok := enrichers[gk]; !ok && !isBuiltinGroup(gv.Group) {
+				customGroupVersions.Insert(gv)
+			}
+			candidates = append(candidates, candidate{gvk: gv.WithKind(r.Kind), scanTarget: scanTarget{gvr: gv.WithResource(r.Name), namespaced: r.Namespaced}})
+		}
+	}
+
+	docs := loadOpenAPIDocuments(discoveryClient, customGroupVersions.UnsortedList())
+	var targets []scanTarget
+	for _, c := range candidates {
+		if doc, ok := docs[c.gvk.GroupVersion()]; ok && !doc.mayHaveConditions(c.gvk) {
+			continue
+		}
+		targets = append(targets, c.scanTarget)
+	}
+	return targets, err
+}
+
+// loadOpenAPIDocuments fetches the OpenAPI v3 documents of the group
+// versions concurrently. Group versions whose document can't be fetched are
+// left out, as the schema is only used to skip resource types.
+func loadOpenAPIDocuments(dc discovery.DiscoveryInterface, gvs []schema.GroupVersion) map[schema.GroupVersion]*openAPIDocument {
+	docs := make(map[schema.GroupVersion]*openAPIDocument)
+	if len(gvs) == 0 {
+		return docs
+	}
+	paths, err := dc.OpenAPIV3().Paths()
+	if err != nil {
+		return docs
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, treeListConcurrency)
+	for _, gv := range gvs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(gv schema.GroupVersion) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			doc, err := loadOpenAPIDocument(paths, gv)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			docs[gv] = doc
+			mu.Unlock()
+		}(gv)
+	}
+	wg.Wait()
+	return docs
+}
+
+// resolveScanTargets returns the resource types for the given names, which
+// can be in any form accepted by kubectl (e.g. "po", "deployments.apps").
+func resolveScanTargets(configFlags *genericclioptions.ConfigFlags, names []string) ([]scanTarget, error) {
+	mapper, err := configFlags.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	var targets []scanTarget
+	for _, name := range names {
+		gvk, err := mapper.KindFor(schema.ParseGroupResource(name).WithVersion(""))
+		if err != nil {
+			return nil, fmt.Errorf("unknown resource type %q: %w", name, err)
+		}
+		m, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, scanTarget{gvr: m.Resource, namespaced: m.Scope.Name() == meta.RESTScopeNameNamespace})
+	}
+	return targets, nil
+}
+
+// scanObjects lists the objects of the resource types in the namespace (all
+// namespaces if empty) matching --selector and --field-selector, and calls
+// fn with each of them. Namespace is ignored for cluster-scoped types. fn is
+// called concurrently for objects of different types. Resource types that
+// fail to be listed (e.g. due to RBAC) are reported in the returned error
+// after all the others are listed.
+func scanObjects(ctx context.Context, dyn dynamic.Interface, targets []scanTarget, namespace string, fn func(*unstructured.Unstructured)) error {
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, treeListConcurrency)
+	for _, t := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t scanTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ns := namespace
+			if !t.namespaced {
+				ns = ""
+			}
+			p := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return dyn.Resource(t.gvr).Namespace(ns).List(ctx, opts)
+			})
+			p.PageSize = chunkSizeFlag
+			opts := metav1.ListOptions{LabelSelector: selectorFlag, FieldSelector: fieldSelectorFlag}
+			err := p.EachListItem(ctx, opts, func(o runtime.Object) error {
+				if obj, ok := o.(*unstructured.Unstructured); ok {
+					fn(obj)
+				}
+				return nil
+			})
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", t.gvr.GroupResource(), err))
+				mu.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
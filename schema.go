@@ -0,0 +1,241 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/openapi"
+)
+
+var inferPolarityFlag bool
+
+// negativePolarityWords are the words in the names of condition types that
+// indicate that their True status is abnormal, e.g. "Degraded" or
+// "DiskPressure".
+var negativePolarityWords = []string{
+	"Pressure", "Degraded", "Stalled", "Failed", "Failure", "Error", "Unavailable",
+	"Unhealthy", "Reconciling", "Invalid", "Conflict", "Blocked", "Deadlock",
+}
+
+// schemaConditionTypePattern matches the condition types mentioned in the
+// descriptions of schemas, which usually quote them, e.g. "Known condition
+// types are `Ready` and `Stalled`".
+var schemaConditionTypePattern = regexp.MustCompile("[`\"']([A-Z][A-Za-z]+)[`\"']")
+
+// schemaPolarity infers the polarity of the condition types of custom
+// resources from their OpenAPI v3 schemas, for the types that aren't
+// configured explicitly.
+type schemaPolarity struct {
+	configFlags *genericclioptions.ConfigFlags
+
+	mu    sync.Mutex
+	paths map[string]openapi.GroupVersion
+	// negative holds the condition types with negative polarity declared
+	// in the schema of each kind
+	negative map[schema.GroupVersionKind]sets.Set[string]
+}
+
+func newSchemaPolarity(configFlags *genericclioptions.ConfigFlags) *schemaPolarity {
+	return &schemaPolarity{configFlags: configFlags, negative: make(map[schema.GroupVersionKind]sets.Set[string])}
+}
+
+// isNegative reports whether the schema of the kind declares the condition
+// type, and the name of the type suggests negative polarity.
+func (s *schemaPolarity) isNegative(gvk schema.GroupVersionKind, condType string) bool {
+	if isBuiltinGroup(gvk.Group) {
+		// builtin types don't have such conditions beyond the well-known
+		// ones
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types, ok := s.negative[gvk]
+	if !ok {
+		types = s.load(gvk)
+		s.negative[gvk] = types
+	}
+	return types.Has(condType)
+}
+
+// load returns the condition types with negative polarity in the schema of
+// the kind. Failures result in no inferred types, as the schema is only a
+// hint.
+func (s *schemaPolarity) load(gvk schema.GroupVersionKind) sets.Set[string] {
+	out := sets.New[string]()
+	if s.paths == nil {
+		s.paths = make(map[string]openapi.GroupVersion)
+		dc, err := s.configFlags.ToDiscoveryClient()
+		if err != nil {
+			return out
+		}
+		if paths, err := dc.OpenAPIV3().Paths(); err == nil {
+			s.paths = paths
+		}
+	}
+	doc, err := loadOpenAPIDocument(s.paths, gvk.GroupVersion())
+	if err != nil {
+		return out
+	}
+	for _, typ := range doc.conditionTypes(gvk) {
+		if hasNegativePolarityName(typ) {
+			out.Insert(typ)
+		}
+	}
+	return out
+}
+
+// isBuiltinGroup reports whether the API group belongs to Kubernetes itself
+// rather than to a custom resource.
+func isBuiltinGroup(group string) bool {
+	return group == "" || !strings.Contains(group, ".") || strings.HasSuffix(group, ".k8s.io")
+}
+
+func hasNegativePolarityName(condType string) bool {
+	for _, w := range negativePolarityWords {
+		if strings.Contains(condType, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// openAPIDocument is the part of an OpenAPI v3 document that describes the
+// conditions of the kinds in a group version.
+type openAPIDocument struct {
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPISchema struct {
+	Ref         string                    `json:"$ref"`
+	AllOf       []*openAPISchema          `json:"allOf"`
+	Description string                    `json:"description"`
+	Enum        []any                     `json:"enum"`
+	Properties  map[string]*openAPISchema `json:"properties"`
+	Items       *openAPISchema            `json:"items"`
+	GVK         []struct {
+		Group   string `json:"group"`
+		Version string `json:"version"`
+		Kind    string `json:"kind"`
+	} `json:"x-kubernetes-group-version-kind"`
+}
+
+// loadOpenAPIDocument fetches the OpenAPI v3 document of the group version
+// from the paths advertised by the API server.
+func loadOpenAPIDocument(paths map[string]openapi.GroupVersion, gv schema.GroupVersion) (*openAPIDocument, error) {
+	p, ok := paths["apis/"+gv.Group+"/"+gv.Version]
+	if !ok {
+		return nil, fmt.Errorf("no OpenAPI schema for %s", gv)
+	}
+	b, err := p.Schema("application/json")
+	if err != nil {
+		return nil, err
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// resolve follows the references of the schema within the document.
+func (d *openAPIDocument) resolve(s *openAPISchema) *openAPISchema {
+	for i := 0; s != nil && i < 10; i++ {
+		switch {
+		case s.Ref != "":
+			s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		case len(s.AllOf) == 1 && s.Properties == nil && s.Items == nil:
+			s = s.AllOf[0]
+		default:
+			return s
+		}
+	}
+	return s
+}
+
+// conditionTypes returns the condition types that the schema of the kind
+// declares in status.conditions[].type, either as an enum or in the
+// descriptions of the fields.
+func (d *openAPIDocument) conditionTypes(gvk schema.GroupVersionKind) []string {
+	root := d.kind(gvk)
+	if root == nil {
+		return nil
+	}
+	status := d.resolve(root.Properties["status"])
+	if status == nil {
+		return nil
+	}
+	conditions := d.resolve(status.Properties["conditions"])
+	if conditions == nil {
+		return nil
+	}
+	item := d.resolve(conditions.Items)
+	descriptions := []string{conditions.Description}
+	var types []string
+	if item != nil {
+		if typ := d.resolve(item.Properties["type"]); typ != nil {
+			descriptions = append(descriptions, typ.Description)
+			for _, v := range typ.Enum {
+				if s, ok := v.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+	}
+	for _, desc := range descriptions {
+		for _, m := range schemaConditionTypePattern.FindAllStringSubmatch(desc, -1) {
+			types = append(types, m[1])
+		}
+	}
+	return types
+}
+
+// kind returns the resolved schema of the kind, or nil if the document
+// doesn't describe it.
+func (d *openAPIDocument) kind(gvk schema.GroupVersionKind) *openAPISchema {
+	for _, s := range d.Components.Schemas {
+		for _, g := range s.GVK {
+			if g.Group == gvk.Group && g.Version == gvk.Version && g.Kind == gvk.Kind {
+				return d.resolve(s)
+			}
+		}
+	}
+	return nil
+}
+
+// mayHaveConditions reports whether the schema of the kind allows
+// status.conditions: it either declares them, or leaves the object or its
+// status unspecified (e.g. x-kubernetes-preserve-unknown-fields). Kinds the
+// document doesn't describe are assumed to have them.
+func (d *openAPIDocument) mayHaveConditions(gvk schema.GroupVersionKind) bool {
+	root := d.kind(gvk)
+	if root == nil || root.Properties == nil {
+		return true
+	}
+	status := d.resolve(root.Properties["status"])
+	if status == nil {
+		return false
+	}
+	return status.Properties == nil || status.Properties["conditions"] != nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestOpenAPIDocumentMayHaveConditions(t *testing.T) {
+	const doc = `{"components": {"schemas": {
+		"WithConditions": {
+			"x-kubernetes-group-version-kind": [{"group": "example.com", "version": "v1", "kind": "WithConditions"}],
+			"properties": {"status": {"$ref": "#/components/schemas/Status"}}
+		},
+		"Status": {"properties": {"conditions": {"type": "array"}}},
+		"NoConditions": {
+			"x-kubernetes-group-version-kind": [{"group": "example.com", "version": "v1", "kind": "NoConditions"}],
+			"properties": {"status": {"properties": {"phase": {"type": "string"}}}}
+		},
+		"NoStatus": {
+			"x-kubernetes-group-version-kind": [{"group": "example.com", "version": "v1", "kind": "NoStatus"}],
+			"properties": {"spec": {"type": "object"}}
+		},
+		"OpaqueStatus": {
+			"x-kubernetes-group-version-kind": [{"group": "example.com", "version": "v1", "kind": "OpaqueStatus"}],
+			"properties": {"status": {"type": "object"}}
+		}
+	}}}`
+	var d openAPIDocument
+	if err := json.Unmarshal([]byte(doc), &d); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"WithConditions": true,
+		"NoConditions":   false,
+		"NoStatus":       false,
+		"OpaqueStatus":   true,
+		"Missing":        true,
+	}
+	for kind, want := range tests {
+		gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: kind}
+		if got := d.mayHaveConditions(gvk); got != want {
+			t.Errorf("mayHaveConditions(%s) = %v, want %v", kind, got, want)
+		}
+	}
+}
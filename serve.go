@@ -0,0 +1,222 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+func newServeCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var listen string
+	cmd := &cobra.Command{
+		Use:   "serve --listen <address>",
+		Short: "Serve the conditions of objects as JSON over HTTP",
+		Long: "Serve the conditions of the objects selected with the query parameters of /v1/conditions " +
+			"(kind, namespace, allNamespaces, name, selector, type and problemsOnly) in the form of -o json, " +
+			"with their polarity resolved and sorted, so that dashboards and bots don't need to parse the tables.\n\n" +
+			"There is no authentication: every client that can reach the address can read the conditions with the " +
+			"credentials of your kubeconfig. It listens on localhost by default; only pass --listen with other " +
+			"addresses behind an authenticating proxy.\n\n" +
+			"Example: curl 'http://localhost:8080/v1/conditions?kind=pods&namespace=default&problemsOnly=true'",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+			// header lines and notes are served without escape codes
+			color.NoColor = true
+			s := &conditionServer{configFlags: configFlags, kc: &kubeClient{configFlags: configFlags}}
+			return s.run(ctx, listen)
+		},
+	}
+	cmd.Flags().StringVar(&listen, "listen", "localhost:8080", "Address to serve the API on. The API has no authentication.")
+	return cmd
+}
+
+// conditionServer serves the conditions of the objects queried for each
+// request.
+type conditionServer struct {
+	configFlags *genericclioptions.ConfigFlags
+	kc          *kubeClient
+}
+
+// conditionsResponse is the response of /v1/conditions. Errors lists the
+// objects that failed to be queried when the others are still returned.
+type conditionsResponse struct {
+	reportList
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (s *conditionServer) run(ctx context.Context, listen string) error {
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/conditions", s.serveConditions)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+	fmt.Fprintf(stderr, "Serving conditions on http://%s/v1/conditions\n", ln.Addr())
+
+	select {
+	case <-ctx.Done():
+		// like --watch, the server stops after --timeout
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (s *conditionServer) serveConditions(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed, use GET", req.Method))
+		return
+	}
+	q := req.URL.Query()
+	kind := q.Get("kind")
+	if kind == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("the kind parameter is required (e.g. ?kind=pods or ?kind=deploy,sts)"))
+		return
+	}
+	var allNamespaces, problemsOnly bool
+	for name, v := range map[string]*bool{"allNamespaces": &allNamespaces, "problemsOnly": &problemsOnly} {
+		if raw := q.Get(name); raw != "" {
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid %s parameter %q, expected true or false", name, raw))
+				return
+			}
+			*v = b
+		}
+	}
+	namespace := q.Get("namespace")
+	if namespace == "" {
+		var err error
+		if namespace, err = resolveNamespace(s.configFlags); err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	var types []string
+	if t := q.Get("type"); t != "" {
+		types = strings.Split(t, ",")
+	}
+
+	rb := resource.NewBuilder(s.configFlags).Unstructured()
+	if namespace != "" {
+		rb.NamespaceParam(namespace)
+	}
+	if selector := q.Get("selector"); selector != "" {
+		rb.LabelSelectorParam(selector)
+	}
+	args := []string{kind}
+	if names := q.Get("name"); names != "" {
+		args = append(args, strings.Split(names, ",")...)
+	}
+	rb = rb.DefaultNamespace().AllNamespaces(allNamespaces).ResourceTypeOrNameArgs(true, args...)
+
+	result := queryLatest(rb)
+	if err := result.Err(); err != nil {
+		// e.g. an unknown resource type
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := conditionsResponse{reportList: reportList{Items: []reportDocument{}}}
+	var failures []error
+	err := result.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			failures = append(failures, err)
+			return nil
+		}
+		r, err := newObjectReport(req.Context(), s.kc, info.Object)
+		if errors.Is(err, errNoConditions) {
+			return nil
+		} else if err != nil {
+			failures = append(failures, fmt.Errorf("failed to get the conditions of %s %s/%s: %w",
+				info.Object.GetObjectKind().GroupVersionKind().Kind, info.Namespace, info.Name, err))
+			return nil
+		}
+		var shown []GenericCondition
+		for _, cond := range r.Conditions {
+			if (problemsOnly && isHealthy(cond)) || !matchesAny(types, cond.Type) {
+				continue
+			}
+			shown = append(shown, cond)
+		}
+		if len(shown) == 0 && (problemsOnly || len(types) > 0) {
+			return nil
+		}
+		r.Conditions = shown
+		resp.Items = append(resp.Items, newReportDocument(r))
+		resp.Summary.add(r.Conditions)
+		return nil
+	})
+	if err != nil {
+		failures = append(failures, err)
+	}
+	if len(resp.Items) == 0 && len(failures) > 0 {
+		writeAPIError(w, apiErrorStatus(failures[0]), errors.Join(failures...))
+		return
+	}
+	for _, err := range failures {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// apiErrorStatus returns the HTTP status to respond with for a failed query,
+// passing through the status of the API server's errors (e.g. 403 when not
+// allowed to list the objects).
+func apiErrorStatus(err error) int {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code != 0 {
+		return int(status.Status().Code)
+	}
+	return http.StatusBadGateway
+}
+
+func writeAPIError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
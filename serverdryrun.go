@@ -0,0 +1,170 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/ptr"
+)
+
+// serverDryRunFieldManager is the field manager of the dry-run applies.
+const serverDryRunFieldManager = "kubectl-cond"
+
+// runServerDryRun submits the objects in the files as server-side dry-run
+// applies, and prints the conditions of their live state along with what
+// the server would do with the change.
+func runServerDryRun(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, args []string) error {
+	if len(args) > 0 {
+		return errors.New("resource arguments are not supported with --server-dry-run, specify the files with -f")
+	}
+	if len(filenameOpts.Filenames) == 0 && filenameOpts.Kustomize == "" {
+		return errors.New("--server-dry-run requires objects to be specified with -f or --kustomize")
+	}
+	if localFlag || watchFlag || waitForFlag != "" || rolloutFlag || treeFlag {
+		return errors.New("--local, --watch, --wait-for, --rollout and --tree are not supported with --server-dry-run")
+	}
+	dyn, err := kc.Dynamic()
+	if err != nil {
+		return err
+	}
+	rb, err := newResourceBuilder(configFlags)
+	if err != nil {
+		return err
+	}
+	var reports []*objectReport
+	err = rb.FilenameParam(false, filenameOpts).
+		Flatten().
+		Do().
+		Visit(func(info *resource.Info, err error) error {
+			if err != nil {
+				return err
+			}
+			r, err := serverDryRunReport(ctx, kc, dyn.Resource(info.Mapping.Resource).Namespace(info.Namespace), info)
+			if err != nil {
+				return fmt.Errorf("failed to dry-run object %s %s: %w", info.Object.GetObjectKind().GroupVersionKind().Kind, info.Name, err)
+			}
+			reports = append(reports, r)
+			return nil
+		})
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeoutFlag)
+	}
+	if err != nil {
+		return err
+	}
+
+	p, err := newPrinter(stdout)
+	if err != nil {
+		return err
+	}
+	for _, r := range reports {
+		if err := p.Print(r); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+// serverDryRunReport returns the report of the live state of the object
+// with the outcome of applying the object in the file prepended to its
+// header. Objects that don't exist yet are reported as the server would
+// create them.
+func serverDryRunReport(ctx context.Context, kc *kubeClient, client dynamic.ResourceInterface, info *resource.Info) (*objectReport, error) {
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", info.Object)
+	}
+	live, err := client.Get(ctx, info.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return nil, err
+	}
+	applied, applyErr := client.Patch(ctx, info.Name, types.ApplyPatchType, applyPatch(u), metav1.PatchOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		FieldManager: serverDryRunFieldManager,
+		Force:        ptr.To(true),
+	})
+	if applyErr != nil && !apierrors.IsInvalid(applyErr) && !apierrors.IsForbidden(applyErr) && !apierrors.IsBadRequest(applyErr) {
+		return nil, applyErr
+	}
+
+	obj := live
+	if obj == nil {
+		obj = applied
+	}
+	if obj == nil {
+		obj = u
+	}
+	r, err := newObjectReport(ctx, kc, obj)
+	if err != nil && !errors.Is(err, errNoConditions) {
+		return nil, err
+	}
+	r.Header = append([]string{serverDryRunOutcome(live, applied, applyErr)}, r.Header...)
+	return r, nil
+}
+
+// applyPatch returns the object as an apply patch. The fields set by the
+// server are removed, so that objects exported with kubectl get -o yaml can
+// be applied as well.
+func applyPatch(u *unstructured.Unstructured) []byte {
+	u = u.DeepCopy()
+	for _, field := range []string{"resourceVersion", "uid", "generation", "creationTimestamp", "managedFields"} {
+		unstructured.RemoveNestedField(u.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(u.Object, "status")
+	b, _ := u.MarshalJSON()
+	return b
+}
+
+// serverDryRunOutcome describes what the server would do when the object is
+// applied.
+func serverDryRunOutcome(live, applied *unstructured.Unstructured, applyErr error) string {
+	const prefix = "Server dry-run: "
+	switch {
+	case applyErr != nil:
+		return activeTheme.unhealthy.Sprint(prefix + "rejected: " + applyErr.Error())
+	case live == nil:
+		return activeTheme.warning.Sprint(prefix + "would be created, conditions are set once its controllers act on it")
+	case applied.GetGeneration() != live.GetGeneration():
+		return activeTheme.warning.Sprintf(prefix+"would change the spec (generation %d → %d), the conditions below will be re-evaluated",
+			live.GetGeneration(), applied.GetGeneration())
+	case !equality.Semantic.DeepEqual(withoutServerFields(live), withoutServerFields(applied)):
+		return prefix + "would change the object without changing its spec, the conditions below are not expected to change"
+	default:
+		return activeTheme.healthy.Sprint(prefix + "no changes")
+	}
+}
+
+// withoutServerFields returns the object without the fields updated by the
+// server on every write.
+func withoutServerFields(u *unstructured.Unstructured) map[string]any {
+	u = u.DeepCopy()
+	for _, field := range []string{"resourceVersion", "managedFields"} {
+		unstructured.RemoveNestedField(u.Object, "metadata", field)
+	}
+	return u.Object
+}
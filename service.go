@@ -0,0 +1,148 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+)
+
+// enrichService adds a synthetic condition summarizing the readiness of the
+// endpoints in the Service's EndpointSlices.
+func enrichService(ctx context.Context, kc *kubeClient, r *objectReport) {
+	svcType, _, _ := unstructured.NestedString(r.Object.Object, "spec", "type")
+	if svcType == "ExternalName" || kc == nil {
+		return
+	}
+	cond := GenericCondition{
+		Type:      "EndpointsReady",
+		Synthetic: true,
+	}
+
+	ready, notReady, notReadyTargets, err := serviceEndpoints(ctx, kc, r.Namespace, r.Name)
+	switch {
+	case err != nil:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "EndpointSlicesUnavailable"
+		cond.Message = err.Error()
+	case ready+notReady == 0:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "NoEndpoints"
+		cond.Message = "no endpoints found for the service"
+		if selector, _, _ := unstructured.NestedStringMap(r.Object.Object, "spec", "selector"); len(selector) == 0 {
+			cond.Message += " (service has no selector)"
+		} else {
+			cond.Message += ", check that the selector matches any running pods"
+		}
+	case ready == 0:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "NoReadyEndpoints"
+		cond.Message = fmt.Sprintf("none of the %d endpoints are ready", notReady)
+	default:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "EndpointsReady"
+		cond.Message = fmt.Sprintf("%d ready, %d not ready endpoints", ready, notReady)
+	}
+	if len(notReadyTargets) > 0 {
+		cond.Notes = append(cond.Notes, fmt.Sprintf("Not ready: %s", strings.Join(notReadyTargets, ", ")))
+	}
+	r.Conditions = append(r.Conditions, cond)
+}
+
+// serviceEndpoints counts the ready and not ready endpoints of the service
+// and returns the names of the objects (usually pods) backing the endpoints
+// that are not ready.
+func serviceEndpoints(ctx context.Context, kc *kubeClient, namespace, name string) (ready, notReady int, notReadyTargets []string, err error) {
+	cs, err := kc.Clientset()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	slices, err := cs.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + name,
+	})
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("failed to list EndpointSlices: %w", err)
+	}
+	// dual-stack services have a slice per address family, listing each
+	// pod in both of them
+	seen := make(map[string]bool)
+	for _, slice := range slices.Items {
+		for _, ep := range slice.Endpoints {
+			key := strings.Join(ep.Addresses, ",")
+			if ep.TargetRef != nil && ep.TargetRef.UID != "" {
+				key = string(ep.TargetRef.UID)
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			// nil readiness should be interpreted as ready
+			if ptr.Deref(ep.Conditions.Ready, true) {
+				ready++
+				continue
+			}
+			notReady++
+			target := strings.Join(ep.Addresses, ",")
+			if ep.TargetRef != nil {
+				target = strings.ToLower(ep.TargetRef.Kind) + "/" + ep.TargetRef.Name
+			}
+			notReadyTargets = append(notReadyTargets, target)
+		}
+	}
+	return ready, notReady, notReadyTargets, nil
+}
+
+// enrichIngress adds a synthetic condition reflecting whether a load
+// balancer address has been assigned to the Ingress, as Ingresses don't have
+// conditions.
+func enrichIngress(r *objectReport) {
+	cond := GenericCondition{
+		Type:      "LoadBalancerAssigned",
+		Status:    metav1.ConditionTrue,
+		Reason:    "AddressAssigned",
+		Synthetic: true,
+	}
+	lbIngress, _, _ := unstructured.NestedSlice(r.Object.Object, "status", "loadBalancer", "ingress")
+	var addresses []string
+	for _, v := range lbIngress {
+		lb, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if ip, _, _ := unstructured.NestedString(lb, "ip"); ip != "" {
+			addresses = append(addresses, ip)
+		}
+		if hostname, _, _ := unstructured.NestedString(lb, "hostname"); hostname != "" {
+			addresses = append(addresses, hostname)
+		}
+	}
+	if len(addresses) > 0 {
+		cond.Message = fmt.Sprintf("address: %s", strings.Join(addresses, ", "))
+	} else {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "AddressPending"
+		cond.Message = "no load balancer address has been assigned yet"
+		if class, _, _ := unstructured.NestedString(r.Object.Object, "spec", "ingressClassName"); class != "" {
+			cond.Message += fmt.Sprintf(", check the controller of ingress class %q", class)
+		}
+	}
+	r.Conditions = append(r.Conditions, cond)
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestServiceEndpointsDualStack(t *testing.T) {
+	endpoint := func(addr, pod string, ready bool) discoveryv1.Endpoint {
+		ep := discoveryv1.Endpoint{Addresses: []string{addr}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(ready)}}
+		if pod != "" {
+			ep.TargetRef = &corev1.ObjectReference{Kind: "Pod", Name: pod, UID: types.UID(pod + "-uid")}
+		}
+		return ep
+	}
+	slice := func(name string, family discoveryv1.AddressType, eps ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta:  metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{discoveryv1.LabelServiceName: "web"}},
+			AddressType: family,
+			Endpoints:   eps,
+		}
+	}
+	cs := fake.NewSimpleClientset(
+		slice("web-v4", discoveryv1.AddressTypeIPv4,
+			endpoint("10.0.0.1", "web-1", true),
+			endpoint("10.0.0.2", "web-2", false),
+			endpoint("10.0.0.9", "", false)),
+		slice("web-v6", discoveryv1.AddressTypeIPv6,
+			endpoint("fd00::1", "web-1", true),
+			endpoint("fd00::2", "web-2", false)),
+	)
+	kc := &kubeClient{clientset: cs}
+	kc.once.Do(func() {})
+
+	ready, notReady, targets, err := serviceEndpoints(context.Background(), kc, "default", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ready != 1 || notReady != 2 {
+		t.Errorf("got %d ready and %d not ready endpoints, want 1 and 2", ready, notReady)
+	}
+	slices.Sort(targets)
+	if want := []string{"10.0.0.9", "pod/web-2"}; !slices.Equal(targets, want) {
+		t.Errorf("not ready targets = %v, want %v", targets, want)
+	}
+}
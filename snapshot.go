@@ -0,0 +1,312 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+)
+
+// snapshotVersion is the version of the snapshot bundle format.
+const snapshotVersion = 1
+
+// The files in a snapshot bundle. The objects are stored under
+// snapshotObjectsDir as YAML files.
+const (
+	snapshotFile       = "snapshot.json"
+	snapshotReportFile = "report.txt"
+	snapshotObjectsDir = "objects"
+)
+
+// snapshot is the content of snapshot.json in a snapshot bundle.
+type snapshot struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	Cluster string    `json:"cluster,omitempty"`
+	// Command is the command line the snapshot was taken with.
+	Command string           `json:"command"`
+	Tool    string           `json:"kubectlCondVersion"`
+	Reports []snapshotReport `json:"reports"`
+}
+
+// snapshotReport is an object report stored in a snapshot bundle, from which
+// the report can be printed again as it was when the snapshot was taken,
+// including the context looked up from the API server.
+type snapshotReport struct {
+	// Object is the path of the object in the bundle.
+	Object     string              `json:"object"`
+	Header     []string            `json:"header,omitempty"`
+	Conditions []snapshotCondition `json:"conditions"`
+}
+
+type snapshotCondition struct {
+	GenericCondition
+	NegativePolarity bool `json:"negativePolarity,omitempty"`
+}
+
+func newSnapshotCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var bundlePath string
+	cmd := &cobra.Command{
+		Use:   "snapshot <object-type>[,<object-type>...] [<object-name>...] --output <bundle.tar.gz>",
+		Short: "Save the requested objects and their condition reports into an archive to be viewed offline",
+		Long: "Save the requested objects (without their managedFields) and their condition reports, including the " +
+			"details looked up from the API server such as events with --events, into a .tar.gz bundle. " +
+			"The bundle can be attached to support tickets, and printed with the view command without cluster access.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if bundlePath == "" {
+				return errors.New("--output is required, e.g. --output bundle.tar.gz")
+			}
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+
+			kc := &kubeClient{configFlags: configFlags}
+			rb, err := newResourceBuilder(configFlags)
+			if err != nil {
+				return err
+			}
+			p, err := newSnapshotPrinter(bundlePath, snapshot{
+				Version: snapshotVersion,
+				Time:    time.Now().UTC(),
+				Cluster: currentContextName(configFlags),
+				Command: strings.Join(append([]string{"kubectl cond"}, os.Args[1:]...), " "),
+				Tool:    version,
+			})
+			if err != nil {
+				return err
+			}
+			return printVisited(ctx, kc, queryLatest(rb.ResourceTypeOrNameArgs(true, args...)), p, stderr)
+		},
+	}
+	cmd.Flags().StringVarP(&bundlePath, "output", "o", "", "Path of the .tar.gz bundle to write.")
+	return cmd
+}
+
+// snapshotPrinter collects the reports and writes them into a snapshot
+// bundle on Flush, along with the reports rendered as text.
+type snapshotPrinter struct {
+	path     string
+	snapshot snapshot
+	objects  map[string]*unstructured.Unstructured
+	text     bytes.Buffer
+	textOut  printer
+}
+
+func newSnapshotPrinter(path string, s snapshot) (*snapshotPrinter, error) {
+	p := &snapshotPrinter{path: path, snapshot: s, objects: make(map[string]*unstructured.Unstructured)}
+	// the rendered reports are meant to be read without a terminal
+	color.NoColor = true
+	textOut, err := newPrinter(&p.text)
+	if err != nil {
+		return nil, err
+	}
+	p.textOut = textOut
+	return p, nil
+}
+
+func (p *snapshotPrinter) Print(r *objectReport) error {
+	name := snapshotObjectPath(r)
+	p.objects[name] = r.Object
+	sr := snapshotReport{Object: name, Conditions: make([]snapshotCondition, 0, len(r.Conditions))}
+	for _, cond := range r.Conditions {
+		sr.Conditions = append(sr.Conditions, snapshotCondition{GenericCondition: cond, NegativePolarity: cond.NegativePolarity})
+	}
+	for _, line := range r.Header {
+		sr.Header = append(sr.Header, ansiEscapePattern.ReplaceAllString(line, ""))
+	}
+	p.snapshot.Reports = append(p.snapshot.Reports, sr)
+	return p.textOut.Print(r)
+}
+
+func (p *snapshotPrinter) Flush() error {
+	if err := p.textOut.Flush(); err != nil {
+		return err
+	}
+	f, err := os.Create(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	if err := p.write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	fmt.Fprintf(stderr, "Saved %d objects to %s\n", len(p.snapshot.Reports), p.path)
+	return nil
+}
+
+func (p *snapshotPrinter) write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: p.snapshot.Time}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	data, err := json.MarshalIndent(p.snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := add(snapshotFile, data); err != nil {
+		return err
+	}
+	if err := add(snapshotReportFile, p.text.Bytes()); err != nil {
+		return err
+	}
+	for _, r := range p.snapshot.Reports {
+		data, err := yaml.Marshal(p.objects[r.Object].Object)
+		if err != nil {
+			return err
+		}
+		if err := add(r.Object, data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// snapshotObjectPath returns the path of the object in the bundle, e.g.
+// objects/deployment.apps/default/web.yaml.
+func snapshotObjectPath(r *objectReport) string {
+	gk := strings.ToLower(r.Object.GroupVersionKind().GroupKind().String())
+	if r.Namespace == "" {
+		return path.Join(snapshotObjectsDir, gk, r.Name+".yaml")
+	}
+	return path.Join(snapshotObjectsDir, gk, r.Namespace, r.Name+".yaml")
+}
+
+func newViewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "view <bundle.tar.gz>",
+		Short: "Print the condition reports saved in a bundle by the snapshot command",
+		Long: "Print the condition reports saved in a bundle by the snapshot command as they were when the snapshot was " +
+			"taken, without cluster access. The output flags (such as -o, --problems-only and --type) work as usual.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, reports, err := readSnapshot(args[0])
+			if err != nil {
+				return err
+			}
+			taken := fmt.Sprintf("Snapshot taken %s (%s)", relativeTime(s.Time), absoluteTime(s.Time))
+			if s.Cluster != "" {
+				taken += " from " + s.Cluster
+			}
+			fmt.Fprintln(stderr, gray.Sprintf("%s with: %s", taken, s.Command))
+
+			p, err := newPrinter(stdout)
+			if err != nil {
+				return err
+			}
+			var counts healthCounts
+			for _, r := range reports {
+				counts.add(r.Conditions)
+				if err := p.Print(r); err != nil {
+					return err
+				}
+			}
+			if err := p.Flush(); err != nil {
+				return err
+			}
+			if failOnUnhealthyFlag && counts.UnhealthyObjects > 0 {
+				return errUnhealthy
+			}
+			return nil
+		},
+	}
+}
+
+// readSnapshot reads the snapshot bundle and returns the reports in it.
+func readSnapshot(name string) (*snapshot, []*objectReport, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not a snapshot bundle: %w", name, err)
+	}
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		files[hdr.Name] = data
+	}
+
+	data, ok := files[snapshotFile]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a snapshot bundle: %s is missing", name, snapshotFile)
+	}
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", snapshotFile, err)
+	}
+	if s.Version > snapshotVersion {
+		return nil, nil, fmt.Errorf("the bundle was created by a newer version of kubectl cond (%s), upgrade to view it", s.Tool)
+	}
+	reports := make([]*objectReport, 0, len(s.Reports))
+	for _, sr := range s.Reports {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(files[sr.Object], &obj.Object); err != nil || obj.Object == nil {
+			return nil, nil, fmt.Errorf("failed to read %s from the bundle: %v", sr.Object, err)
+		}
+		r := &objectReport{
+			Object:    obj,
+			Kind:      obj.GetKind(),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Header:    sr.Header,
+		}
+		for _, cond := range sr.Conditions {
+			cond.GenericCondition.NegativePolarity = cond.NegativePolarity
+			r.Conditions = append(r.Conditions, cond.GenericCondition)
+		}
+		reports = append(reports, r)
+	}
+	return &s, reports, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/ahmetb/kubectl-cond/pkg/conditions"
+)
+
+// conditionOrders are the orders selectable with --sort-by.
+var conditionOrders = map[string]conditions.Order{
+	"severity":           bySeverity,
+	"type":               conditions.ByType,
+	"lastTransitionTime": conditions.ByLastTransitionTime,
+	"status":             conditions.ByStatus,
+}
+
+func conditionOrderNames() []string {
+	return []string{"severity", "type", "lastTransitionTime", "status"}
+}
+
+// sortConditions sorts the conditions in the order selected with --sort-by
+// and --reverse.
+func sortConditions(conds []GenericCondition) {
+	less, ok := conditionOrders[sortByFlag]
+	if !ok {
+		less = bySeverity
+	}
+	conditions.Sort(conds, less, reverseFlag)
+}
+
+// bySeverity orders the conditions by severity with --treat-unknown-as, and
+// lists the synthetic container conditions after the pod's own conditions.
+func bySeverity(i, j GenericCondition) bool {
+	if ci, cj := isContainerCondition(i.Type), isContainerCondition(j.Type); ci != cj {
+		return cj
+	}
+	return conditions.BySeverity(unknownAs())(i, j)
+}
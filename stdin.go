@@ -0,0 +1,119 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// stdinFilename is the filename that reads objects from stdin.
+const stdinFilename = "-"
+
+// newStreamSource returns a watch source that reads the objects (or watch
+// events) from r as they arrive, such as the output of
+// "kubectl get -w -o json". The source reports io.EOF once r is exhausted.
+func newStreamSource(r io.Reader) watchSource {
+	started := false
+	return func() (watch.Interface, error) {
+		if started {
+			return nil, io.EOF
+		}
+		started = true
+		return watch.NewStreamWatcher(&objectStreamDecoder{d: yaml.NewYAMLOrJSONDecoder(r, 4096), r: r}, streamErrorReporter{}), nil
+	}
+}
+
+// streamErrorReporter reports errors reading the stream as watch errors.
+type streamErrorReporter struct{}
+
+func (streamErrorReporter) AsObject(err error) runtime.Object {
+	return &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: fmt.Sprintf("failed to read objects from stdin: %v", err),
+	}
+}
+
+// objectStreamDecoder decodes a stream of JSON or YAML documents into watch
+// events. Documents can be objects, lists of objects (which are expanded) or
+// watch events (as printed by kubectl get --output-watch-events).
+type objectStreamDecoder struct {
+	r       io.Reader
+	d       *yaml.YAMLOrJSONDecoder
+	pending []watch.Event
+}
+
+func (d *objectStreamDecoder) Decode() (watch.EventType, runtime.Object, error) {
+	for len(d.pending) == 0 {
+		var doc map[string]any
+		if err := d.d.Decode(&doc); err != nil {
+			return "", nil, err
+		}
+		if doc == nil {
+			continue
+		}
+		d.pending = streamEvents(doc)
+	}
+	ev := d.pending[0]
+	d.pending = d.pending[1:]
+	return ev.Type, ev.Object, nil
+}
+
+func (d *objectStreamDecoder) Close() {
+	if c, ok := d.r.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// streamEvents returns the watch events for a document read from a stream.
+func streamEvents(doc map[string]any) []watch.Event {
+	if typ, ok := doc["type"].(string); ok {
+		if obj, ok := doc["object"].(map[string]any); ok {
+			return []watch.Event{{Type: watch.EventType(strings.ToUpper(typ)), Object: &unstructured.Unstructured{Object: obj}}}
+		}
+	}
+	if items, ok := doc["items"].([]any); ok {
+		var out []watch.Event
+		for _, item := range items {
+			if obj, ok := item.(map[string]any); ok {
+				out = append(out, watch.Event{Type: watch.Modified, Object: &unstructured.Unstructured{Object: obj}})
+			}
+		}
+		return out
+	}
+	return []watch.Event{{Type: watch.Modified, Object: &unstructured.Unstructured{Object: doc}}}
+}
+
+// splitStdinFilename returns the filenames without the stdin filename, and
+// whether it was present.
+func splitStdinFilename(filenames []string) ([]string, bool) {
+	var out []string
+	var found bool
+	for _, f := range filenames {
+		if f == stdinFilename {
+			found = true
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, found
+}
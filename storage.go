@@ -0,0 +1,175 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// enrichPersistentVolumeClaim adds a synthetic condition for the binding
+// phase of the claim, in addition to the resize conditions the claim may
+// report.
+func enrichPersistentVolumeClaim(r *objectReport) {
+	obj := r.Object.Object
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	volumeName, _, _ := unstructured.NestedString(obj, "spec", "volumeName")
+	storageClass, _, _ := unstructured.NestedString(obj, "spec", "storageClassName")
+	capacity, _, _ := unstructured.NestedString(obj, "status", "capacity", "storage")
+
+	cond := GenericCondition{
+		Type:      "Bound",
+		Reason:    phase,
+		Synthetic: true,
+	}
+	switch phase {
+	case "Bound":
+		cond.Status = metav1.ConditionTrue
+		cond.Message = fmt.Sprintf("bound to PersistentVolume %s", volumeName)
+		if capacity != "" {
+			cond.Message += fmt.Sprintf(" (capacity: %s)", capacity)
+		}
+	case "Pending":
+		cond.Status = metav1.ConditionFalse
+		cond.Message = "waiting for a volume to be provisioned or bound"
+		cond.Warning = true
+	case "Lost":
+		cond.Status = metav1.ConditionFalse
+		cond.Message = fmt.Sprintf("the bound PersistentVolume %s no longer exists", volumeName)
+	default:
+		cond.Status = metav1.ConditionUnknown
+	}
+	if storageClass != "" {
+		cond.Notes = append(cond.Notes, fmt.Sprintf("Storage class: %s", storageClass))
+	}
+	r.Conditions = append(r.Conditions, cond)
+}
+
+// enrichVolumeSnapshot adds a synthetic condition for the readiness of a
+// VolumeSnapshot or VolumeSnapshotContent, which report readyToUse and the
+// last snapshotting error instead of conditions.
+func enrichVolumeSnapshot(r *objectReport) {
+	obj := r.Object.Object
+	readyToUse, found, _ := unstructured.NestedBool(obj, "status", "readyToUse")
+	errMessage, hasErr, _ := unstructured.NestedString(obj, "status", "error", "message")
+	errTime, _, _ := unstructured.NestedString(obj, "status", "error", "time")
+
+	cond := GenericCondition{
+		Type:      "ReadyToUse",
+		Synthetic: true,
+	}
+	switch {
+	case hasErr:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "SnapshotError"
+		cond.Message = errMessage
+		if t, err := time.Parse(time.RFC3339, errTime); err == nil {
+			cond.LastTransitionTime = &metav1.Time{Time: t}
+		}
+	case readyToUse:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "SnapshotReady"
+	case found:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "SnapshotPending"
+		cond.Message = "waiting for the snapshot to be taken"
+		cond.Warning = true
+	default:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "NoStatus"
+		cond.Message = "the snapshot controller has not processed the snapshot yet"
+	}
+
+	if r.Kind == "VolumeSnapshot" {
+		if content, _, _ := unstructured.NestedString(obj, "status", "boundVolumeSnapshotContentName"); content != "" {
+			cond.Notes = append(cond.Notes, fmt.Sprintf("Content: %s", content))
+		}
+		if size, _, _ := unstructured.NestedString(obj, "status", "restoreSize"); size != "" {
+			cond.Notes = append(cond.Notes, fmt.Sprintf("Restore size: %s", size))
+		}
+	} else {
+		if handle, _, _ := unstructured.NestedString(obj, "status", "snapshotHandle"); handle != "" {
+			cond.Notes = append(cond.Notes, fmt.Sprintf("Snapshot handle: %s", handle))
+		}
+		if size, ok, _ := unstructured.NestedInt64(obj, "status", "restoreSize"); ok {
+			cond.Notes = append(cond.Notes, fmt.Sprintf("Restore size: %s", humanize.IBytes(uint64(size))))
+		}
+	}
+	r.Conditions = append(r.Conditions, cond)
+}
+
+// enrichVolumeAttachment adds synthetic conditions for the attach and detach
+// status of a CSI VolumeAttachment, whose errors are otherwise only visible
+// in its status fields.
+func enrichVolumeAttachment(r *objectReport) {
+	obj := r.Object.Object
+	attached, _, _ := unstructured.NestedBool(obj, "status", "attached")
+	nodeName, _, _ := unstructured.NestedString(obj, "spec", "nodeName")
+	attacher, _, _ := unstructured.NestedString(obj, "spec", "attacher")
+	pvName, _, _ := unstructured.NestedString(obj, "spec", "source", "persistentVolumeName")
+
+	cond := GenericCondition{
+		Type:      "Attached",
+		Synthetic: true,
+	}
+	if attached {
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "VolumeAttached"
+	} else if errCond, ok := volumeAttachmentError(obj, "attachError"); ok {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "AttachError"
+		cond.Message = errCond.Message
+		cond.LastTransitionTime = errCond.LastTransitionTime
+	} else {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "Attaching"
+		cond.Message = "waiting for the volume to be attached"
+		cond.Warning = true
+	}
+	cond.Notes = append(cond.Notes, fmt.Sprintf("PersistentVolume: %s, node: %s, attacher: %s", pvName, nodeName, attacher))
+	r.Conditions = append(r.Conditions, cond)
+
+	if errCond, ok := volumeAttachmentError(obj, "detachError"); ok {
+		r.Conditions = append(r.Conditions, GenericCondition{
+			Type:               "Detached",
+			Status:             metav1.ConditionFalse,
+			Reason:             "DetachError",
+			Message:            errCond.Message,
+			LastTransitionTime: errCond.LastTransitionTime,
+			Synthetic:          true,
+		})
+	}
+}
+
+// volumeAttachmentError returns the message and time of the attachError or
+// detachError of a VolumeAttachment.
+func volumeAttachmentError(obj map[string]any, field string) (GenericCondition, bool) {
+	message, ok, _ := unstructured.NestedString(obj, "status", field, "message")
+	if !ok {
+		return GenericCondition{}, false
+	}
+	var cond GenericCondition
+	cond.Message = message
+	if ts, _, _ := unstructured.NestedString(obj, "status", field, "time"); ts != "" {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			cond.LastTransitionTime = &metav1.Time{Time: t}
+		}
+	}
+	return cond, true
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var summaryFlag bool
+
+// objectSummaryPrinter buffers all reports and prints one line per object
+// with the counts of its conditions by health and its worst condition,
+// unhealthy objects first.
+type objectSummaryPrinter struct {
+	w       io.Writer
+	reports []*objectReport
+}
+
+func (p *objectSummaryPrinter) Print(r *objectReport) error {
+	p.reports = append(p.reports, r)
+	return nil
+}
+
+// objectSummary is the health of the conditions of an object.
+type objectSummary struct {
+	report           *objectReport
+	bad, unknown, ok int
+	worst            *GenericCondition
+	worstUnknown     *GenericCondition
+}
+
+func summarizeObject(r *objectReport) objectSummary {
+	s := objectSummary{report: r}
+	// conditions may be sorted differently with --sort-by, so the worst one
+	// is picked by severity
+	for i, cond := range r.Conditions {
+		switch semanticStatus(cond) {
+		case metav1.ConditionTrue:
+			s.ok++
+		case metav1.ConditionUnknown:
+			s.unknown++
+			if s.worstUnknown == nil || bySeverity(cond, *s.worstUnknown) {
+				s.worstUnknown = &r.Conditions[i]
+			}
+		default:
+			s.bad++
+			if s.worst == nil || bySeverity(cond, *s.worst) {
+				s.worst = &r.Conditions[i]
+			}
+		}
+	}
+	if s.worst == nil {
+		s.worst = s.worstUnknown
+	}
+	return s
+}
+
+func (p *objectSummaryPrinter) Flush() error {
+	if len(p.reports) == 0 {
+		return nil
+	}
+	summaries := make([]objectSummary, 0, len(p.reports))
+	namespaced := false
+	for _, r := range p.reports {
+		summaries = append(summaries, summarizeObject(r))
+		namespaced = namespaced || r.Namespace != ""
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.bad != b.bad {
+			return a.bad > b.bad
+		}
+		return a.unknown > b.unknown
+	})
+
+	header := []string{"Object", "Conditions", "Worst"}
+	if namespaced {
+		header = append([]string{"Namespace"}, header...)
+	}
+	table := newPlainTable(p.w, header...)
+	for _, s := range summaries {
+		row := []string{strings.ToLower(s.report.Kind) + "/" + s.report.Name, formatHealthSummary(s), "-"}
+		if s.worst != nil {
+			cond := *s.worst
+			row[2] = statusColor(cond)(cond.Type + "=" + string(cond.Status))
+			if cond.Reason != "" {
+				row[2] += " (" + cond.Reason + ")"
+			}
+		}
+		if namespaced {
+			row = append([]string{s.report.Namespace}, row...)
+		}
+		table.Append(row)
+	}
+	table.Render()
+	return nil
+}
+
+func formatHealthSummary(s objectSummary) string {
+	var parts []string
+	if s.bad > 0 {
+		parts = append(parts, activeTheme.unhealthy.Sprintf("%d bad", s.bad))
+	}
+	if s.unknown > 0 {
+		parts = append(parts, gray.Sprintf("%d unknown", s.unknown))
+	}
+	if s.ok > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%d ok", s.ok))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,184 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// newTemplatePrinter returns the printer for the template based output
+// formats (e.g. -o jsonpath=...), which are applied to the machine-readable
+// form of each object's report. ok is false for other formats.
+func newTemplatePrinter(w io.Writer, output string) (p printer, ok bool, err error) {
+	format, arg, _ := strings.Cut(output, "=")
+	switch format {
+	case "go-template":
+		if arg == "" {
+			return nil, true, fmt.Errorf("template format specified but no template given")
+		}
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return nil, true, fmt.Errorf("error parsing template %s: %w", arg, err)
+		}
+		return &templatePrinter{w: w, execute: tmpl.Execute}, true, nil
+	case "jsonpath":
+		if arg == "" {
+			return nil, true, fmt.Errorf("jsonpath format specified but no expression given")
+		}
+		jp, err := newJSONPath(arg)
+		if err != nil {
+			return nil, true, err
+		}
+		return &templatePrinter{w: w, execute: jp.Execute}, true, nil
+	case "custom-columns":
+		p, err := newCustomColumnsPrinter(w, arg)
+		return p, true, err
+	}
+	return nil, false, nil
+}
+
+func newJSONPath(expr string) (*jsonpath.JSONPath, error) {
+	jp := jsonpath.New("output").AllowMissingKeys(true)
+	if err := jp.Parse(relaxedJSONPath(expr)); err != nil {
+		return nil, fmt.Errorf("error parsing jsonpath %s: %w", expr, err)
+	}
+	return jp, nil
+}
+
+// relaxedJSONPath allows the JSONPath expressions to be specified without
+// the surrounding braces and the leading dot, like kubectl does, e.g.
+// "conditions[0].type" for "{.conditions[0].type}".
+func relaxedJSONPath(expr string) string {
+	if strings.Contains(expr, "{") {
+		return expr
+	}
+	return "{." + strings.TrimPrefix(expr, ".") + "}"
+}
+
+// reportData returns the report in the same form as -o json, for templates
+// to refer to the fields by their JSON names.
+func reportData(r *objectReport) (map[string]any, error) {
+	b, err := json.Marshal(newReportDocument(r))
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// templatePrinter executes a template for each object's report.
+type templatePrinter struct {
+	w       io.Writer
+	execute func(io.Writer, any) error
+}
+
+func (p *templatePrinter) Print(r *objectReport) error {
+	data, err := reportData(r)
+	if err != nil {
+		return err
+	}
+	if err := p.execute(p.w, data); err != nil {
+		return fmt.Errorf("error executing template: %w", err)
+	}
+	return nil
+}
+
+func (p *templatePrinter) Flush() error { return nil }
+
+// customColumn is a column of -o custom-columns.
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+// customColumnsPrinter buffers all reports and prints a table with a row
+// per object, with columns specified as HEADER:JSONPATH pairs.
+type customColumnsPrinter struct {
+	w       io.Writer
+	columns []customColumn
+	rows    [][]string
+}
+
+func newCustomColumnsPrinter(w io.Writer, spec string) (*customColumnsPrinter, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns format specified but no custom columns given")
+	}
+	p := &customColumnsPrinter{w: w}
+	for _, col := range strings.Split(spec, ",") {
+		header, expr, ok := strings.Cut(col, ":")
+		if !ok || header == "" || expr == "" {
+			return nil, fmt.Errorf("unexpected custom-columns spec: %s, expected <header>:<json-path-expr>", col)
+		}
+		jp, err := newJSONPath(expr)
+		if err != nil {
+			return nil, err
+		}
+		p.columns = append(p.columns, customColumn{header: header, path: jp})
+	}
+	return p, nil
+}
+
+func (p *customColumnsPrinter) Print(r *objectReport) error {
+	data, err := reportData(r)
+	if err != nil {
+		return err
+	}
+	row := make([]string, 0, len(p.columns))
+	for _, col := range p.columns {
+		results, err := col.path.FindResults(data)
+		if err != nil {
+			return fmt.Errorf("error evaluating column %s: %w", col.header, err)
+		}
+		var values []string
+		for _, res := range results {
+			for _, v := range res {
+				var b bytes.Buffer
+				if err := col.path.PrintResults(&b, []reflect.Value{v}); err != nil {
+					return err
+				}
+				values = append(values, b.String())
+			}
+		}
+		if len(values) == 0 {
+			values = []string{"<none>"}
+		}
+		row = append(row, strings.Join(values, ","))
+	}
+	p.rows = append(p.rows, row)
+	return nil
+}
+
+func (p *customColumnsPrinter) Flush() error {
+	headers := make([]string, 0, len(p.columns))
+	for _, col := range p.columns {
+		headers = append(headers, col.header)
+	}
+	table := newPlainTable(p.w, headers...)
+	table.SetAutoFormatHeaders(false)
+	table.AppendBulk(p.rows)
+	table.Render()
+	return nil
+}
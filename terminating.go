@@ -0,0 +1,52 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// enrichTerminating adds a synthetic Terminating condition for objects that
+// have been marked for deletion. Deletions that take longer than the
+// configured threshold are reported as stuck.
+func enrichTerminating(r *objectReport) {
+	deletionTimestamp := r.Object.GetDeletionTimestamp()
+	if deletionTimestamp == nil {
+		return
+	}
+	age := time.Since(deletionTimestamp.Time)
+
+	cond := GenericCondition{
+		Type:               "Terminating",
+		Status:             metav1.ConditionTrue,
+		Reason:             "DeletionInProgress",
+		Message:            fmt.Sprintf("object has been terminating for %s", duration.HumanDuration(age)),
+		LastTransitionTime: deletionTimestamp,
+		Synthetic:          true,
+		Warning:            true,
+	}
+	if age > terminatingThresholdFlag {
+		cond.Reason = "DeletionStuck"
+		cond.Message = fmt.Sprintf("object has been terminating for %s, longer than the %s threshold",
+			duration.HumanDuration(age), terminatingThresholdFlag)
+		cond.Warning = false
+	}
+	cond.Notes = finalizerNotes(r.Object.GetFinalizers(), !cond.Warning)
+	r.Conditions = append(r.Conditions, cond)
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var themeFlag string
+var iconsFlag bool
+
+// theme holds the colors used for the health of conditions.
+type theme struct {
+	healthy, unhealthy, warning, unknown *color.Color
+	// muted is used for secondary information, such as notes and stale or
+	// Unknown conditions.
+	muted *color.Color
+}
+
+var themes = map[string]theme{
+	"default": {
+		healthy:   color.New(color.FgGreen),
+		unhealthy: color.New(color.FgRed),
+		warning:   color.New(color.FgYellow),
+		unknown:   color.New(color.FgHiBlack),
+		muted:     color.New(color.FgHiBlack),
+	},
+	// bright colors for dark backgrounds, where dark gray is hard to read
+	"dark": {
+		healthy:   color.New(color.FgHiGreen),
+		unhealthy: color.New(color.FgHiRed),
+		warning:   color.New(color.FgHiYellow),
+		unknown:   color.New(color.FgWhite),
+		muted:     color.New(color.FgWhite),
+	},
+	// no yellow, which is unreadable on light backgrounds
+	"light": {
+		healthy:   color.New(color.FgGreen),
+		unhealthy: color.New(color.FgRed),
+		warning:   color.New(color.FgMagenta),
+		unknown:   color.New(color.FgBlue),
+		muted:     color.New(color.FgHiBlack),
+	},
+	// only emphasis, for terminals (or readers) that can't tell colors
+	// apart; status icons are shown by default
+	"mono": {
+		healthy:   color.New(color.Reset),
+		unhealthy: color.New(color.Bold),
+		warning:   color.New(color.Underline),
+		unknown:   color.New(color.Faint),
+		muted:     color.New(color.Faint),
+	},
+}
+
+var activeTheme = themes["default"]
+
+// statusIcons are the glyphs shown before condition types with --icons,
+// keyed by health.
+var statusIcons = map[string]string{
+	"healthy":   "✔",
+	"unhealthy": "✖",
+	"warning":   "!",
+	"unknown":   "?",
+}
+
+// themeColorNames maps the color names accepted in the config file to
+// their attributes.
+var themeColorNames = map[string]color.Attribute{
+	"black": color.FgBlack, "red": color.FgRed, "green": color.FgGreen, "yellow": color.FgYellow,
+	"blue": color.FgBlue, "magenta": color.FgMagenta, "cyan": color.FgCyan, "white": color.FgWhite,
+	"hiblack": color.FgHiBlack, "hired": color.FgHiRed, "higreen": color.FgHiGreen, "hiyellow": color.FgHiYellow,
+	"hiblue": color.FgHiBlue, "himagenta": color.FgHiMagenta, "hicyan": color.FgHiCyan, "hiwhite": color.FgHiWhite,
+	"bold": color.Bold, "faint": color.Faint, "underline": color.Underline,
+}
+
+// setupTheme selects the theme and the status icons from the flags, falling
+// back to the config file.
+func setupTheme(cfg *config, iconsChanged bool) error {
+	name := themeFlag
+	if name == "" {
+		name = cfg.Theme
+	}
+	if name == "" {
+		name = "default"
+	}
+	t, ok := themes[name]
+	if !ok {
+		names := make([]string, 0, len(themes))
+		for n := range themes {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return fmt.Errorf("unsupported --theme value %q, expected one of: (%s)", name, strings.Join(names, ", "))
+	}
+	for health, spec := range cfg.Colors {
+		c, err := parseThemeColor(spec)
+		if err != nil {
+			return fmt.Errorf("invalid color for %q in config file: %w", health, err)
+		}
+		switch health {
+		case "healthy":
+			t.healthy = c
+		case "unhealthy":
+			t.unhealthy = c
+		case "warning":
+			t.warning = c
+		case "unknown":
+			t.unknown = c
+		case "muted":
+			t.muted = c
+		default:
+			return fmt.Errorf("invalid color in config file: unknown key %q, expected one of: (healthy, unhealthy, warning, unknown, muted)", health)
+		}
+	}
+	activeTheme = t
+	gray = t.muted
+
+	if !iconsChanged {
+		iconsFlag = name == "mono"
+		if cfg.Icons != nil {
+			iconsFlag = *cfg.Icons
+		}
+	}
+	for health, icon := range cfg.Glyphs {
+		if _, ok := statusIcons[health]; !ok {
+			return fmt.Errorf("invalid glyph in config file: unknown key %q, expected one of: (healthy, unhealthy, warning, unknown)", health)
+		}
+		statusIcons[health] = icon
+	}
+	return nil
+}
+
+// parseThemeColor parses a space separated list of color names, such as
+// "bold hired".
+func parseThemeColor(spec string) (*color.Color, error) {
+	var attrs []color.Attribute
+	for _, name := range strings.Fields(strings.ToLower(spec)) {
+		attr, ok := themeColorNames[strings.ReplaceAll(name, "-", "")]
+		if !ok {
+			names := make([]string, 0, len(themeColorNames))
+			for n := range themeColorNames {
+				names = append(names, n)
+			}
+			slices.Sort(names)
+			return nil, fmt.Errorf("unknown color %q, expected one of: (%s)", name, strings.Join(names, ", "))
+		}
+		attrs = append(attrs, attr)
+	}
+	if len(attrs) == 0 {
+		return nil, fmt.Errorf("no color specified")
+	}
+	return color.New(attrs...), nil
+}
+
+// statusIcon returns the icon for the health of the condition followed by a
+// space, or nothing without --icons.
+func statusIcon(cond GenericCondition) string {
+	if !iconsFlag {
+		return ""
+	}
+	return statusIcons[healthLabel(cond)] + " "
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+var timeFormatFlag string
+var timezoneFlag string
+
+// displayLocation is the time zone that timestamps are printed in.
+var displayLocation = time.Local
+
+// setupTimeFormat validates the time display flags.
+func setupTimeFormat() error {
+	switch timeFormatFlag {
+	case "relative", "absolute", "both":
+	default:
+		return fmt.Errorf("unsupported --time-format value %q, expected one of: (relative, absolute, both)", timeFormatFlag)
+	}
+	switch timezoneFlag {
+	case "", "local", "Local":
+		displayLocation = time.Local
+	default:
+		loc, err := time.LoadLocation(timezoneFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --timezone value %q: %w", timezoneFlag, err)
+		}
+		displayLocation = loc
+	}
+	return nil
+}
+
+// relativeTime formats t relative to now, e.g. "3 hours ago".
+func relativeTime(t time.Time) string {
+	return humanize.RelTime(t, time.Now(), "ago", "from now")
+}
+
+// absoluteTime formats t as an RFC3339 timestamp in the time zone selected
+// with --timezone.
+func absoluteTime(t time.Time) string {
+	return t.In(displayLocation).Format(time.RFC3339)
+}
+
+// formatTime formats t as selected with --time-format. In the "both" format,
+// the absolute time is decorated with decorate (e.g. to print it in gray), or
+// omitted when compact is set.
+func formatTime(t time.Time, compact bool, decorate func(string) string) string {
+	switch timeFormatFlag {
+	case "relative":
+		return relativeTime(t)
+	case "absolute":
+		return absoluteTime(t)
+	}
+	if compact {
+		return relativeTime(t)
+	}
+	return fmt.Sprintf("%s %s", relativeTime(t), decorate("("+absoluteTime(t)+")"))
+}
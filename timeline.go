@@ -0,0 +1,89 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// timelinePrinter buffers all reports and prints their conditions as a
+// single chronological list, newest transition first, to tell what happened
+// when across the objects.
+type timelinePrinter struct {
+	w       io.Writer
+	entries []timelineEntry
+	kinds   map[string]bool
+}
+
+type timelineEntry struct {
+	report *objectReport
+	cond   GenericCondition
+	time   time.Time
+}
+
+func (p *timelinePrinter) Print(r *objectReport) error {
+	if p.kinds == nil {
+		p.kinds = make(map[string]bool)
+	}
+	p.kinds[r.Kind] = true
+	for _, cond := range r.Conditions {
+		// conditions without a transition time (e.g. synthetic ones) fall
+		// back to their last update, or are listed last
+		t := ptr.Deref(cond.LastTransitionTime, ptr.Deref(cond.LastUpdateTime, metav1.Time{})).Time
+		p.entries = append(p.entries, timelineEntry{report: r, cond: cond, time: t})
+	}
+	return nil
+}
+
+func (p *timelinePrinter) Flush() error {
+	if len(p.entries) == 0 {
+		return nil
+	}
+	sort.SliceStable(p.entries, func(i, j int) bool {
+		return p.entries[i].time.After(p.entries[j].time)
+	})
+
+	table := newPlainTable(p.w, "Time", "Age", "Object", "Type", "Status", "Reason")
+	now := time.Now()
+	for _, e := range p.entries {
+		when, age := "-", "-"
+		if !e.time.IsZero() {
+			when = absoluteTime(e.time)
+			age = humanize.RelTime(e.time, now, "ago", "from now")
+		}
+		name := e.report.displayName()
+		if len(p.kinds) > 1 {
+			name = strings.ToLower(e.report.Kind) + "/" + name
+		}
+		colorFn := statusColor(e.cond)
+		table.Append([]string{
+			gray.Sprint(when),
+			age,
+			name,
+			highlightRecent(e.cond, colorFn(statusIcon(e.cond)+e.cond.Type)),
+			colorFn(string(e.cond.Status)),
+			e.cond.Reason,
+		})
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	_ "modernc.org/sqlite"
+)
+
+// transitionSchema creates the table of recorded transitions. Times are
+// stored as Unix milliseconds so that they can be compared in queries.
+const transitionSchema = `
+CREATE TABLE IF NOT EXISTS transitions (
+	id          INTEGER PRIMARY KEY,
+	time        INTEGER NOT NULL,
+	cluster     TEXT NOT NULL,
+	event       TEXT NOT NULL,
+	api_version TEXT NOT NULL,
+	kind        TEXT NOT NULL,
+	namespace   TEXT NOT NULL,
+	name        TEXT NOT NULL,
+	type        TEXT NOT NULL,
+	old_status  TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	old_reason  TEXT NOT NULL,
+	reason      TEXT NOT NULL,
+	old_message TEXT NOT NULL,
+	message     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS transitions_time ON transitions (time);
+CREATE INDEX IF NOT EXISTS transitions_object ON transitions (namespace, kind, name, time);
+`
+
+// transitionDB persists the condition transitions observed by the record
+// command to a SQLite database, which the query command reads from. Unlike
+// the history recorded with --record (which only keeps status changes per
+// object), every transition of all objects is kept in one place.
+type transitionDB struct {
+	db      *sql.DB
+	cluster string
+}
+
+// storedTransition is a transition read from the database.
+type storedTransition struct {
+	Cluster string `json:"cluster,omitempty"`
+	transitionRecord
+}
+
+// transitionQuery selects the transitions to read from the database. Empty
+// fields match all transitions.
+type transitionQuery struct {
+	Since     time.Time
+	Cluster   string
+	Namespace string
+	Kind      string
+	Name      string
+	// Type is matched as a glob pattern.
+	Type   string
+	Status metav1.ConditionStatus
+}
+
+// defaultTransitionDBPath returns the database used when --db isn't given.
+func defaultTransitionDBPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".kubectl-cond.db"
+	}
+	return filepath.Join(home, ".kubectl-cond.db")
+}
+
+// openTransitionDB opens (or creates) the database at path. Transitions
+// written to it are attributed to cluster.
+func openTransitionDB(path, cluster string) (*transitionDB, error) {
+	// WAL mode lets the database be queried while it's being recorded to
+	db, err := sql.Open("sqlite", transitionDBURI(path, "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+	if _, err := db.Exec(transitionSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize the database %s: %w", path, err)
+	}
+	return &transitionDB{db: db, cluster: cluster}, nil
+}
+
+// transitionDBURI returns the SQLite URI of the database file at path with
+// the query, escaping the characters of the path that are special in URIs
+// (such as ? and #).
+func transitionDBURI(path, query string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths, e.g. file:///C:/Users/...
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path, RawQuery: query}).String()
+}
+
+func (d *transitionDB) Close() error {
+	return d.db.Close()
+}
+
+func (d *transitionDB) Transition(t conditionTransition) error {
+	return d.insert(newTransitionRecord(t))
+}
+
+func (d *transitionDB) Deleted(obj *unstructured.Unstructured, now time.Time) error {
+	return d.insert(transitionRecord{
+		Time:       now,
+		Event:      eventDeleted,
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	})
+}
+
+func (d *transitionDB) insert(rec transitionRecord) error {
+	_, err := d.db.Exec(`INSERT INTO transitions
+		(time, cluster, event, api_version, kind, namespace, name, type, old_status, status, old_reason, reason, old_message, message)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Time.UnixMilli(), d.cluster, rec.Event, rec.APIVersion, rec.Kind, rec.Namespace, rec.Name, rec.Type,
+		rec.OldStatus, rec.Status, rec.OldReason, rec.Reason, rec.OldMessage, rec.Message)
+	if err != nil {
+		return fmt.Errorf("failed to record transition: %w", err)
+	}
+	return nil
+}
+
+// query returns the recorded transitions matching q, oldest first.
+func (d *transitionDB) query(q transitionQuery) ([]storedTransition, error) {
+	where := []string{"time >= ?"}
+	args := []any{q.Since.UnixMilli()}
+	for _, f := range []struct {
+		column, value string
+	}{
+		{"cluster", q.Cluster},
+		{"namespace", q.Namespace},
+		{"name", q.Name},
+		{"status", string(q.Status)},
+	} {
+		if f.value != "" {
+			where = append(where, f.column+" = ?")
+			args = append(args, f.value)
+		}
+	}
+	if q.Kind != "" {
+		where = append(where, "kind = ? COLLATE NOCASE")
+		args = append(args, q.Kind)
+	}
+	rows, err := d.db.Query(`SELECT
+		time, cluster, event, api_version, kind, namespace, name, type, old_status, status, old_reason, reason, old_message, message
+		FROM transitions WHERE `+strings.Join(where, " AND ")+` ORDER BY time, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []storedTransition
+	for rows.Next() {
+		var t storedTransition
+		var ms int64
+		if err := rows.Scan(&ms, &t.Cluster, &t.Event, &t.APIVersion, &t.Kind, &t.Namespace, &t.Name, &t.Type,
+			&t.OldStatus, &t.Status, &t.OldReason, &t.Reason, &t.OldMessage, &t.Message); err != nil {
+			return nil, fmt.Errorf("failed to read transitions: %w", err)
+		}
+		if q.Type != "" && !matchesAny([]string{q.Type}, t.Type) {
+			continue
+		}
+		t.Time = time.UnixMilli(ms)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// multiTransitionWriter writes the events observed while watching to all of
+// its writers.
+type multiTransitionWriter []transitionWriter
+
+func (m multiTransitionWriter) Transition(t conditionTransition) error {
+	for _, w := range m {
+		if err := w.Transition(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiTransitionWriter) Deleted(obj *unstructured.Unstructured, now time.Time) error {
+	for _, w := range m {
+		if err := w.Deleted(obj, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/tools/pager"
+)
+
+// treeListConcurrency is the number of resource types listed in parallel
+// when looking up the dependents of the objects.
+const treeListConcurrency = 8
+
+var treeFlag bool
+
+// ownerIndex maps the UIDs of objects to the objects they own.
+type ownerIndex map[types.UID][]*unstructured.Unstructured
+
+// runTree prints the requested objects along with the objects they own
+// (through ownerReferences, recursively) as a tree.
+func runTree(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, rb *resource.Builder) error {
+	infos, err := queryLatest(rb).Infos()
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		fmt.Fprintln(stderr, "No resources found.")
+		return nil
+	}
+	namespaces := sets.New[string]()
+	for _, info := range infos {
+		namespaces.Insert(info.Namespace)
+	}
+	index, err := buildOwnerIndex(ctx, kc, configFlags, namespaces)
+	if err != nil && len(index) == 0 {
+		return err
+	}
+
+	t := &treePrinter{ctx: ctx, kc: kc, w: stdout, index: index, visited: sets.New[types.UID]()}
+	for _, info := range infos {
+		obj, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if err := t.print(obj, "", ""); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, gray.Sprintf("Some dependents may be missing: %v", err))
+	}
+	fmt.Fprintf(t.w, "\n%s\n", bold.Sprint(t.counts))
+	if failOnUnhealthyFlag && t.counts.UnhealthyObjects > 0 {
+		return errUnhealthy
+	}
+	return nil
+}
+
+// buildOwnerIndex lists the objects of all resource types that can be listed
+// in the namespaces (and the cluster-scoped ones) and indexes them by their
+// owners. Resource types that fail to be listed (e.g. due to RBAC) are
+// reported in the returned error along with the partial index.
+func buildOwnerIndex(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, namespaces sets.Set[string]) (ownerIndex, error) {
+	dyn, err := kc.Dynamic()
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := configFlags.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	// partial discovery results are still usable
+	lists, discoveryErr := discoveryClient.ServerPreferredResources()
+	if len(lists) == 0 && discoveryErr != nil {
+		return nil, fmt.Errorf("failed to discover resource types: %w", discoveryErr)
+	}
+
+	type listRequest struct {
+		gvr       schema.GroupVersionResource
+		namespace string
+	}
+	var requests []listRequest
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") ||
+				r.Kind == "Event" {
+				continue
+			}
+			gvr := gv.WithResource(r.Name)
+			if !r.Namespaced {
+				requests = append(requests, listRequest{gvr: gvr})
+				continue
+			}
+			for ns := range namespaces {
+				requests = append(requests, listRequest{gvr: gvr, namespace: ns})
+			}
+		}
+	}
+
+	index := make(ownerIndex)
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, treeListConcurrency)
+	for _, req := range requests {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(req listRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return dyn.Resource(req.gvr).Namespace(req.namespace).List(ctx, opts)
+			})
+			p.PageSize = chunkSizeFlag
+			err := p.EachListItem(ctx, metav1.ListOptions{}, func(o runtime.Object) error {
+				obj, ok := o.(*unstructured.Unstructured)
+				if !ok {
+					return nil
+				}
+				refs := obj.GetOwnerReferences()
+				if len(refs) == 0 {
+					return nil
+				}
+				pruneObject(obj)
+				mu.Lock()
+				defer mu.Unlock()
+				for _, ref := range refs {
+					index[ref.UID] = append(index[ref.UID], obj)
+				}
+				return nil
+			})
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", req.gvr.GroupResource(), err))
+				mu.Unlock()
+			}
+		}(req)
+	}
+	wg.Wait()
+	return index, errors.Join(errs...)
+}
+
+// treePrinter prints objects with their conditions and dependents.
+type treePrinter struct {
+	ctx     context.Context
+	kc      *kubeClient
+	w       io.Writer
+	index   ownerIndex
+	visited sets.Set[types.UID]
+	counts  healthCounts
+}
+
+// print prints the object on a line starting with linePrefix, and its
+// conditions and dependents on lines starting with childPrefix.
+func (t *treePrinter) print(obj *unstructured.Unstructured, linePrefix, childPrefix string) error {
+	if t.visited.Has(obj.GetUID()) {
+		return nil
+	}
+	t.visited.Insert(obj.GetUID())
+
+	report, err := newObjectReport(t.ctx, t.kc, obj)
+	if errors.Is(err, errNoConditions) {
+		report = &objectReport{Object: obj, Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	} else if err != nil {
+		return fmt.Errorf("failed to process object %s %s/%s: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	t.counts.add(report.Conditions)
+
+	fmt.Fprintf(t.w, "%s%s\n", linePrefix, bold.Sprintf("%s %s", report.Kind, report.displayName()))
+
+	children := t.index[obj.GetUID()]
+	sort.SliceStable(children, func(i, j int) bool {
+		if children[i].GetKind() != children[j].GetKind() {
+			return children[i].GetKind() < children[j].GetKind()
+		}
+		return children[i].GetName() < children[j].GetName()
+	})
+	detailPrefix := childPrefix + "│  "
+	if len(children) == 0 {
+		detailPrefix = childPrefix + "   "
+	}
+	for _, line := range report.Header {
+		fmt.Fprintf(t.w, "%s%s\n", detailPrefix, line)
+	}
+	for _, cond := range report.Conditions {
+		line := matrixCell(cond) + " " + statusColor(cond)(cond.Type+"="+string(cond.Status))
+		if cond.Reason != "" {
+			line += " " + cond.Reason
+		}
+		if cond.Message != "" {
+			line += gray.Sprintf(": %s", strings.SplitN(cond.Message, "\n", 2)[0])
+		}
+		fmt.Fprintf(t.w, "%s%s\n", detailPrefix, line)
+	}
+
+	for i, child := range children {
+		if i == len(children)-1 {
+			if err := t.print(child, childPrefix+"└─ ", childPrefix+"   "); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := t.print(child, childPrefix+"├─ ", childPrefix+"│  "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// latestReleaseURL is the GitHub API endpoint for the latest release.
+var latestReleaseURL = "https://api.github.com/repos/ahmetb/kubectl-cond/releases/latest"
+
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// buildVersion returns the release version of the binary, or the module
+// version for binaries built with go install.
+func buildVersion() string {
+	if version == "dev" {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+			return info.Main.Version
+		}
+	}
+	return version
+}
+
+// semverOf returns v in the canonical "vMAJOR.MINOR.PATCH" form, or "" if it
+// isn't a semantic version.
+func semverOf(v string) string {
+	if v != "" && v[0] != 'v' {
+		v = "v" + v
+	}
+	return semver.Canonical(v)
+}
+
+// compareVersion compares the current version with a release version like
+// semver.Compare does. It returns false if current isn't a semantic version
+// (e.g. "dev") or is a pseudo-version of an untagged commit, which can't be
+// ordered against releases.
+func compareVersion(current, release string) (int, bool) {
+	v := semverOf(current)
+	if v == "" || module.IsPseudoVersion(v) {
+		return 0, false
+	}
+	return semver.Compare(v, release), true
+}
+
+func (r *githubRelease) assetURL(match func(name string) bool) string {
+	for _, a := range r.Assets {
+		if match(a.Name) {
+			return a.URL
+		}
+	}
+	return ""
+}
+
+func newUpgradeCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var checkOnly, force bool
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade kubectl-cond to the latest release",
+		Long: "Download the latest release from GitHub, verify its checksum and replace the running binary with it. " +
+			"If kubectl-cond was installed with krew, use \"kubectl krew upgrade cond\" instead. " +
+			"Development builds and builds newer than the latest release are left alone unless --force is given.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, cancel := commandContext(cmd, configFlags)
+			defer cancel()
+			return runUpgrade(ctx, checkOnly, force)
+		},
+	}
+	cmd.Flags().BoolVar(&checkOnly, "check", false, "If present, only check whether a newer release is available.")
+	cmd.Flags().BoolVar(&force, "force", false, "If present, replace development builds and builds newer than the latest release.")
+	return cmd
+}
+
+func runUpgrade(ctx context.Context, checkOnly, force bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	rel, err := latestRelease(ctx)
+	if err != nil {
+		return err
+	}
+	latest := semverOf(rel.TagName)
+	if latest == "" {
+		return fmt.Errorf("latest release has an unexpected tag %q", rel.TagName)
+	}
+	current := versionString()
+	cmp, ok := compareVersion(buildVersion(), latest)
+	switch {
+	case ok && cmp == 0:
+		fmt.Fprintf(stdout, "kubectl-cond is up to date (%s).\n", current)
+		return nil
+	case ok && cmp > 0:
+		fmt.Fprintf(stdout, "kubectl-cond %s is newer than the latest release %s.\n", current, rel.TagName)
+	case !ok:
+		fmt.Fprintf(stdout, "kubectl-cond %s is a development build, the latest release is %s.\n", current, rel.TagName)
+	default:
+		fmt.Fprintf(stdout, "Latest release is %s (current: %s).\n", rel.TagName, current)
+	}
+	if checkOnly {
+		return nil
+	}
+	if (!ok || cmp > 0) && !force {
+		fmt.Fprintln(stdout, "Not replacing it, use --force to install the latest release anyway.")
+		return nil
+	}
+	if installedWithKrew(exe) {
+		fmt.Fprintln(stdout, "kubectl-cond was installed with krew, run \"kubectl krew upgrade cond\" to upgrade it.")
+		return nil
+	}
+
+	archiveName := fmt.Sprintf("kubectl-cond_%s_%s_%s.tar.gz", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	archiveURL := rel.assetURL(func(name string) bool { return name == archiveName })
+	if archiveURL == "" {
+		return fmt.Errorf("release %s has no archive for %s/%s", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksumsURL := rel.assetURL(func(name string) bool { return strings.HasSuffix(name, "checksums.txt") })
+	if checksumsURL == "" {
+		return fmt.Errorf("release %s has no checksums file, refusing to upgrade", rel.TagName)
+	}
+
+	checksums, err := download(ctx, checksumsURL)
+	if err != nil {
+		return err
+	}
+	want, err := findChecksum(checksums, archiveName)
+	if err != nil {
+		return err
+	}
+	archive, err := download(ctx, archiveURL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", archiveName, want, got)
+	}
+	bin, err := extractBinary(archive)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", archiveName, err)
+	}
+	if err := replaceBinary(exe, bin); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Upgraded %s to %s.\n", exe, rel.TagName)
+	return nil
+}
+
+func latestRelease(ctx context.Context) (*githubRelease, error) {
+	b, err := download(ctx, latestReleaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the latest release: %w", err)
+	}
+	var rel githubRelease
+	if err := json.Unmarshal(b, &rel); err != nil {
+		return nil, fmt.Errorf("failed to parse the latest release: %w", err)
+	}
+	return &rel, nil
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// installedWithKrew reports whether the binary at path is managed by krew.
+func installedWithKrew(path string) bool {
+	root := os.Getenv("KREW_ROOT")
+	if root == "" {
+		home, _ := os.UserHomeDir()
+		root = filepath.Join(home, ".krew")
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// findChecksum returns the SHA-256 checksum of the file from the contents of
+// a checksums file in the format of sha256sum.
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no checksum found for %s", name)
+}
+
+// extractBinary returns the kubectl-cond binary in the release archive.
+func extractBinary(archive []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("binary not found in archive")
+		} else if err != nil {
+			return nil, err
+		}
+		if name := filepath.Base(hdr.Name); name == "kubectl-cond" || name == "kubectl-cond.exe" {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceBinary atomically replaces the binary at path with bin.
+func replaceBinary(path string, bin []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".kubectl-cond-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bin); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		// a running executable can't be replaced, but it can be renamed
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		current string
+		want    int
+		wantOK  bool
+	}{
+		{current: "v1.2.0", want: 0, wantOK: true},
+		{current: "1.2.0", want: 0, wantOK: true},
+		{current: "v1.1.9", want: -1, wantOK: true},
+		{current: "v1.10.0", want: 1, wantOK: true},
+		{current: "v1.2.1-rc.1", want: 1, wantOK: true},
+		{current: "v1.2.0-rc.1", want: -1, wantOK: true},
+		{current: "dev", wantOK: false},
+		{current: "", wantOK: false},
+		{current: "v1.2.1-0.20240101000000-abcdefabcdef", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current, func(t *testing.T) {
+			got, ok := compareVersion(tt.current, "v1.2.0")
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("compareVersion(%q) = %d, %v; want %d, %v", tt.current, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRunUpgradeRefusesDowngrade(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/latest" {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"tag_name": "v1.2.0", "assets": [{"name": "checksums.txt", "browser_download_url": %q}]}`, "http://"+r.Host+"/checksums.txt")
+	}))
+	defer srv.Close()
+
+	defer func(u, v string) { latestReleaseURL, version = u, v }(latestReleaseURL, version)
+	oldStdout := stdout
+	defer func() { stdout = oldStdout }()
+	latestReleaseURL = srv.URL + "/latest"
+
+	for _, tt := range []struct {
+		version string
+		want    string
+	}{
+		{version: "v1.3.0", want: "newer than the latest release"},
+		{version: "dev", want: "is a development build"},
+	} {
+		t.Run(tt.version, func(t *testing.T) {
+			var out bytes.Buffer
+			stdout = &out
+			version = tt.version
+			if err := runUpgrade(context.Background(), false, false); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), tt.want) || !strings.Contains(out.String(), "use --force") {
+				t.Errorf("unexpected output:\n%s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// waitSettleDelay is how long to wait after an event before checking
+// whether the awaited condition is met, so that the initial state of all
+// watched objects is received before deciding. Later events don't postpone
+// the check.
+const waitSettleDelay = 500 * time.Millisecond
+
+var waitForFlag string
+
+// waitCondition is the condition awaited with --wait-for.
+type waitCondition struct {
+	Type   string
+	Status metav1.ConditionStatus
+}
+
+func (w waitCondition) String() string {
+	return w.Type + "=" + string(w.Status)
+}
+
+// parseWaitFor parses a --wait-for value in the form of TYPE[=STATUS], where
+// the status defaults to True.
+func parseWaitFor(s string) (waitCondition, error) {
+	typ, status, ok := strings.Cut(s, "=")
+	if !ok {
+		status = string(metav1.ConditionTrue)
+	}
+	if typ == "" {
+		return waitCondition{}, fmt.Errorf("invalid --wait-for value %q, expected TYPE[=STATUS] (e.g. Ready=True)", s)
+	}
+	for _, st := range []metav1.ConditionStatus{metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown} {
+		if strings.EqualFold(status, string(st)) {
+			return waitCondition{Type: typ, Status: st}, nil
+		}
+	}
+	return waitCondition{}, fmt.Errorf("invalid --wait-for status %q, expected one of: (True, False, Unknown)", status)
+}
+
+// met reports whether all tracked objects have the condition with the
+// awaited status. Conditions that describe an older generation of the object
+// (e.g. right after kubectl apply) don't count until the controller observes
+// the latest one.
+func (t *conditionTracker) met(w waitCondition) bool {
+	if len(t.objects) == 0 {
+		return false
+	}
+	for key, conds := range t.objects {
+		if t.outdated[key] {
+			return false
+		}
+		found := false
+		for typ, cond := range conds {
+			if strings.EqualFold(typ, w.Type) {
+				found = cond.Status == w.Status && !cond.Stale
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConditionTrackerMet(t *testing.T) {
+	awaited := waitCondition{Type: "Available", Status: metav1.ConditionTrue}
+	tests := []struct {
+		name                 string
+		generation, observed int64
+		status               metav1.ConditionStatus
+		stale                bool
+		want                 bool
+	}{
+		{name: "met", generation: 2, observed: 2, status: metav1.ConditionTrue, want: true},
+		{name: "other status", generation: 2, observed: 2, status: metav1.ConditionFalse, want: false},
+		{name: "stale condition", generation: 2, observed: 2, status: metav1.ConditionTrue, stale: true, want: false},
+		{name: "generation not observed yet", generation: 2, observed: 1, status: metav1.ConditionTrue, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testReport(GenericCondition{Type: "Available", Status: tt.status, Stale: tt.stale})
+			r.Object.SetGeneration(tt.generation)
+			r.Object.Object["status"] = map[string]any{"observedGeneration": tt.observed}
+			tracker := newConditionTracker()
+			tracker.observe(r, time.Now())
+			if got := tracker.met(awaited); got != tt.want {
+				t.Errorf("met() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
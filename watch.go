@@ -0,0 +1,545 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// watchSource starts a watch on one resource type or a single object.
+type watchSource func() (watch.Interface, error)
+
+// conditionTransition is an observed change of a condition on an object.
+type conditionTransition struct {
+	Time       time.Time
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	UID        types.UID
+	// Old is nil when the condition is observed for the first time.
+	Old *GenericCondition
+	// New is nil when the condition (or the object) has been removed.
+	New *GenericCondition
+}
+
+func (t conditionTransition) displayName() string {
+	if t.Namespace != "" {
+		return t.Namespace + "/" + t.Name
+	}
+	return t.Name
+}
+
+func (t conditionTransition) conditionType() string {
+	if t.New != nil {
+		return t.New.Type
+	}
+	return t.Old.Type
+}
+
+// conditionTracker remembers the last observed conditions of the watched
+// objects to detect transitions.
+type conditionTracker struct {
+	objects map[string]map[string]GenericCondition
+	// outdated holds the objects whose controller hasn't observed their
+	// latest generation yet (per status.observedGeneration).
+	outdated map[string]bool
+}
+
+func newConditionTracker() *conditionTracker {
+	return &conditionTracker{
+		objects:  make(map[string]map[string]GenericCondition),
+		outdated: make(map[string]bool),
+	}
+}
+
+func objectKey(obj *unstructured.Unstructured) string {
+	return obj.GroupVersionKind().GroupKind().String() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// observe records the conditions of the object and returns the transitions
+// since the object was last observed. Changes to the status, reason or
+// message of a condition are considered transitions.
+func (t *conditionTracker) observe(r *objectReport, now time.Time) []conditionTransition {
+	key := objectKey(r.Object)
+	prev, seen := t.objects[key]
+	cur := make(map[string]GenericCondition, len(r.Conditions))
+
+	var out []conditionTransition
+	for _, cond := range r.Conditions {
+		cur[cond.Type] = cond
+		old, ok := prev[cond.Type]
+		if ok && old.Status == cond.Status && old.Reason == cond.Reason && old.Message == cond.Message {
+			continue
+		}
+		tr := conditionTransition{Time: now, APIVersion: r.Object.GetAPIVersion(), Kind: r.Kind, Namespace: r.Namespace, Name: r.Name, UID: r.Object.GetUID(), New: &cond}
+		if ok {
+			tr.Old = &old
+		}
+		out = append(out, tr)
+	}
+	if seen {
+		for typ, old := range prev {
+			if _, ok := cur[typ]; !ok {
+				out = append(out, conditionTransition{Time: now, APIVersion: r.Object.GetAPIVersion(), Kind: r.Kind, Namespace: r.Namespace, Name: r.Name, UID: r.Object.GetUID(), Old: &old})
+			}
+		}
+	}
+	t.objects[key] = cur
+	if observed, found, _ := unstructured.NestedInt64(r.Object.Object, "status", "observedGeneration"); found && r.Object.GetGeneration() > observed {
+		t.outdated[key] = true
+	} else {
+		delete(t.outdated, key)
+	}
+	return out
+}
+
+// healthCounts returns the health of the last observed conditions of the
+// tracked objects.
+func (t *conditionTracker) healthCounts() healthCounts {
+	var c healthCounts
+	for _, conds := range t.objects {
+		list := make([]GenericCondition, 0, len(conds))
+		for _, cond := range conds {
+			list = append(list, cond)
+		}
+		c.addShown(list)
+	}
+	return c
+}
+
+// forget removes the object from the tracker.
+func (t *conditionTracker) forget(obj *unstructured.Unstructured) {
+	delete(t.objects, objectKey(obj))
+	delete(t.outdated, objectKey(obj))
+}
+
+// runWatch watches the requested objects and prints the condition
+// transitions of all of them as a single stream in the order they are
+// observed. The events are also written to sinks, if any.
+func runWatch(ctx context.Context, kc *kubeClient, configFlags *genericclioptions.ConfigFlags, args []string, sinks ...transitionWriter) error {
+	notify, err := newNotifiers(kc)
+	if err != nil {
+		return err
+	}
+	defer notify.Close()
+	var history *historyStore
+	if recordFlag {
+		if history, err = newHistoryStore(currentContextName(configFlags)); err != nil {
+			return err
+		}
+	}
+	var waitFor *waitCondition
+	if waitForFlag != "" {
+		wc, err := parseWaitFor(waitForFlag)
+		if err != nil {
+			return err
+		}
+		waitFor = &wc
+	}
+	sources, err := newWatchSources(configFlags, args)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return errors.New("no objects to watch")
+	}
+
+	events := make(chan watch.Event)
+	errCh := make(chan error, len(sources))
+	running := len(sources)
+	for _, src := range sources {
+		go runWatchSource(ctx, src, events, errCh)
+	}
+
+	var tw transitionWriter
+	summaryOut := stdout
+	switch outputFlag {
+	case "":
+		tw = &textTransitionWriter{w: stdout}
+	case "ndjson", "jsonl":
+		tw = &ndjsonTransitionWriter{enc: json.NewEncoder(stdout)}
+		// keep stdout parseable
+		summaryOut = stderr
+	default:
+		return fmt.Errorf("output format %q is not supported in watch mode", outputFlag)
+	}
+	if len(sinks) > 0 {
+		tw = append(multiTransitionWriter{tw}, sinks...)
+	}
+
+	tracker := newConditionTracker()
+	stats := newWatchStats(time.Now())
+	flaps := newFlapDetector(flapThresholdFlag, flapWindowFlag)
+	finish := func() error {
+		counts := tracker.healthCounts()
+		stats.printSummary(summaryOut, time.Now(), counts)
+		flaps.printSummary(summaryOut)
+		if counts.UnhealthyObjects > 0 {
+			return errUnhealthy
+		}
+		return nil
+	}
+	var settle <-chan time.Time
+	var footer <-chan time.Time
+	if watchStatsIntervalFlag > 0 && outputFlag == "" {
+		ticker := time.NewTicker(watchStatsIntervalFlag)
+		defer ticker.Stop()
+		footer = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if waitFor != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				finish()
+				return fmt.Errorf("timed out after %s waiting for %s", timeoutFlag, waitFor)
+			}
+			return finish()
+		case <-settle:
+			settle = nil
+			if tracker.met(*waitFor) {
+				fmt.Fprintf(summaryOut, "%s met for %d objects after %s.\n", waitFor,
+					len(tracker.objects), duration.HumanDuration(time.Since(stats.start)))
+				return nil
+			}
+		case err := <-errCh:
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			// a stream from stdin has ended
+			if running--; running == 0 {
+				return finish()
+			}
+		case now := <-footer:
+			stats.printFooter(stdout, now)
+		case ev := <-events:
+			obj, ok := ev.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			now := time.Now()
+			// the timer isn't re-armed while pending, so that the condition is
+			// checked even if the objects keep changing
+			if waitFor != nil && settle == nil {
+				settle = time.After(waitSettleDelay)
+			}
+			if ev.Type == watch.Deleted {
+				if err := tw.Deleted(obj, now); err != nil {
+					return err
+				}
+				tracker.forget(obj)
+				continue
+			}
+			report, err := newObjectReport(ctx, kc, obj)
+			if errors.Is(err, errNoConditions) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to process object %s %s/%s: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+			}
+			transitions := tracker.observe(report, now)
+			stats.record(transitions)
+			if history != nil && len(transitions) > 0 {
+				recordHistory(history, report, now)
+			}
+			for _, t := range transitions {
+				if !matchesAny(typeFlag, t.conditionType()) {
+					continue
+				}
+				if err := tw.Transition(t); err != nil {
+					return err
+				}
+				flaps.record(summaryOut, t)
+				notify.Transition(ctx, t)
+			}
+		}
+	}
+}
+
+// runWatchSource forwards the events of the watch to the events channel
+// until the context is cancelled or the source reports io.EOF. Watches
+// closed by the server (which happens periodically) are restarted. Since restarted watches replay the
+// current state of the objects, the tracker deduplicates them.
+func runWatchSource(ctx context.Context, src watchSource, events chan<- watch.Event, errCh chan<- error) {
+	for {
+		w, err := src()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			errCh <- err
+			return
+		}
+		if err := forwardEvents(ctx, w, events); err != nil {
+			errCh <- err
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func forwardEvents(ctx context.Context, w watch.Interface, events chan<- watch.Event) error {
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			if ev.Type == watch.Error {
+				err := apierrors.FromObject(ev.Object)
+				if apierrors.IsGone(err) || apierrors.IsResourceExpired(err) {
+					// restart the watch from the current state
+					return nil
+				}
+				return fmt.Errorf("watch failed: %w", err)
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// newWatchSources resolves the arguments into watches. Resource types (such
+// as "deploy,rs,pods") are watched as collections so that newly created
+// objects are picked up, while named objects and objects from files are
+// watched individually. Objects piped to stdin (with -f -) are read as they
+// arrive instead of being watched on the server.
+func newWatchSources(configFlags *genericclioptions.ConfigFlags, args []string) ([]watchSource, error) {
+	var sources []watchSource
+	seen := make(map[string]bool)
+	fileOpts := *filenameOpts
+	filenames, stdin := splitStdinFilename(fileOpts.Filenames)
+	fileOpts.Filenames = filenames
+	if stdin {
+		sources = append(sources, newStreamSource(os.Stdin))
+	}
+	addInfos := func(r *resource.Result) error {
+		infos, err := r.Infos()
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if seen[infoKey(info)] {
+				continue
+			}
+			seen[infoKey(info)] = true
+			sources = append(sources, func() (watch.Interface, error) { return info.Watch("") })
+		}
+		return nil
+	}
+
+	for _, argSet := range splitWatchArgs(args) {
+		rb, err := newResourceBuilder(configFlags)
+		if err != nil {
+			return nil, err
+		}
+		r := rb.ResourceTypeOrNameArgs(true, argSet...).Do()
+		if err := r.Err(); err != nil {
+			return nil, err
+		}
+		if len(argSet) == 1 && !strings.Contains(argSet[0], "/") {
+			sources = append(sources, func() (watch.Interface, error) { return r.Watch("") })
+			continue
+		}
+		if err := addInfos(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fileOpts.Filenames) > 0 || fileOpts.Kustomize != "" {
+		rb, err := newResourceBuilder(configFlags)
+		if err != nil {
+			return nil, err
+		}
+		r := rb.FilenameParam(false, &fileOpts).Flatten().Do()
+		if err := r.Err(); err != nil {
+			return nil, err
+		}
+		if err := addInfos(r); err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+// splitWatchArgs splits the resource arguments into sets that can each be
+// watched on their own, e.g. "deploy,rs,pods" into "deploy", "rs" and
+// "pods", and "pod/a pod/b" into "pod/a" and "pod/b".
+func splitWatchArgs(args []string) [][]string {
+	if len(args) == 0 {
+		return nil
+	}
+	var out [][]string
+	if strings.Contains(args[0], "/") {
+		for _, arg := range args {
+			out = append(out, []string{arg})
+		}
+		return out
+	}
+	for _, typ := range strings.Split(args[0], ",") {
+		if typ == "" {
+			continue
+		}
+		out = append(out, append([]string{typ}, args[1:]...))
+	}
+	return out
+}
+
+// transitionWriter prints the events observed while watching.
+type transitionWriter interface {
+	Transition(t conditionTransition) error
+	Deleted(obj *unstructured.Unstructured, now time.Time) error
+}
+
+// textTransitionWriter prints each transition as a human-readable line.
+type textTransitionWriter struct {
+	w io.Writer
+}
+
+func (p *textTransitionWriter) Transition(t conditionTransition) error {
+	prefix := fmt.Sprintf("%s %s %s", gray.Sprint(t.Time.In(displayLocation).Format(time.TimeOnly)), bold.Sprint(t.Kind), t.displayName())
+	if t.New == nil {
+		_, err := fmt.Fprintf(p.w, "%s %s %s\n", prefix, t.Old.Type, gray.Sprint("removed"))
+		return err
+	}
+	colorFn := statusColor(*t.New)
+	status := colorFn(string(t.New.Status))
+	if t.Old != nil && t.Old.Status != t.New.Status {
+		arrow := " → "
+		if plainFlag {
+			arrow = " to "
+		}
+		status = statusColor(*t.Old)(string(t.Old.Status)) + arrow + status
+	}
+	line := fmt.Sprintf("%s %s=%s", prefix, colorFn(t.New.Type), status)
+	if t.New.Reason != "" {
+		line += " " + colorFn(bold.Sprint(t.New.Reason))
+	}
+	if t.New.Message != "" {
+		line += " " + t.New.Message
+	}
+	_, err := fmt.Fprintln(p.w, line)
+	return err
+}
+
+func (p *textTransitionWriter) Deleted(obj *unstructured.Unstructured, now time.Time) error {
+	name := obj.GetName()
+	if obj.GetNamespace() != "" {
+		name = obj.GetNamespace() + "/" + name
+	}
+	_, err := fmt.Fprintf(p.w, "%s %s %s %s\n", gray.Sprint(now.In(displayLocation).Format(time.TimeOnly)), bold.Sprint(obj.GetKind()), name, gray.Sprint("deleted"))
+	return err
+}
+
+// transitionRecord is the machine-readable form of a watch event.
+type transitionRecord struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	APIVersion string    `json:"apiVersion,omitempty"`
+	Kind       string    `json:"kind"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name"`
+
+	Type       string                 `json:"type,omitempty"`
+	OldStatus  metav1.ConditionStatus `json:"oldStatus,omitempty"`
+	Status     metav1.ConditionStatus `json:"status,omitempty"`
+	OldReason  string                 `json:"oldReason,omitempty"`
+	Reason     string                 `json:"reason,omitempty"`
+	OldMessage string                 `json:"oldMessage,omitempty"`
+	Message    string                 `json:"message,omitempty"`
+}
+
+// Watch event kinds in transition records.
+const (
+	eventObserved = "Observed" // condition seen for the first time
+	eventChanged  = "Changed"
+	eventRemoved  = "Removed" // condition no longer reported
+	eventDeleted  = "Deleted" // object deleted
+)
+
+func newTransitionRecord(t conditionTransition) transitionRecord {
+	rec := transitionRecord{
+		Time:       t.Time,
+		APIVersion: t.APIVersion,
+		Kind:       t.Kind,
+		Namespace:  t.Namespace,
+		Name:       t.Name,
+	}
+	switch {
+	case t.New == nil:
+		rec.Event = eventRemoved
+		rec.Type = t.Old.Type
+	case t.Old == nil:
+		rec.Event = eventObserved
+	default:
+		rec.Event = eventChanged
+	}
+	if t.Old != nil {
+		rec.OldStatus = t.Old.Status
+		rec.OldReason = t.Old.Reason
+		rec.OldMessage = t.Old.Message
+	}
+	if t.New != nil {
+		rec.Type = t.New.Type
+		rec.Status = t.New.Status
+		rec.Reason = t.New.Reason
+		rec.Message = t.New.Message
+	}
+	return rec
+}
+
+// ndjsonTransitionWriter prints each event as a JSON object on its own line
+// as soon as it's observed.
+type ndjsonTransitionWriter struct {
+	enc *json.Encoder
+}
+
+func (p *ndjsonTransitionWriter) Transition(t conditionTransition) error {
+	return p.enc.Encode(newTransitionRecord(t))
+}
+
+func (p *ndjsonTransitionWriter) Deleted(obj *unstructured.Unstructured, now time.Time) error {
+	return p.enc.Encode(transitionRecord{
+		Time:       now,
+		Event:      eventDeleted,
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	})
+}
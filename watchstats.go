@@ -0,0 +1,154 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// rateWindow is the period over which the transition rate is computed.
+const rateWindow = 5 * time.Minute
+
+// watchStats keeps track of the transitions observed during a watch.
+type watchStats struct {
+	start       time.Time
+	total       int
+	recent      []time.Time // transition times within the rate window
+	lastChanged string
+	lastTime    time.Time
+}
+
+func newWatchStats(start time.Time) *watchStats {
+	return &watchStats{start: start}
+}
+
+func (s *watchStats) record(transitions []conditionTransition) {
+	for _, t := range transitions {
+		if t.Old == nil {
+			// first observation of the condition, not a change
+			continue
+		}
+		s.total++
+		s.recent = append(s.recent, t.Time)
+		s.lastChanged = t.Kind + " " + t.displayName()
+		s.lastTime = t.Time
+	}
+}
+
+// ratePerMinute returns the number of transitions per minute observed
+// within the rate window (or since the start of the watch if it's shorter).
+func (s *watchStats) ratePerMinute(now time.Time) float64 {
+	cutoff := now.Add(-rateWindow)
+	i := 0
+	for i < len(s.recent) && s.recent[i].Before(cutoff) {
+		i++
+	}
+	s.recent = s.recent[i:]
+
+	window := now.Sub(s.start)
+	if window > rateWindow {
+		window = rateWindow
+	}
+	if window < time.Minute {
+		window = time.Minute
+	}
+	return float64(len(s.recent)) / window.Minutes()
+}
+
+// printFooter prints a line summarizing the rate of change, so that it's
+// apparent whether the watched objects are converging or churning.
+func (s *watchStats) printFooter(w io.Writer, now time.Time) {
+	line := fmt.Sprintf("-- %.1f transitions/min", s.ratePerMinute(now))
+	if s.lastChanged != "" {
+		line += fmt.Sprintf(", last change: %s (%s ago)", s.lastChanged, duration.HumanDuration(now.Sub(s.lastTime)))
+	} else {
+		line += ", no changes observed yet"
+	}
+	fmt.Fprintln(w, gray.Sprint(line))
+}
+
+// printSummary prints the closing summary of the watch.
+func (s *watchStats) printSummary(w io.Writer, now time.Time, counts healthCounts) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, bold.Sprintf("Watched for %s, observed %d transitions.",
+		duration.HumanDuration(now.Sub(s.start)), s.total))
+	fmt.Fprintf(w, "Currently %s.\n", counts)
+}
+
+// healthCounts summarizes the health of a set of objects' conditions.
+type healthCounts struct {
+	Objects          int `json:"objects"`
+	UnhealthyObjects int `json:"unhealthyObjects"`
+	Conditions       int `json:"conditions"`
+	Healthy          int `json:"healthy"`
+	Unhealthy        int `json:"unhealthy"`
+	Unknown          int `json:"unknown"`
+	// Stale conditions are counted in addition to their health.
+	Stale int `json:"stale"`
+}
+
+// add counts the conditions of an object.
+func (c *healthCounts) add(conditions []GenericCondition) {
+	c.Objects++
+	c.Conditions += len(conditions)
+	unhealthy := false
+	for _, cond := range conditions {
+		if cond.Stale {
+			c.Stale++
+		}
+		switch semanticStatus(cond) {
+		case metav1.ConditionTrue:
+			c.Healthy++
+		case metav1.ConditionUnknown:
+			// neutral Unknown conditions still make the object unhealthy,
+			// as they often mean the controller lost track of it
+			c.Unknown++
+			unhealthy = true
+		default:
+			c.Unhealthy++
+			unhealthy = true
+		}
+	}
+	if unhealthy {
+		c.UnhealthyObjects++
+	}
+}
+
+// addShown counts the conditions of an object that are not excluded by the
+// condition filters, and skips the object if all of them are, so that the
+// counts (and the exit code) reflect what's printed.
+func (c *healthCounts) addShown(conditions []GenericCondition) {
+	if !hasConditionFilters() {
+		c.add(conditions)
+		return
+	}
+	if shown := shownConditions(conditions); len(shown) > 0 {
+		c.add(shown)
+	}
+}
+
+func (c healthCounts) String() string {
+	s := fmt.Sprintf("%d objects (%d unhealthy), %d conditions: %d unhealthy, %d unknown, %d healthy",
+		c.Objects, c.UnhealthyObjects, c.Conditions, c.Unhealthy, c.Unknown, c.Healthy)
+	if c.Stale > 0 {
+		s += fmt.Sprintf(" (%d stale)", c.Stale)
+	}
+	return s
+}
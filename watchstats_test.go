@@ -0,0 +1,76 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHealthCountsAddShown(t *testing.T) {
+	defer func(v []string) { typeFlag = v }(typeFlag)
+	conds := []GenericCondition{
+		{Type: "Ready", Status: metav1.ConditionTrue},
+		{Type: "PodScheduled", Status: metav1.ConditionFalse},
+	}
+
+	tests := []struct {
+		name  string
+		types []string
+		want  healthCounts
+	}{
+		{"no filters", nil, healthCounts{Objects: 1, UnhealthyObjects: 1, Conditions: 2, Healthy: 1, Unhealthy: 1}},
+		{"hidden unhealthy condition", []string{"Ready"}, healthCounts{Objects: 1, Conditions: 1, Healthy: 1}},
+		{"all conditions hidden", []string{"Initialized"}, healthCounts{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typeFlag = tt.types
+			var c healthCounts
+			c.addShown(conds)
+			if c != tt.want {
+				t.Errorf("got %+v, want %+v", c, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthCountsAddUnknown(t *testing.T) {
+	defer func(v string) { treatUnknownAsFlag = v }(treatUnknownAsFlag)
+	conds := []GenericCondition{
+		{Type: "Ready", Status: metav1.ConditionUnknown},
+		{Type: "MemoryPressure", Status: metav1.ConditionFalse, NegativePolarity: true},
+	}
+
+	tests := []struct {
+		unknownAs string
+		want      healthCounts
+	}{
+		{"neutral", healthCounts{Objects: 1, UnhealthyObjects: 1, Conditions: 2, Healthy: 1, Unknown: 1}},
+		{"healthy", healthCounts{Objects: 1, Conditions: 2, Healthy: 2}},
+		{"unhealthy", healthCounts{Objects: 1, UnhealthyObjects: 1, Conditions: 2, Healthy: 1, Unhealthy: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.unknownAs, func(t *testing.T) {
+			treatUnknownAsFlag = tt.unknownAs
+			var c healthCounts
+			c.add(conds)
+			if c != tt.want {
+				t.Errorf("got %+v, want %+v", c, tt.want)
+			}
+		})
+	}
+}
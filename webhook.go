@@ -0,0 +1,99 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// enrichWebhookConfiguration adds a synthetic condition per admission webhook
+// reporting whether the Service backing the webhook has ready endpoints.
+// Broken webhooks block writes to the objects they intercept, but this never
+// shows up in the status of any object.
+func enrichWebhookConfiguration(ctx context.Context, kc *kubeClient, r *objectReport) {
+	if kc == nil {
+		return
+	}
+	webhooks, _, _ := unstructured.NestedSlice(r.Object.Object, "webhooks")
+	for _, v := range webhooks {
+		webhook, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		r.Conditions = append(r.Conditions, webhookBackendCondition(ctx, kc, webhook))
+	}
+}
+
+func webhookBackendCondition(ctx context.Context, kc *kubeClient, webhook map[string]any) GenericCondition {
+	name, _, _ := unstructured.NestedString(webhook, "name")
+	failurePolicy, _, _ := unstructured.NestedString(webhook, "failurePolicy")
+	url, _, _ := unstructured.NestedString(webhook, "clientConfig", "url")
+	svcNamespace, _, _ := unstructured.NestedString(webhook, "clientConfig", "service", "namespace")
+	svcName, _, _ := unstructured.NestedString(webhook, "clientConfig", "service", "name")
+
+	cond := GenericCondition{
+		Type:      name,
+		Synthetic: true,
+	}
+	if svcName == "" {
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "ExternalURL"
+		cond.Message = fmt.Sprintf("webhook is served at %s, its reachability is not checked", url)
+		return cond
+	}
+
+	svcRef := svcNamespace + "/" + svcName
+	cs, err := kc.Clientset()
+	if err == nil {
+		_, err = cs.CoreV1().Services(svcNamespace).Get(ctx, svcName, metav1.GetOptions{})
+	}
+	var ready, notReady int
+	if err == nil {
+		ready, notReady, _, err = serviceEndpoints(ctx, kc, svcNamespace, svcName)
+	}
+	switch {
+	case apierrors.IsNotFound(err):
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "ServiceNotFound"
+		cond.Message = fmt.Sprintf("backing service %s does not exist", svcRef)
+	case err != nil:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "BackendUnknown"
+		cond.Message = fmt.Sprintf("failed to check backing service %s: %v", svcRef, err)
+	case ready == 0:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "NoReadyEndpoints"
+		cond.Message = fmt.Sprintf("backing service %s has no ready endpoints (%d not ready)", svcRef, notReady)
+	default:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "BackendReady"
+		cond.Message = fmt.Sprintf("backing service %s has %d ready endpoints", svcRef, ready)
+	}
+
+	if cond.Status == metav1.ConditionFalse {
+		if failurePolicy == "Ignore" {
+			cond.Warning = true
+			cond.Notes = append(cond.Notes, "failurePolicy is Ignore, matching requests are admitted without this webhook")
+		} else {
+			cond.Notes = append(cond.Notes, "failurePolicy is Fail, matching requests are rejected")
+		}
+	}
+	return cond
+}
@@ -0,0 +1,324 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// enrichDeployment adds the replica counts of a Deployment to the header,
+// along with the progress of an ongoing rollout in the terms of kubectl
+// rollout status.
+func enrichDeployment(r *objectReport) {
+	obj := r.Object.Object
+	replicas, found, _ := unstructured.NestedInt64(obj, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	updated, _, _ := unstructured.NestedInt64(obj, "status", "updatedReplicas")
+	available, _, _ := unstructured.NestedInt64(obj, "status", "availableReplicas")
+	r.Header = append(r.Header, fmt.Sprintf("%s %d/%d updated, %d available", gray.Sprint("Replicas:"), updated, replicas, available))
+
+	if paused, _, _ := unstructured.NestedBool(obj, "spec", "paused"); paused {
+		r.Header = append(r.Header, gray.Sprint("Rollout: ")+activeTheme.warning.Sprint("paused (spec.paused is set)"))
+	} else if state := workloadRolloutState(r.Object); !state.Done {
+		r.Header = append(r.Header, gray.Sprint("Rollout: ")+state.Message)
+	}
+}
+
+// enrichStatefulSet adds the rollout progress of a StatefulSet to the header
+// since StatefulSets report very few conditions.
+func enrichStatefulSet(r *objectReport) {
+	obj := r.Object.Object
+	currentRevision, _, _ := unstructured.NestedString(obj, "status", "currentRevision")
+	updateRevision, _, _ := unstructured.NestedString(obj, "status", "updateRevision")
+	replicas, found, _ := unstructured.NestedInt64(obj, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	updated, _, _ := unstructured.NestedInt64(obj, "status", "updatedReplicas")
+	ready, _, _ := unstructured.NestedInt64(obj, "status", "readyReplicas")
+	partition, _, _ := unstructured.NestedInt64(obj, "spec", "updateStrategy", "rollingUpdate", "partition")
+
+	if currentRevision != "" || updateRevision != "" {
+		if currentRevision == updateRevision {
+			r.Header = append(r.Header, fmt.Sprintf("%s %s (up to date)", gray.Sprint("Revision:"), currentRevision))
+		} else {
+			r.Header = append(r.Header, fmt.Sprintf("%s current %s, update %s", gray.Sprint("Revision:"), currentRevision, updateRevision))
+		}
+	}
+	rollout := fmt.Sprintf("%s %d/%d updated, %d ready", gray.Sprint("Replicas:"), updated, replicas, ready)
+	if partition > 0 {
+		rollout += fmt.Sprintf(", partition %d (only ordinals >= %d are updated)", partition, partition)
+	}
+	r.Header = append(r.Header, rollout)
+}
+
+// enrichDaemonSet adds synthetic conditions about unavailable and
+// misscheduled daemon pods, since DaemonSets don't report conditions for
+// them.
+func enrichDaemonSet(ctx context.Context, kc *kubeClient, r *objectReport) {
+	obj := r.Object.Object
+	desired, _, _ := unstructured.NestedInt64(obj, "status", "desiredNumberScheduled")
+	unavailable, _, _ := unstructured.NestedInt64(obj, "status", "numberUnavailable")
+	misscheduled, _, _ := unstructured.NestedInt64(obj, "status", "numberMisscheduled")
+
+	available := GenericCondition{
+		Type:      "PodsAvailable",
+		Status:    metav1.ConditionTrue,
+		Reason:    "AllPodsAvailable",
+		Message:   fmt.Sprintf("all %d daemon pods are available", desired),
+		Synthetic: true,
+	}
+	if unavailable > 0 {
+		available.Status = metav1.ConditionFalse
+		available.Reason = "PodsUnavailable"
+		available.Message = fmt.Sprintf("%d of %d daemon pods are unavailable", unavailable, desired)
+		if missingNodesFlag && kc != nil {
+			nodes, err := nodesMissingDaemonPod(ctx, kc, r.Object)
+			if err != nil {
+				available.Notes = append(available.Notes, fmt.Sprintf("Failed to determine the nodes missing a daemon pod: %v", err))
+			} else if len(nodes) > 0 {
+				available.Notes = append(available.Notes, fmt.Sprintf("Nodes without a ready daemon pod: %s", strings.Join(nodes, ", ")))
+			}
+		}
+	}
+
+	misscheduledCond := GenericCondition{
+		Type:      "Misscheduled",
+		Status:    metav1.ConditionFalse,
+		Reason:    "NoPodsMisscheduled",
+		Synthetic: true,
+	}
+	if misscheduled > 0 {
+		misscheduledCond.Status = metav1.ConditionTrue
+		misscheduledCond.Reason = "PodsMisscheduled"
+		misscheduledCond.Message = fmt.Sprintf("%d daemon pods are running on nodes they are not supposed to run on", misscheduled)
+	}
+	r.Conditions = append(r.Conditions, available, misscheduledCond)
+}
+
+// nodesMissingDaemonPod returns the names of the nodes that the DaemonSet
+// should run on (based on its node selector, required node affinity and
+// tolerations, including the ones added to daemon pods by the controller)
+// but that don't have a ready pod of the DaemonSet.
+func nodesMissingDaemonPod(ctx context.Context, kc *kubeClient, ds *unstructured.Unstructured) ([]string, error) {
+	cs, err := kc.Clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	var spec struct {
+		Selector *metav1.LabelSelector  `json:"selector"`
+		Template corev1.PodTemplateSpec `json:"template"`
+	}
+	rawSpec, _, _ := unstructured.NestedMap(ds.Object, "spec")
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse DaemonSet spec: %w", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	pods, err := cs.CoreV1().Pods(ds.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	ready := sets.New[string]()
+	for _, pod := range pods.Items {
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				ready.Insert(pod.Spec.NodeName)
+			}
+		}
+	}
+
+	nodes, err := cs.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(spec.Template.Spec.NodeSelector).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	podSpec := spec.Template.Spec
+	tolerations := append(slices.Clone(podSpec.Tolerations), daemonPodTolerations(podSpec.HostNetwork)...)
+	var missing []string
+	for _, node := range nodes.Items {
+		if ready.Has(node.Name) || !toleratesTaints(tolerations, node.Spec.Taints) {
+			continue
+		}
+		if ok, err := matchesRequiredNodeAffinity(podSpec.Affinity, &node); err != nil {
+			return nil, fmt.Errorf("invalid node affinity: %w", err)
+		} else if !ok {
+			continue
+		}
+		missing = append(missing, node.Name)
+	}
+	return missing, nil
+}
+
+// daemonPodTolerations returns the tolerations that the DaemonSet controller
+// adds to daemon pods, so that they run on nodes with these conditions.
+func daemonPodTolerations(hostNetwork bool) []corev1.Toleration {
+	out := []corev1.Toleration{
+		{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+		{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+		{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	}
+	if hostNetwork {
+		out = append(out, corev1.Toleration{Key: corev1.TaintNodeNetworkUnavailable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule})
+	}
+	return out
+}
+
+// nodeSelectorOperators maps the operators of node selector requirements to
+// the ones of label selectors.
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// matchesRequiredNodeAffinity reports whether the node satisfies the
+// requiredDuringSchedulingIgnoredDuringExecution node affinity, i.e. any of
+// its terms, the same way the scheduler does.
+func matchesRequiredNodeAffinity(affinity *corev1.Affinity, node *corev1.Node) (bool, error) {
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true, nil
+	}
+	// only metadata.name is supported in matchFields
+	fields := labels.Set{"metadata.name": node.Name}
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		ok, err := matchesNodeSelectorRequirements(term.MatchExpressions, labels.Set(node.Labels))
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			continue
+		}
+		if ok, err = matchesNodeSelectorRequirements(term.MatchFields, fields); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func matchesNodeSelectorRequirements(reqs []corev1.NodeSelectorRequirement, set labels.Set) (bool, error) {
+	selector := labels.NewSelector()
+	for _, r := range reqs {
+		op, ok := nodeSelectorOperators[r.Operator]
+		if !ok {
+			return false, fmt.Errorf("unknown operator %q", r.Operator)
+		}
+		req, err := labels.NewRequirement(r.Key, op, r.Values)
+		if err != nil {
+			return false, err
+		}
+		selector = selector.Add(*req)
+	}
+	return selector.Matches(set), nil
+}
+
+// toleratesTaints reports whether the tolerations allow scheduling onto a
+// node with the given taints.
+func toleratesTaints(tolerations []corev1.Toleration, taints []corev1.Taint) bool {
+	for _, taint := range taints {
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for _, t := range tolerations {
+			if t.ToleratesTaint(&taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// podWorkloadKinds are the kinds whose pods are followed with --pods.
+var podWorkloadKinds = sets.New("Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job")
+
+func isPodWorkload(obj *unstructured.Unstructured) bool {
+	gk := obj.GroupVersionKind().GroupKind()
+	return (gk.Group == "apps" || gk.Group == "batch") && podWorkloadKinds.Has(gk.Kind)
+}
+
+// expandWorkloadPods returns the reports of the pods matching the selector
+// of the workload, with --pods. The number of pods is added to the header of
+// the workload, along with the errors of listing them.
+func expandWorkloadPods(ctx context.Context, kc *kubeClient, w *objectReport) []*objectReport {
+	selector, err := workloadSelector(w.Object)
+	if err == nil && selector == "" {
+		err = fmt.Errorf("%s has no selector", w.Kind)
+	}
+	if err != nil {
+		w.Header = append(w.Header, gray.Sprintf("Failed to list pods: %v", err))
+		return nil
+	}
+	dyn, err := kc.Dynamic()
+	if err != nil {
+		w.Header = append(w.Header, gray.Sprintf("Failed to list pods: %v", err))
+		return nil
+	}
+	pods, err := dyn.Resource(podsResource).Namespace(w.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		w.Header = append(w.Header, gray.Sprintf("Failed to list pods: %v", err))
+		return nil
+	}
+
+	var out []*objectReport
+	var notReady int
+	for i := range pods.Items {
+		r, err := newObjectReport(ctx, kc, &pods.Items[i])
+		if err != nil {
+			continue
+		}
+		if !podReady(r) {
+			notReady++
+		}
+		out = append(out, r)
+	}
+	pl := fmt.Sprintf("%s %d", gray.Sprint("Pods:"), len(out))
+	if notReady > 0 {
+		pl += activeTheme.warning.Sprintf(" (%d not ready)", notReady)
+	}
+	w.Header = append(w.Header, pl)
+	return out
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Ahmet Alp Balkan
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodesMissingDaemonPod(t *testing.T) {
+	node := func(name string, labels map[string]string, taints ...corev1.Taint) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}, Spec: corev1.NodeSpec{Taints: taints}}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent-1", Namespace: "kube-system", Labels: map[string]string{"app": "agent"}},
+		Spec:       corev1.PodSpec{NodeName: "ready"},
+		Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+	}
+	linux := map[string]string{"kubernetes.io/os": "linux"}
+	cs := fake.NewSimpleClientset(pod,
+		node("ready", linux),
+		node("missing", linux),
+		node("windows", map[string]string{"kubernetes.io/os": "windows"}),
+		node("tainted", linux, corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}),
+		node("not-ready", linux, corev1.Taint{Key: corev1.TaintNodeNotReady, Effect: corev1.TaintEffectNoExecute}),
+		node("excluded", linux),
+	)
+	kc := &kubeClient{clientset: cs}
+	kc.once.Do(func() {})
+
+	ds := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "kubernetes.io/os", Operator: corev1.NodeSelectorOpIn, Values: []string{"linux"}}},
+				MatchFields:      []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"excluded"}}},
+			}}},
+		}},
+	}}
+	template, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata":   map[string]any{"name": "agent", "namespace": "kube-system"},
+		"spec": map[string]any{
+			"selector": map[string]any{"matchLabels": map[string]any{"app": "agent"}},
+			"template": template,
+		},
+	}}
+
+	got, err := nodesMissingDaemonPod(context.Background(), kc, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	if want := []string{"missing", "not-ready"}; !slices.Equal(got, want) {
+		t.Errorf("nodesMissingDaemonPod() = %v, want %v", got, want)
+	}
+}
+
+func TestMatchesRequiredNodeAffinity(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1", Labels: map[string]string{"zone": "a", "cpus": "8"}}}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+	expr := func(key string, op corev1.NodeSelectorOperator, values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: key, Operator: op, Values: values}}}
+	}
+	tests := []struct {
+		name     string
+		affinity *corev1.Affinity
+		want     bool
+	}{
+		{name: "no affinity", want: true},
+		{name: "only preferred", affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}, want: true},
+		{name: "no terms", affinity: affinity()},
+		{name: "empty term", affinity: affinity(corev1.NodeSelectorTerm{})},
+		{name: "In", affinity: affinity(expr("zone", corev1.NodeSelectorOpIn, "a", "b")), want: true},
+		{name: "NotIn", affinity: affinity(expr("zone", corev1.NodeSelectorOpNotIn, "a"))},
+		{name: "Exists", affinity: affinity(expr("zone", corev1.NodeSelectorOpExists)), want: true},
+		{name: "DoesNotExist", affinity: affinity(expr("gpu", corev1.NodeSelectorOpDoesNotExist)), want: true},
+		{name: "Gt", affinity: affinity(expr("cpus", corev1.NodeSelectorOpGt, "4")), want: true},
+		{name: "Lt", affinity: affinity(expr("cpus", corev1.NodeSelectorOpLt, "4"))},
+		{name: "any term", affinity: affinity(expr("zone", corev1.NodeSelectorOpIn, "b"), expr("zone", corev1.NodeSelectorOpIn, "a")), want: true},
+		{name: "matchFields", affinity: affinity(corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+			{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"n1"}},
+		}}), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchesRequiredNodeAffinity(tt.affinity, node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("matchesRequiredNodeAffinity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}